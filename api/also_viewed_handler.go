@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
+	"github.com/ygo-skc/skc-suggestion-engine/downstream"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+)
+
+const (
+	alsoViewedOp = "Also Viewed"
+)
+
+// Cards viewed in the same sessions as the card, most sessions first - see covisit.Builder
+func getAlsoViewedHandler(res http.ResponseWriter, req *http.Request) {
+	cardID := chi.URLParam(req, "cardID")
+	logger, ctx := cUtil.InitRequest(req.Context(), apiName, alsoViewedOp, slog.String("card_id", cardID))
+	logger.Info("Getting cards viewed along with card")
+
+	entry, err := skcSuggestionEngineDBInterface.GetAlsoViewed(ctx, model.CardResource, cardID)
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+
+	cardIDs := cModel.CardIDs{cardID}
+	if entry != nil {
+		for _, coViewed := range entry.Cards {
+			cardIDs = append(cardIDs, coViewed.CardID)
+		}
+	}
+
+	cardsProto, err := downstream.YGO.CardService.GetCardsByIDProto(ctx, cardIDs)
+	if err != nil {
+		logger.Error("Could not retrieve card info", slog.Any("err", err))
+		err.HandleServerResponse(res)
+		return
+	}
+	cardData := cModel.BatchCardDataFromProto[cModel.CardIDs](cardsProto, cModel.CardIDAsKey)
+
+	subject, isPresent := cardData.CardInfo[cardID]
+	if !isPresent {
+		logger.Warn("Card not found")
+		cModel.HandleServerResponse(cModel.APIError{Message: "Cannot find card using ID " + cardID, StatusCode: http.StatusNotFound}, res)
+		return
+	}
+
+	alsoViewed := model.AlsoViewed{Card: subject, AlsoViewed: toAlsoViewedReferences(entry, cardData.CardInfo)}
+	if entry != nil {
+		alsoViewed.BuiltAt = &entry.BuiltAt
+	}
+	if parseIncludePopularity(req.URL.Query()) {
+		setPopularity(&alsoViewed.AlsoViewed)
+	}
+
+	res.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(res).Encode(alsoViewed); err != nil {
+		logger.Error("Could not encode also viewed response", slog.Any("err", err))
+	}
+}
+
+// References keep the order of the entry - occurrences are the number of sessions both cards were viewed in. Cards ygo-service doesn't know about are skipped.
+func toAlsoViewedReferences(entry *model.AlsoViewedEntry, cardData cModel.CardDataMap) []model.CardReference {
+	refs := make([]model.CardReference, 0)
+	if entry == nil {
+		return refs
+	}
+
+	for _, coViewed := range entry.Cards {
+		if card, isPresent := cardData[coViewed.CardID]; isPresent {
+			refs = append(refs, model.CardReference{Card: card, Occurrences: coViewed.Sessions})
+		}
+	}
+	return refs
+}
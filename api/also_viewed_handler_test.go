@@ -0,0 +1,24 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+	skc_testing "github.com/ygo-skc/skc-suggestion-engine/testing"
+)
+
+func TestToAlsoViewedReferences(t *testing.T) {
+	assert := assert.New(t)
+	darkMagician, magiciansSouls := skc_testing.CardMocks["Dark Magician"], skc_testing.CardMocks["Magicians' Souls"]
+
+	assert.Empty(toAlsoViewedReferences(nil, cModel.CardDataMap{}), "Cards never viewed with another card should have no references")
+
+	entry := &model.AlsoViewedEntry{ResourceName: model.CardResource, ResourceID: "38033121", Cards: []model.CoViewedCard{
+		{CardID: magiciansSouls.GetID(), Sessions: 3}, {CardID: "00000000", Sessions: 2}, {CardID: darkMagician.GetID(), Sessions: 1},
+	}}
+	refs := toAlsoViewedReferences(entry, cModel.CardDataMap{darkMagician.GetID(): darkMagician, magiciansSouls.GetID(): magiciansSouls})
+	assert.Equal([]model.CardReference{{Card: magiciansSouls, Occurrences: 3}, {Card: darkMagician, Occurrences: 1}}, refs,
+		"Unknown cards should be skipped and session order kept")
+}
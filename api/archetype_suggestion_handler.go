@@ -42,6 +42,12 @@ func getArchetypeSupportHandler(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	page, err := parsePage(req.URL.Query())
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+
 	if isBlackListed, err := skcSuggestionEngineDBInterface.IsBlackListed(ctx, "archetype", archetypeName); err != nil {
 		err.HandleServerResponse(res)
 		return
@@ -102,6 +108,14 @@ func getArchetypeSupportHandler(res http.ResponseWriter, req *http.Request) {
 	removeExclusions(ctx, &archetypalSuggestions)
 	archetypalSuggestions.Total = len(archetypalSuggestions.UsingName) + len(archetypalSuggestions.UsingText)
 
+	slices.SortFunc(archetypalSuggestions.UsingName, archetypeSort)
+	slices.SortFunc(archetypalSuggestions.UsingText, archetypeSort)
+	slices.SortFunc(archetypalSuggestions.Exclusions, archetypeSort)
+	archetypalSuggestions.SetNextCursor(page, model.PaginateLists(page, &archetypalSuggestions.UsingName, &archetypalSuggestions.UsingText))
+	if parseIncludePopularity(req.URL.Query()) {
+		archetypalSuggestions.Popularity = cardPopularity(archetypalSuggestions.UsingName, archetypalSuggestions.UsingText, archetypalSuggestions.Exclusions)
+	}
+
 	logger.Info("Returning archetypal suggestions",
 		slog.String("archetype_name", archetypeName),
 		slog.Int("cards_found_using_name", len(archetypalSuggestions.UsingName)),
@@ -164,6 +178,12 @@ func getArchetypeSupportV2Handler(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	page, err := parsePage(req.URL.Query())
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+
 	inherit, qualified, excluded, err := skcSuggestionEngineDBInterface.GetArchetypeMembers(ctx, archetypeName)
 	if err != nil {
 		logger.Error("Failed to retrieve archetype data", slog.Any("err", err))
@@ -203,6 +223,11 @@ func getArchetypeSupportV2Handler(res http.ResponseWriter, req *http.Request) {
 	slices.SortFunc(archetypeMembers.InheritMembers, archetypeSort)
 	slices.SortFunc(archetypeMembers.QualifiedMembers, archetypeSort)
 	slices.SortFunc(archetypeMembers.ExcludedMembers, archetypeSort)
+	archetypeMembers.SetNextCursor(page, model.PaginateLists(page, &archetypeMembers.InheritMembers, &archetypeMembers.QualifiedMembers,
+		&archetypeMembers.ExcludedMembers))
+	if parseIncludePopularity(req.URL.Query()) {
+		archetypeMembers.Popularity = cardPopularity(archetypeMembers.InheritMembers, archetypeMembers.QualifiedMembers, archetypeMembers.ExcludedMembers)
+	}
 
 	logger.Info("Returning archetypal suggestions",
 		slog.String("archetype_name", archetypeName),
@@ -217,5 +242,5 @@ func getArchetypeSupportV2Handler(res http.ResponseWriter, req *http.Request) {
 }
 
 func archetypeSort(a, b cModel.YGOCard) int {
-	return cmp.Compare(a.GetName(), b.GetName())
+	return cmp.Or(cmp.Compare(a.GetName(), b.GetName()), cmp.Compare(a.GetID(), b.GetID()))
 }
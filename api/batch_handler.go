@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"log/slog"
+	"maps"
 	"net/http"
 	"slices"
 	"strings"
@@ -84,6 +85,17 @@ func getBatchSuggestionsHandler(res http.ResponseWriter, req *http.Request) {
 	logger, ctx := cUtil.InitRequest(req.Context(), apiName, batchCardSuggestionsOp)
 	logger.Info("Batch card suggestions requested")
 
+	page, err := parsePage(req.URL.Query())
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+	strategy, err := parseRankingStrategy(req.URL.Query())
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+
 	if reqBody := parseBatchRequestBody(ctx, res, req); reqBody == nil {
 		res.WriteHeader(http.StatusOK)
 		if err := json.NewEncoder(res).Encode(
@@ -111,9 +123,20 @@ func getBatchSuggestionsHandler(res http.ResponseWriter, req *http.Request) {
 		}
 
 		subjects := cModel.BatchCardDataFromProto[cModel.CardIDs](cardsProto, cModel.CardIDAsKey)
-		suggestions := getBatchSuggestions(ctx, *subjects, relevantArchetypes, ccIDs.GetValues())
+		suggestions, err := getBatchSuggestions(ctx, *subjects, relevantArchetypes, ccIDs.GetValues())
+		if err != nil {
+			logger.Warn("Could not resolve named references - returning degraded batch suggestions", slog.Any("err", err))
+		}
+		if err := rankReferences(ctx, strategy, ccIDs.GetValues(), &suggestions.NamedMaterials, &suggestions.NamedReferences); err != nil {
+			logger.Warn("Could not rank batch suggestions using every signal", slog.String("sort", string(strategy)), slog.Any("err", err))
+			suggestions.Fail(model.RankingSignalsStage, err)
+		}
+		suggestions.Paginate(page)
+		if parseIncludePopularity(req.URL.Query()) {
+			setPopularity(&suggestions.NamedMaterials, &suggestions.NamedReferences)
+		}
 
-		res.WriteHeader(http.StatusOK)
+		res.WriteHeader(batchStatusCode(suggestions.Degradation))
 		if err := json.NewEncoder(res).Encode(suggestions); err != nil {
 			logger.Error("Could not encode batch card suggestions response", slog.Any("err", err), slog.Int("card_id_count", len(reqBody.CardIDs)))
 		}
@@ -122,8 +145,8 @@ func getBatchSuggestionsHandler(res http.ResponseWriter, req *http.Request) {
 }
 
 func getBatchSuggestions(ctx context.Context, subjects cModel.BatchCardData[cModel.CardIDs], relevantArchetypes []string,
-	ccIDs map[string]uint32) model.BatchCardSuggestions[cModel.CardIDs] {
-	suggestionByCardName := generateBatchSuggestionData(ctx, subjects, relevantArchetypes)
+	ccIDs map[string]uint32) (model.BatchCardSuggestions[cModel.CardIDs], *cModel.APIError) {
+	suggestionByCardName, err := generateBatchSuggestionData(ctx, subjects, relevantArchetypes)
 
 	uniqueNamedMaterialsByCardID, uniqueNamedReferencesByCardIDs := make(map[string]*model.CardReference, 5), make(map[string]*model.CardReference, 5)
 	uniqueMaterialArchetypes, uniqueReferencedArchetypes := make(map[string]struct{}, 5), make(map[string]struct{}, 5)
@@ -136,7 +159,9 @@ func getBatchSuggestions(ctx context.Context, subjects cModel.BatchCardData[cMod
 		MaterialArchetypes:    make([]string, 0, 5),
 		ReferencedArchetypes:  make([]string, 0, 5)}
 
-	for cardName, s := range suggestionByCardName {
+	// map order is random - intents of references found in multiple cards need to be merged in the same order every time
+	for _, cardName := range slices.Sorted(maps.Keys(suggestionByCardName)) {
+		s := suggestionByCardName[cardName]
 		model.RemoveSelfReference(cardName, &s.NamedReferences)
 		parseSuggestionReferences(s.NamedMaterials, uniqueNamedMaterialsByCardID, subjects.CardInfo, &suggestions.IntersectingResources)
 		parseSuggestionReferences(s.NamedReferences, uniqueNamedReferencesByCardIDs, subjects.CardInfo, &suggestions.IntersectingResources)
@@ -157,11 +182,14 @@ func getBatchSuggestions(ctx context.Context, subjects cModel.BatchCardData[cMod
 	slices.Sort(suggestions.IntersectingResources)
 	slices.Sort(suggestions.UnknownResources)
 
-	return suggestions
+	if err != nil {
+		suggestions.Fail(model.CardNameResolutionStage, err)
+	}
+	return suggestions, err
 }
 
 func generateBatchSuggestionData(ctx context.Context,
-	subjects cModel.BatchCardData[cModel.CardIDs], relevantArchetypes []string) map[string]model.CardSuggestions {
+	subjects cModel.BatchCardData[cModel.CardIDs], relevantArchetypes []string) (map[string]model.CardSuggestions, *cModel.APIError) {
 	numSubjects := len(subjects.CardInfo)
 	materialTextByCardName, effectTextByCardName := make(map[string]string, numSubjects), make(map[string]string, numSubjects)
 	var fullText4AllCards strings.Builder
@@ -173,15 +201,15 @@ func generateBatchSuggestionData(ctx context.Context,
 		fullText4AllCards.WriteByte('\n')
 	}
 
-	usd := suggest.GenerateUnparsedSuggestionData(ctx,
-		suggest.QuotedStringRegex.FindAllString(fullText4AllCards.String(), -1), relevantArchetypes)
+	usd, err := suggest.GenerateUnparsedSuggestionData(ctx,
+		suggest.TokenTexts(fullText4AllCards.String()), relevantArchetypes, cardReferenceIndex)
 
 	suggestionByCardName := make(map[string]model.CardSuggestions, numSubjects)
 	for cardName := range materialTextByCardName {
 		suggestionByCardName[cardName] = suggest.ParseSuggestionData(cardName, materialTextByCardName[cardName], effectTextByCardName[cardName], usd)
 	}
 
-	return suggestionByCardName
+	return suggestionByCardName, err
 }
 
 func groupArchetypes(archetypesToParse []string, uniqueArchetypeSet map[string]struct{}, uniqueArchetypes *[]string) {
@@ -208,20 +236,22 @@ func parseSuggestionReferences(
 		switch {
 		case refPreviouslyAdded:
 			uniqueReferencesByCardID[suggestionID].Occurrences += suggestion.Occurrences
+			uniqueReferencesByCardID[suggestionID].AddIntents(suggestion.Intents)
 		case isIntersecting:
 			if !slices.Contains(*intersectingResources, suggestionID) {
 				*intersectingResources = append(*intersectingResources, suggestionID)
 			}
 		default:
-			uniqueReferencesByCardID[suggestionID] = &model.CardReference{Card: suggestion.Card, Occurrences: suggestion.Occurrences}
+			uniqueReferencesByCardID[suggestionID] = &model.CardReference{Card: suggestion.Card, Occurrences: suggestion.Occurrences, Intents: slices.Clone(suggestion.Intents)}
 		}
 	}
 }
 
+// references ordered by card ID - callers are expected to sort them using SortCardReferences
 func getUniqueReferences(uniqueReferences map[string]*model.CardReference) []model.CardReference {
 	references := make([]model.CardReference, 0, len(uniqueReferences))
-	for _, ref := range uniqueReferences {
-		references = append(references, *ref)
+	for _, cardID := range slices.Sorted(maps.Keys(uniqueReferences)) {
+		references = append(references, *uniqueReferences[cardID])
 	}
 
 	return references
@@ -231,12 +261,29 @@ func getBatchSupportHandler(res http.ResponseWriter, req *http.Request) {
 	logger, ctx := cUtil.InitRequest(req.Context(), apiName, batchCardSupportOp)
 	logger.Info("Batch card support requested")
 
+	intents, err := parseIntentFilter(req.URL.Query())
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+	page, err := parsePage(req.URL.Query())
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+	strategy, err := parseRankingStrategy(req.URL.Query())
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+
 	if reqBody := parseBatchRequestBody(ctx, res, req); reqBody == nil {
 		res.WriteHeader(http.StatusOK)
 		if err := json.NewEncoder(res).Encode(
 			model.BatchCardSupport[cModel.CardIDs]{
 				ReferencedBy:          make([]model.CardReference, 0),
 				MaterialFor:           make([]model.CardReference, 0),
+				AliasedBy:             make([]model.CardReference, 0),
 				UnknownResources:      make(cModel.CardIDs, 0),
 				IntersectingResources: make(cModel.CardIDs, 0),
 			},
@@ -249,22 +296,46 @@ func getBatchSupportHandler(res http.ResponseWriter, req *http.Request) {
 		return
 	} else {
 		subjects := cModel.BatchCardDataFromProto[cModel.CardIDs](cardsProto, cModel.CardIDAsKey)
-		res.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(res).Encode(getBatchSupport(ctx, *subjects, nil)); err != nil {
+		support, err := getBatchSupport(ctx, *subjects, nil)
+		if err != nil {
+			logger.Warn("Could not retrieve support cards - returning degraded batch support", slog.Any("err", err))
+		} else if support.Degraded {
+			logger.Warn("Returning degraded batch support", slog.Any("errors", support.Errors))
+		}
+		support.ReferencedBy = model.FilterByIntent(support.ReferencedBy, intents)
+		support.MaterialFor = model.FilterByIntent(support.MaterialFor, intents)
+		support.AliasedBy = model.FilterByIntent(support.AliasedBy, intents)
+		if err := rankReferences(ctx, strategy, nil, &support.ReferencedBy, &support.MaterialFor, &support.AliasedBy); err != nil {
+			logger.Warn("Could not rank batch support using every signal", slog.String("sort", string(strategy)), slog.Any("err", err))
+			support.Fail(model.RankingSignalsStage, err)
+		}
+		support.Paginate(page)
+		if parseIncludePopularity(req.URL.Query()) {
+			setPopularity(&support.ReferencedBy, &support.MaterialFor, &support.AliasedBy)
+		}
+
+		res.WriteHeader(batchStatusCode(support.Degradation, model.SupportLookupStage))
+		if err := json.NewEncoder(res).Encode(support); err != nil {
 			logger.Error("Could not encode batch card support response", slog.Any("err", err))
 		}
 		return
 	}
 }
 
-func getBatchSupport(ctx context.Context, requestedCards cModel.BatchCardData[cModel.CardIDs], ccIDs map[string]uint32) model.BatchCardSupport[cModel.CardIDs] {
-	var ccIDsAWG *cUtil.AtomicWaitGroup[ygo.CardColors]
+// Support is still returned when err is not nil - it is flagged as degraded and has no references
+func getBatchSupport(ctx context.Context, requestedCards cModel.BatchCardData[cModel.CardIDs],
+	ccIDs map[string]uint32) (model.BatchCardSupport[cModel.CardIDs], *cModel.APIError) {
+	type cardColorRes struct {
+		ccIDs *ygo.CardColors
+		err   *cModel.APIError
+	}
+	var ccIDsAWG *cUtil.AtomicWaitGroup[cardColorRes]
 	if ccIDs == nil {
 		var wg sync.WaitGroup
-		ccIDsAWG = cUtil.NewAtomicWaitGroup[ygo.CardColors](&wg)
-		go func(awg *cUtil.AtomicWaitGroup[ygo.CardColors]) {
-			cc, _ := downstream.YGO.CardService.GetCardColorsProto(ctx) // retrieve card color IDs
-			awg.Store(cc)                                               // TODO: handle error
+		ccIDsAWG = cUtil.NewAtomicWaitGroup[cardColorRes](&wg)
+		go func(awg *cUtil.AtomicWaitGroup[cardColorRes]) {
+			cc, err := downstream.YGO.CardService.GetCardColorsProto(ctx) // retrieve card color IDs
+			awg.Store(&cardColorRes{ccIDs: cc, err: err})
 		}(ccIDsAWG)
 	}
 
@@ -273,37 +344,66 @@ func getBatchSupport(ctx context.Context, requestedCards cModel.BatchCardData[cM
 		UnknownResources:      requestedCards.UnknownResources,
 	}
 
-	cardNames := make([]string, len(requestedCards.CardInfo))
-	ind := 0
+	subjects := make([]cModel.YGOCard, 0, len(requestedCards.CardInfo))
 	for _, card := range requestedCards.CardInfo {
-		cardNames[ind] = card.GetName()
-		ind++
+		subjects = append(subjects, card)
 	}
 
-	if cardRefsProto, err := downstream.YGO.CardService.GetCardsReferencingNameInEffectProto(ctx, cardNames); err != nil {
-		// TODO: error handling
+	supportByCardID, err := getSupportReferences(ctx, subjects)
+	if err != nil {
+		support.ReferencedBy, support.MaterialFor, support.AliasedBy = make([]model.CardReference, 0), make([]model.CardReference, 0), make([]model.CardReference, 0)
+		support.Fail(model.SupportLookupStage, err)
 	} else {
-		uniqueReferenceByCardID, uniqueMaterialByCardIDs := make(map[string]*model.CardReference), make(map[string]*model.CardReference)
+		uniqueReferenceByCardID, uniqueMaterialByCardIDs, uniqueAliasByCardIDs := make(map[string]*model.CardReference), make(map[string]*model.CardReference),
+			make(map[string]*model.CardReference)
 		for _, card := range requestedCards.CardInfo {
-			referencedBy, materialFor := determineSupportCards(card, cModel.YGOCardListRESTFromProto(cardRefsProto))
-			if len(referencedBy) > 0 {
-				parseSuggestionReferences(referencedBy, uniqueReferenceByCardID, requestedCards.CardInfo, &support.IntersectingResources)
+			cardSupport := supportByCardID[card.GetID()]
+			if len(cardSupport.ReferencedBy) > 0 {
+				parseSuggestionReferences(cardSupport.ReferencedBy, uniqueReferenceByCardID, requestedCards.CardInfo, &support.IntersectingResources)
 			}
-			if len(materialFor) > 0 {
-				parseSuggestionReferences(materialFor, uniqueMaterialByCardIDs, requestedCards.CardInfo, &support.IntersectingResources)
+			if len(cardSupport.MaterialFor) > 0 {
+				parseSuggestionReferences(cardSupport.MaterialFor, uniqueMaterialByCardIDs, requestedCards.CardInfo, &support.IntersectingResources)
+			}
+			if len(cardSupport.AliasedBy) > 0 {
+				parseSuggestionReferences(cardSupport.AliasedBy, uniqueAliasByCardIDs, requestedCards.CardInfo, &support.IntersectingResources)
 			}
 		}
 
 		support.ReferencedBy = getUniqueReferences(uniqueReferenceByCardID)
 		support.MaterialFor = getUniqueReferences(uniqueMaterialByCardIDs)
+		support.AliasedBy = getUniqueReferences(uniqueAliasByCardIDs)
 
 		slices.Sort(support.IntersectingResources)
 		slices.Sort(support.UnknownResources)
 		if ccIDsAWG != nil {
-			ccIDs = ccIDsAWG.Load().GetValues()
+			// references are still returned when colors can't be retrieved - they just aren't grouped by color
+			if ccRes := ccIDsAWG.Load(); ccRes.err != nil {
+				support.Fail(model.CardColorsStage, ccRes.err)
+			} else {
+				ccIDs = ccRes.ccIDs.GetValues()
+			}
 		}
 		slices.SortStableFunc(support.ReferencedBy, suggest.SortCardReferences(ccIDs))
 		slices.SortStableFunc(support.MaterialFor, suggest.SortCardReferences(ccIDs))
+		slices.SortStableFunc(support.AliasedBy, suggest.SortCardReferences(ccIDs))
+	}
+	return support, err
+}
+
+// 200 when every stage succeeded. When one of the required stages failed there is nothing useful to return so the status of that failure is used,
+// otherwise 207 is used as the response is only partially complete.
+func batchStatusCode(d model.Degradation, required ...model.SuggestionStage) int {
+	for _, e := range d.Errors {
+		if slices.Contains(required, e.Stage) {
+			if e.StatusCode >= http.StatusBadRequest {
+				return e.StatusCode
+			}
+			return http.StatusBadGateway
+		}
+	}
+
+	if d.Degraded {
+		return http.StatusMultiStatus
 	}
-	return support
+	return http.StatusOK
 }
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ygo-skc/skc-go/common/v3/client"
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	"github.com/ygo-skc/skc-go/common/v3/ygo"
+	"github.com/ygo-skc/skc-suggestion-engine/downstream"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+	"github.com/ygo-skc/skc-suggestion-engine/reference"
+	skc_testing "github.com/ygo-skc/skc-suggestion-engine/testing"
+)
+
+// card colors can't be retrieved
+type cardColorsFailingClient struct {
+	skc_testing.YGOCardClientMock
+}
+
+func (svc cardColorsFailingClient) GetCardColorsProto(ctx context.Context) (*ygo.CardColors, *cModel.APIError) {
+	return nil, &cModel.APIError{Message: "Service unavailable", StatusCode: http.StatusServiceUnavailable}
+}
+
+func TestBatchSupportWithoutCardColors(t *testing.T) {
+	// setup
+	assert := assert.New(t)
+	downstream.YGO = client.YGOClientImpV1{CardService: cardColorsFailingClient{}}
+	cardReferenceIndex = reference.NewIndex(nil, determineSupportCards)
+	t.Cleanup(func() {
+		downstream.YGO = client.YGOClientImpV1{CardService: skc_testing.YGOCardClientMock{}}
+		cardReferenceIndex = nil
+	})
+
+	cards := make([]cModel.YGOCard, 0, len(skc_testing.CardMocks))
+	for _, card := range skc_testing.CardMocks {
+		cards = append(cards, card)
+	}
+	cardReferenceIndex.Build(skc_testing.TestContext, cards)
+
+	hamon := skc_testing.CardMocks["Hamon, Lord of Striking Thunder"]
+	support, err := getBatchSupport(skc_testing.TestContext, cModel.BatchCardData[cModel.CardIDs]{CardInfo: cModel.CardDataMap{hamon.ID: hamon}}, nil)
+	assert.Nil(err)
+	assert.Len(support.MaterialFor, 2, "Support should still be returned when card colors are missing")
+	assert.True(support.Failed(model.CardColorsStage))
+	assert.Equal(http.StatusMultiStatus, batchStatusCode(support.Degradation, model.SupportLookupStage))
+}
+
+func TestBatchStatusCode(t *testing.T) {
+	assert := assert.New(t)
+
+	var d model.Degradation
+	assert.Equal(http.StatusOK, batchStatusCode(d, model.SupportLookupStage))
+
+	d.Fail(model.CardColorsStage, &cModel.APIError{Message: "Service unavailable", StatusCode: http.StatusServiceUnavailable})
+	assert.Equal(http.StatusMultiStatus, batchStatusCode(d, model.SupportLookupStage), "Partial failure")
+
+	d.Fail(model.SupportLookupStage, &cModel.APIError{Message: "Timeout", StatusCode: http.StatusGatewayTimeout})
+	assert.Equal(http.StatusGatewayTimeout, batchStatusCode(d, model.SupportLookupStage), "Required stage failed")
+	assert.Equal(http.StatusMultiStatus, batchStatusCode(d), "Nothing is required")
+
+	d.Fail(model.SupportLookupStage, &cModel.APIError{Message: "Duplicate"})
+	assert.Len(d.Errors, 2, "A stage should only be reported once")
+}
+
+func TestParsePage(t *testing.T) {
+	assert := assert.New(t)
+
+	page, err := parsePage(url.Values{})
+	assert.Nil(err)
+	assert.Equal(model.Page{}, page, "Everything should be returned by default")
+
+	page, err = parsePage(url.Values{"limit": {"2"}, "cursor": {model.EncodeCursor(4)}})
+	assert.Nil(err)
+	assert.Equal(model.Page{Limit: 2, Offset: 4}, page)
+
+	for _, query := range []url.Values{{"limit": {"0"}}, {"limit": {"501"}}, {"limit": {"ten"}}, {"cursor": {"not a cursor"}}, {"cursor": {model.EncodeCursor(-1)}}} {
+		_, err = parsePage(query)
+		assert.NotNil(err, query.Encode())
+		assert.Equal(http.StatusBadRequest, err.StatusCode)
+	}
+}
+
+func TestBatchSupportPagination(t *testing.T) {
+	// setup
+	assert := assert.New(t)
+	cardReferenceIndex = reference.NewIndex(nil, determineSupportCards)
+	t.Cleanup(func() { cardReferenceIndex = nil })
+
+	cards := make([]cModel.YGOCard, 0, len(skc_testing.CardMocks))
+	for _, card := range skc_testing.CardMocks {
+		cards = append(cards, card)
+	}
+	cardReferenceIndex.Build(skc_testing.TestContext, cards)
+
+	subjects := cModel.CardDataMap{}
+	for _, cardName := range []string{"Dark Magician", "Hamon, Lord of Striking Thunder", "Elemental HERO Neos"} {
+		subjects[skc_testing.CardMocks[cardName].ID] = skc_testing.CardMocks[cardName]
+	}
+	full, _ := getBatchSupport(skc_testing.TestContext, cModel.BatchCardData[cModel.CardIDs]{CardInfo: subjects}, skc_testing.CardColors)
+	for range 5 {
+		again, _ := getBatchSupport(skc_testing.TestContext, cModel.BatchCardData[cModel.CardIDs]{CardInfo: subjects}, skc_testing.CardColors)
+		assert.Equal(full, again, "Support should always be returned in the same order")
+	}
+
+	// walk every page
+	materialFor := make([]model.CardReference, 0)
+	page := model.Page{Limit: 2}
+	for pages := 1; ; pages++ {
+		support, _ := getBatchSupport(skc_testing.TestContext, cModel.BatchCardData[cModel.CardIDs]{CardInfo: subjects}, skc_testing.CardColors)
+		support.Paginate(page)
+		assert.LessOrEqual(len(support.MaterialFor), 2)
+		materialFor = append(materialFor, support.MaterialFor...)
+
+		if support.NextCursor == "" {
+			break
+		}
+		assert.Less(pages, len(full.MaterialFor), "Pagination should end")
+		page.Offset, _ = model.DecodeCursor(support.NextCursor)
+	}
+	assert.Equal(full.MaterialFor, materialFor, "Pages should contain every reference once, in order")
+}
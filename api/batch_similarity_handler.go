@@ -0,0 +1,180 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
+	"github.com/ygo-skc/skc-suggestion-engine/downstream"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+	"github.com/ygo-skc/skc-suggestion-engine/popularity"
+	"github.com/ygo-skc/skc-suggestion-engine/usage"
+	"github.com/ygo-skc/skc-suggestion-engine/validation"
+)
+
+const (
+	batchSimilarCardsOp = "Batch Similar Cards"
+
+	batchSimilarAggregateLimit   = 20
+	maxConcurrentSimilarSearches = 5
+)
+
+// Finds cards similar to every card in the request (eg: a deck). Cards that are part of the request are never returned as matches.
+func getBatchSimilarCardsHandler(res http.ResponseWriter, req *http.Request) {
+	includeScores := req.URL.Query().Get("includeScores") == "true"
+	logger, ctx := cUtil.InitRequest(req.Context(), apiName, batchSimilarCardsOp, slog.Bool("include_scores", includeScores))
+	ctx = usage.WithOperation(ctx, batchSimilarCardsOp)
+	logger.Info("Batch similar cards requested")
+
+	filter, err := parseSimilarCardsFilter(req.URL.Query())
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+	if err := validation.ValidateSimilarCardsFilter(filter); err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+
+	if reqBody := parseBatchRequestBody(ctx, res, req); reqBody == nil {
+		res.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(res).Encode(
+			model.BatchSimilarCards[cModel.CardIDs]{
+				Subjects:              make([]model.SimilarCards, 0),
+				Aggregate:             make([]model.CardReference, 0),
+				UnknownResources:      make(cModel.CardIDs, 0),
+				IntersectingResources: make(cModel.CardIDs, 0),
+			},
+		); err != nil {
+			logger.Error("Could not encode empty batch similar cards response", slog.Any("err", err))
+		}
+		return
+	} else if cardsProto, err := downstream.YGO.CardService.GetCardsByIDProto(ctx, reqBody.CardIDs); err != nil {
+		err.HandleServerResponse(res)
+		return
+	} else {
+		subjects := cModel.BatchCardDataFromProto[cModel.CardIDs](cardsProto, cModel.CardIDAsKey)
+		similarCards, err := getBatchSimilarCards(ctx, *subjects, filter, includeScores)
+		if err != nil {
+			logger.Error("Could not retrieve batch similar cards", slog.Any("err", err))
+			err.HandleServerResponse(res)
+			return
+		}
+		if parseIncludePopularity(req.URL.Query()) {
+			for i := range similarCards.Subjects {
+				similarCards.Subjects[i].Popularity = cardPopularity(similarCards.Subjects[i].Matches)
+			}
+			setPopularity(&similarCards.Aggregate)
+		}
+
+		res.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(res).Encode(similarCards); err != nil {
+			logger.Error("Could not encode batch similar cards response", slog.Any("err", err), slog.Int("card_id_count", len(reqBody.CardIDs)))
+		}
+		return
+	}
+}
+
+func getBatchSimilarCards(ctx context.Context, subjects cModel.BatchCardData[cModel.CardIDs],
+	filter model.SimilarCardsFilter, includeScores bool) (*model.BatchSimilarCards[cModel.CardIDs], *cModel.APIError) {
+	cards := make([]cModel.YGOCard, 0, len(subjects.CardInfo))
+	for _, card := range subjects.CardInfo {
+		cards = append(cards, card)
+	}
+	slices.SortFunc(cards, func(a, b cModel.YGOCard) int { return cmp.Compare(a.GetID(), b.GetID()) })
+
+	embeddedQueries, err := embedCardEffects(ctx, cards)
+	if err != nil {
+		return nil, err
+	}
+
+	// subjects are excluded from every search so they never use up the candidates of another subject
+	subjectIDs := make([]string, len(cards))
+	for i, card := range cards {
+		subjectIDs[i] = card.GetID()
+	}
+	resultsBySubject := make([][]model.VectorSearchResult, len(cards))
+	rankingStageBySubject := make([]model.RankingStage, len(cards))
+	errs := make([]*cModel.APIError, len(cards))
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, maxConcurrentSimilarSearches)
+	for i, card := range cards {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			resultsBySubject[i], rankingStageBySubject[i], errs[i] = searchSimilarCards(ctx, card, embeddedQueries[card.GetID()], filter, subjectIDs)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	batchSimilarCards := model.BatchSimilarCards[cModel.CardIDs]{
+		Subjects:              make([]model.SimilarCards, 0, len(cards)),
+		UnknownResources:      subjects.UnknownResources,
+		IntersectingResources: make(cModel.CardIDs, 0),
+	}
+
+	// merge results of every subject
+	uniqueResults := make([]model.VectorSearchResult, 0)
+	occurrencesByID, bestRerankScoreByID := make(map[string]int), make(map[string]float64)
+	for _, results := range resultsBySubject {
+		for _, r := range results {
+			if _, isPresent := occurrencesByID[r.ID]; !isPresent {
+				uniqueResults = append(uniqueResults, r)
+			}
+			occurrencesByID[r.ID]++
+			bestRerankScoreByID[r.ID] = max(bestRerankScoreByID[r.ID], r.RerankScore)
+		}
+	}
+
+	cardData := make(cModel.CardDataMap)
+	if len(uniqueResults) > 0 {
+		if cardData, err = fetchVectorSearchCards(ctx, uniqueResults); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, card := range cards {
+		similarCards := model.SimilarCards{Card: card, RankingStage: rankingStageBySubject[i]}
+		if matches, scores := toSimilarCards(resultsBySubject[i], cardData); includeScores {
+			similarCards.Matches, similarCards.Scores = matches, scores
+		} else {
+			similarCards.Matches = matches
+		}
+		batchSimilarCards.Subjects = append(batchSimilarCards.Subjects, similarCards)
+	}
+
+	batchSimilarCards.Aggregate = make([]model.CardReference, 0, len(occurrencesByID))
+	for _, r := range uniqueResults {
+		if card, isPresent := cardData[r.ID]; isPresent {
+			batchSimilarCards.Aggregate = append(batchSimilarCards.Aggregate, model.CardReference{Card: card, Occurrences: occurrencesByID[r.ID]})
+		}
+	}
+
+	// cards similar to many subjects first, ties broken by how relevant the reranker thought they were then by popularity
+	slices.SortStableFunc(batchSimilarCards.Aggregate, func(a, b model.CardReference) int {
+		return cmp.Or(
+			cmp.Compare(b.Occurrences, a.Occurrences),
+			cmp.Compare(bestRerankScoreByID[b.Card.GetID()], bestRerankScoreByID[a.Card.GetID()]),
+			cmp.Compare(popularity.CardScores.Score(b.Card.GetID()), popularity.CardScores.Score(a.Card.GetID())),
+		)
+	})
+	batchSimilarCards.Aggregate = batchSimilarCards.Aggregate[:min(len(batchSimilarCards.Aggregate), batchSimilarAggregateLimit)]
+
+	slices.Sort(batchSimilarCards.UnknownResources)
+	return &batchSimilarCards, nil
+}
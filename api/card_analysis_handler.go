@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
+	"github.com/ygo-skc/skc-suggestion-engine/downstream"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+	"github.com/ygo-skc/skc-suggestion-engine/suggest"
+	"github.com/ygo-skc/skc-suggestion-engine/validation"
+)
+
+const (
+	cardAnalysisOp = "Card Analysis"
+)
+
+// Suggestions for text that doesn't belong to a card in the DB - eg: custom cards or drafts.
+// Returns the same info as the card suggestion endpoint.
+func analyzeCardTextHandler(res http.ResponseWriter, req *http.Request) {
+	logger, ctx := cUtil.InitRequest(req.Context(), apiName, cardAnalysisOp)
+	logger.Info("Card text analysis requested")
+
+	var reqBody model.CardAnalysisRequest
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		logger.Error("Error occurred while reading card analysis request body", slog.Any("err", err))
+		cModel.HandleServerResponse(cModel.APIError{Message: "Body could not be deserialized", StatusCode: http.StatusBadRequest}, res)
+		return
+	}
+
+	if err := validation.ValidateCardAnalysisRequest(reqBody); err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+
+	suggestions, err := analyzeCardText(ctx, reqBody)
+	if err != nil {
+		logger.Error("Failed to analyze card text", slog.Any("err", err))
+		err.HandleServerResponse(res)
+		return
+	}
+
+	logger.Info("Card text analyzed",
+		slog.String("card_name", reqBody.Name),
+		slog.Int("named_materials", len(suggestions.NamedMaterials)),
+		slog.Int("named_references", len(suggestions.NamedReferences)))
+
+	res.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(res).Encode(suggestions); err != nil {
+		logger.Error("Could not encode card analysis response", slog.Any("err", err))
+	}
+}
+
+func analyzeCardText(ctx context.Context, reqBody model.CardAnalysisRequest) (*model.CardSuggestions, *cModel.APIError) {
+	subject := newDraftCard(reqBody)
+
+	ccIDs, err := downstream.YGO.CardService.GetCardColorsProto(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// draft cards aren't part of any archetype in the DB - archetypes are determined using quoted text instead
+	referencedArchetypes, err := skcSuggestionEngineDBInterface.GetArchetypesByName(ctx, suggest.TokenTexts(subject.GetEffect()))
+	if err != nil {
+		return nil, err
+	}
+
+	materialText := strings.TrimSpace(reqBody.Materials) // same as the material line newDraftCard adds to the effect
+	if materialText == "" {
+		materialText = cModel.GetPotentialMaterialsAsString(subject)
+	}
+
+	cardNames := append([]string{subject.GetName()}, suggest.FindAliases(subject.GetEffect()).NameAliases...)
+	suggestions, err := suggestFromCardText(ctx, subject, materialText, ccIDs.GetValues(), referencedArchetypes, draftCardArchetypes(cardNames, referencedArchetypes))
+	if err != nil {
+		cUtil.RetrieveLogger(ctx).Warn("Could not resolve named references - returning degraded analysis", slog.Any("err", err))
+	}
+	return &suggestions, nil
+}
+
+// materials are placed at the start of the effect the same way they are on real cards
+func newDraftCard(reqBody model.CardAnalysisRequest) cModel.YGOCardREST {
+	card := cModel.YGOCardREST{Name: strings.TrimSpace(reqBody.Name), Effect: strings.TrimSpace(reqBody.Effect)}
+	if materials := strings.TrimSpace(reqBody.Materials); materials != "" {
+		card.Effect = materials + "\n" + card.Effect
+	}
+	if monsterType := strings.TrimSpace(reqBody.MonsterType); monsterType != "" {
+		card.MonsterType = &monsterType
+	}
+	return card
+}
+
+// a card is part of an archetype when the archetype is part of its name or one of its name aliases
+func draftCardArchetypes(cardNames []string, archetypes []string) []string {
+	relevantArchetypes := make([]string, 0)
+	for _, archetype := range archetypes {
+		if slices.ContainsFunc(cardNames, func(cardName string) bool { return strings.Contains(cardName, archetype) }) {
+			relevantArchetypes = append(relevantArchetypes, archetype)
+		}
+	}
+	return relevantArchetypes
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ygo-skc/skc-go/common/v3/client"
+	"github.com/ygo-skc/skc-suggestion-engine/db"
+	"github.com/ygo-skc/skc-suggestion-engine/downstream"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+	"github.com/ygo-skc/skc-suggestion-engine/suggest"
+	skc_testing "github.com/ygo-skc/skc-suggestion-engine/testing"
+	"github.com/ygo-skc/skc-suggestion-engine/validation"
+)
+
+func TestNewDraftCard(t *testing.T) {
+	assert := assert.New(t)
+
+	card := newDraftCard(model.CardAnalysisRequest{
+		Name: " Dark Magician Knight Rider ", Effect: `Must be Special Summoned with "Dark Magician".`, MonsterType: "Spellcaster/Fusion/Effect",
+		Materials: `"Dark Magician" + "Gaia the Fierce Knight"`,
+	})
+	assert.Equal("Dark Magician Knight Rider", card.GetName())
+	assert.Equal("\"Dark Magician\" + \"Gaia the Fierce Knight\"\nMust be Special Summoned with \"Dark Magician\".", card.GetEffect(), "Materials should be the first line of the effect")
+	assert.Equal("Spellcaster/Fusion/Effect", card.GetMonsterType())
+
+	card = newDraftCard(model.CardAnalysisRequest{Name: "Pot of Custom", Effect: "Draw 2 cards."})
+	assert.Equal("Draw 2 cards.", card.GetEffect())
+	assert.Nil(card.MonsterType)
+}
+
+func TestAnalyzeCardTextMaterials(t *testing.T) {
+	// setup
+	assert := assert.New(t)
+	downstream.YGO = client.YGOClientImpV1{CardService: skc_testing.YGOCardClientMock{}}
+	skcSuggestionEngineDBInterface, _ = db.NewSKCSuggestionEngineDAOInMemory("")
+	t.Cleanup(func() { skcSuggestionEngineDBInterface = skc_testing.SKCSuggestionEngineDAOImplementation{} })
+
+	suggestions, err := analyzeCardText(skc_testing.TestContext, model.CardAnalysisRequest{
+		Name: "Dark Magician Hero", Effect: "Must be Fusion Summoned.", MonsterType: "Spellcaster/Fusion/Effect",
+		Materials: "  \"Dark Magician\" + \"Elemental HERO Neos\"\n",
+	})
+	assert.Nil(err)
+	assert.Len(suggestions.NamedMaterials, 2)
+	assert.Empty(suggestions.NamedReferences, "Materials surrounded by whitespace should not be counted as references")
+}
+
+func TestDraftCardArchetypes(t *testing.T) {
+	assert := assert.New(t)
+
+	tokens := suggest.TokenTexts(`Add 1 "Blue-Eyes" monster and 1 "Dark Magician" from your Deck to your hand.`)
+	assert.Equal([]string{"Blue-Eyes", "Dark Magician"}, tokens)
+
+	assert.Equal([]string{"Blue-Eyes"}, draftCardArchetypes([]string{"Blue-Eyes Custom Dragon"}, []string{"Blue-Eyes", "Dark Magician"}))
+	assert.Empty(draftCardArchetypes([]string{"Custom Dragon"}, []string{"Blue-Eyes"}))
+	assert.Equal([]string{"Blue-Eyes"}, draftCardArchetypes([]string{"Custom Dragon", "Blue-Eyes White Dragon"}, []string{"Blue-Eyes"}), "Name aliases should be used")
+}
+
+func TestCardAnalysisRequestValidation(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(validation.ValidateCardAnalysisRequest(model.CardAnalysisRequest{Name: "Pot of Custom", Effect: "Draw 2 cards."}))
+
+	errs := validation.ValidateCardAnalysisRequest(model.CardAnalysisRequest{Name: "Pot of Custom"})
+	assert.Equal(1, errs.TotalErrors)
+	assert.Equal("Effect", errs.Errors[0].Field)
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
+	"github.com/ygo-skc/skc-suggestion-engine/downstream"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+	"github.com/ygo-skc/skc-suggestion-engine/usage"
+	"github.com/ygo-skc/skc-suggestion-engine/validation"
+)
+
+const (
+	cardEmbeddingIngestionOp       = "Card Embedding Ingestion"
+	cardEmbeddingIngestionStatusOp = "Card Embedding Ingestion Status"
+	cardEmbeddingDedupeOp          = "Card Embedding Dedupe"
+)
+
+// Starts a background job that embeds the text of the requested cards (and cards found in requested products) - cards whose text didn't change are skipped.
+func submitCardEmbeddingIngestionHandler(res http.ResponseWriter, req *http.Request) {
+	logger, ctx := cUtil.InitRequest(req.Context(), apiName, cardEmbeddingIngestionOp)
+	ctx = usage.WithOperation(ctx, cardEmbeddingIngestionOp)
+	logger.Info("Starting card embedding ingestion")
+
+	var reqBody model.CardEmbeddingIngestionRequest
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		logger.Error("Error occurred while reading card embedding ingestion request body", slog.Any("err", err))
+		cModel.HandleServerResponse(cModel.APIError{Message: "Body could not be deserialized", StatusCode: http.StatusBadRequest}, res)
+		return
+	}
+
+	if err := validation.ValidateCardEmbeddingIngestionRequest(reqBody); err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+
+	if len(reqBody.CardIDs) == 0 && len(reqBody.ProductIDs) == 0 {
+		cModel.HandleServerResponse(cModel.APIError{Message: "At least one card ID or product ID is required", StatusCode: http.StatusBadRequest}, res)
+		return
+	}
+
+	cards, unknownCardIDs, releaseDates, err := loadCardsForIngestion(ctx, reqBody)
+	if err != nil {
+		logger.Error("Could not load cards to embed", slog.Any("err", err))
+		err.HandleServerResponse(res)
+		return
+	}
+
+	job, started := cardEmbeddingIngester.Start(ctx, cards, unknownCardIDs, releaseDates)
+	if !started {
+		cModel.HandleServerResponse(cModel.APIError{Message: "Another card embedding ingestion job is running", StatusCode: http.StatusConflict}, res)
+		return
+	}
+
+	res.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(res).Encode(job); err != nil {
+		logger.Error("Could not encode card embedding ingestion response", slog.Any("err", err), slog.String("job_id", job.ID))
+	}
+}
+
+func getCardEmbeddingIngestionHandler(res http.ResponseWriter, req *http.Request) {
+	jobID := chi.URLParam(req, "jobID")
+
+	logger, _ := cUtil.InitRequest(req.Context(), apiName, cardEmbeddingIngestionStatusOp, slog.String("job_id", jobID))
+	logger.Info("Getting card embedding ingestion status")
+
+	job, isPresent := cardEmbeddingIngester.Job(jobID)
+	if !isPresent {
+		cModel.HandleServerResponse(cModel.APIError{Message: "Ingestion job not found", StatusCode: http.StatusNotFound}, res)
+		return
+	}
+
+	res.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(res).Encode(job); err != nil {
+		logger.Error("Could not encode card embedding ingestion status response", slog.Any("err", err))
+	}
+}
+
+// Lists cards with more than one cardEmbedding document. Duplicates are only removed when confirm=true - the unique card ID index is then created.
+func dedupeCardEmbeddingsHandler(res http.ResponseWriter, req *http.Request) {
+	confirm := req.URL.Query().Get("confirm") == "true"
+
+	logger, ctx := cUtil.InitRequest(req.Context(), apiName, cardEmbeddingDedupeOp, slog.Bool("confirm", confirm))
+	logger.Info("Card embedding dedupe requested")
+
+	dedupe, err := skcSuggestionEngineDBInterface.DedupeCardEmbeddings(ctx, confirm)
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+
+	if confirm {
+		logger.Warn("Removed duplicate card embeddings", slog.Int("cards", len(dedupe.CardIDs)), slog.Int64("removed", dedupe.Removed))
+	} else {
+		logger.Info("Found duplicate card embeddings - nothing was removed", slog.Int("cards", len(dedupe.CardIDs)), slog.Int("duplicates", dedupe.Duplicates))
+	}
+
+	res.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(res).Encode(dedupe); err != nil {
+		logger.Error("Could not encode card embedding dedupe response", slog.Any("err", err))
+	}
+}
+
+// resolves requested card IDs and product contents to card data, de-duplicating cards found more than once.
+// Cards found in products are released on the date of the earliest product containing them.
+func loadCardsForIngestion(ctx context.Context, reqBody model.CardEmbeddingIngestionRequest) ([]cModel.YGOCard, cModel.CardIDs, map[string]string, *cModel.APIError) {
+	logger := cUtil.RetrieveLogger(ctx)
+	cardsByID := make(cModel.CardDataMap)
+	unknownCardIDs := make(cModel.CardIDs, 0)
+	releaseDates := make(map[string]string)
+
+	if len(reqBody.CardIDs) > 0 {
+		cardsProto, err := downstream.YGO.CardService.GetCardsByIDProto(ctx, reqBody.CardIDs)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		batchCardData := cModel.BatchCardDataFromProto[cModel.CardIDs](cardsProto, cModel.CardIDAsKey)
+		for id, card := range batchCardData.CardInfo {
+			cardsByID[id] = card
+		}
+		unknownCardIDs = append(unknownCardIDs, batchCardData.UnknownResources...)
+	}
+
+	for _, productID := range reqBody.ProductIDs {
+		productContents, err := downstream.YGO.ProductService.GetCardsByProductIDProto(ctx, productID)
+		if err != nil {
+			logger.Error("Could not retrieve product contents", slog.String("product_id", productID), slog.Any("err", err))
+			return nil, nil, nil, err
+		}
+		for id, card := range cModel.BatchCardDataFromProductProto[cModel.CardIDs](productContents, cModel.CardIDAsKey).CardInfo {
+			cardsByID[id] = card
+			if releaseDate := productContents.GetReleaseDate(); releaseDate != "" && (releaseDates[id] == "" || releaseDate < releaseDates[id]) {
+				releaseDates[id] = releaseDate
+			}
+		}
+	}
+
+	cards := make([]cModel.YGOCard, 0, len(cardsByID))
+	for _, card := range cardsByID {
+		cards = append(cards, card)
+	}
+	return cards, unknownCardIDs, releaseDates, nil
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
+	"github.com/ygo-skc/skc-suggestion-engine/downstream"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+)
+
+const (
+	cardGraphOp = "Card Graph"
+
+	defaultCardGraphDepth  = 1
+	maxCardGraphDepth      = 3
+	maxCardGraphNodes      = 150
+	maxConcurrentGraphHops = 5
+
+	jsonGraphFormat    = "json"
+	graphMLGraphFormat = "graphml"
+	dotGraphFormat     = "dot"
+)
+
+// Walks suggestions (outgoing references) and support (incoming references) of a card up to depth hops.
+// Query params: depth (1 - 3, default 1) and format (json, graphml or dot - default json)
+func getCardGraphHandler(res http.ResponseWriter, req *http.Request) {
+	cardID := chi.URLParam(req, "cardID")
+
+	logger, ctx := cUtil.InitRequest(req.Context(), apiName, cardGraphOp, slog.String("card_id", cardID))
+	logger.Info("Card graph requested")
+
+	depth, format, err := parseCardGraphParams(req.URL.Query())
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+
+	cardProto, err := downstream.YGO.CardService.GetCardByIDProto(ctx, cardID)
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+
+	graph, err := buildCardGraph(ctx, cModel.YGOCardRESTFromProto(cardProto), depth)
+	if err != nil {
+		logger.Error("Could not build card graph", slog.Any("err", err))
+		err.HandleServerResponse(res)
+		return
+	}
+	logger.Info("Card graph generated", slog.Int("depth", depth), slog.Int("nodes", len(graph.Nodes)), slog.Int("edges", len(graph.Edges)), slog.Bool("truncated", graph.Truncated))
+
+	switch format {
+	case graphMLGraphFormat:
+		body, err := graph.GraphML()
+		if err != nil {
+			logger.Error("Could not encode card graph as GraphML", slog.Any("err", err))
+			cModel.HandleServerResponse(cModel.APIError{Message: "Could not encode graph", StatusCode: http.StatusInternalServerError}, res)
+			return
+		}
+		res.Header().Set("Content-Type", "application/graphml+xml")
+		res.WriteHeader(http.StatusOK)
+		res.Write(body)
+	case dotGraphFormat:
+		res.Header().Set("Content-Type", "text/vnd.graphviz")
+		res.WriteHeader(http.StatusOK)
+		res.Write(graph.DOT())
+	default:
+		res.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(res).Encode(graph); err != nil {
+			logger.Error("Could not encode card graph response", slog.Any("err", err))
+		}
+	}
+}
+
+func parseCardGraphParams(query url.Values) (int, string, *cModel.APIError) {
+	depth := defaultCardGraphDepth
+	if value := query.Get("depth"); value != "" {
+		var err error
+		if depth, err = strconv.Atoi(value); err != nil || depth < 1 || depth > maxCardGraphDepth {
+			return 0, "", &cModel.APIError{Message: fmt.Sprintf("depth should be a number between 1 and %d", maxCardGraphDepth), StatusCode: http.StatusBadRequest}
+		}
+	}
+
+	format := query.Get("format")
+	switch format {
+	case "":
+		format = jsonGraphFormat
+	case jsonGraphFormat, graphMLGraphFormat, dotGraphFormat:
+	default:
+		return 0, "", &cModel.APIError{Message: "format should be one of: json, graphml, dot", StatusCode: http.StatusBadRequest}
+	}
+
+	return depth, format, nil
+}
+
+// edges found by looking at the suggestions and support of a single card
+type cardNeighborhood struct {
+	edges      []model.GraphEdge
+	cards      map[string]cModel.YGOCard // cards found at the other end of an edge, keyed by ID
+	archetypes []string
+}
+
+func buildCardGraph(ctx context.Context, root cModel.YGOCard, depth int) (*model.CardGraph, *cModel.APIError) {
+	ccIDs, err := downstream.YGO.CardService.GetCardColorsProto(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b := newCardGraphBuilder(root, depth)
+	frontier := []cModel.YGOCard{root}
+	for hop := 1; hop <= depth && len(frontier) > 0; hop++ {
+		neighborhoods := make([]*cardNeighborhood, len(frontier))
+		errs := make([]*cModel.APIError, len(frontier))
+
+		var wg sync.WaitGroup
+		semaphore := make(chan struct{}, maxConcurrentGraphHops)
+		for i, card := range frontier {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				semaphore <- struct{}{}
+				defer func() { <-semaphore }()
+				neighborhoods[i], errs[i] = getCardNeighborhood(ctx, card, ccIDs.GetValues())
+			}()
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// merged in frontier order so the graph is the same for every request
+		frontier = make([]cModel.YGOCard, 0)
+		for _, neighborhood := range neighborhoods {
+			frontier = append(frontier, b.merge(neighborhood, hop)...)
+		}
+	}
+
+	return &b.graph, nil
+}
+
+func getCardNeighborhood(ctx context.Context, card cModel.YGOCard, ccIDs map[string]uint32) (*cardNeighborhood, *cModel.APIError) {
+	relevantArchetypes, err := skcSuggestionEngineDBInterface.GetRelevantArchetypes(ctx, cModel.CardIDs{card.GetID()})
+	if err != nil {
+		return nil, err
+	}
+	suggestions, err := getCardSuggestions(ctx, card, ccIDs, relevantArchetypes)
+	if err != nil {
+		return nil, err // a graph missing edges would look complete
+	}
+
+	supportByCardID, err := getSupportReferences(ctx, []cModel.YGOCard{card})
+	if err != nil {
+		return nil, err
+	}
+	support := supportByCardID[card.GetID()]
+
+	n := cardNeighborhood{cards: make(map[string]cModel.YGOCard)}
+	addCardEdges := func(references []model.CardReference, edgeType model.GraphEdgeType, outgoing bool) {
+		for _, reference := range references {
+			source, target := card.GetID(), reference.Card.GetID()
+			if !outgoing {
+				source, target = target, source
+			}
+			n.cards[reference.Card.GetID()] = reference.Card
+			n.edges = append(n.edges, model.GraphEdge{Source: source, Target: target, Type: edgeType, Occurrences: reference.Occurrences})
+		}
+	}
+	addCardEdges(suggestions.NamedMaterials, model.MaterialEdge, true)
+	addCardEdges(suggestions.NamedReferences, model.ReferenceEdge, true)
+	addCardEdges(support.MaterialFor, model.MaterialEdge, false)
+	addCardEdges(support.ReferencedBy, model.ReferenceEdge, false)
+	addCardEdges(support.AliasedBy, model.ReferenceEdge, false) // aliasing cards still name the card in their text
+
+	for _, archetype := range slices.Concat(suggestions.RelevantArchetypes, suggestions.MaterialArchetypes, suggestions.ReferencedArchetypes) {
+		if !slices.Contains(n.archetypes, archetype) {
+			n.archetypes = append(n.archetypes, archetype)
+			n.edges = append(n.edges, model.GraphEdge{Source: card.GetID(), Target: model.ArchetypeNodeID(archetype), Type: model.ArchetypeEdge, Occurrences: 1})
+		}
+	}
+
+	return &n, nil
+}
+
+type cardGraphEdgeKey struct {
+	source, target string
+	edgeType       model.GraphEdgeType
+}
+
+// de-duplicates nodes and edges found while walking the graph
+type cardGraphBuilder struct {
+	graph model.CardGraph
+	nodes map[string]struct{}
+	edges map[cardGraphEdgeKey]struct{}
+}
+
+func newCardGraphBuilder(root cModel.YGOCard, depth int) *cardGraphBuilder {
+	b := cardGraphBuilder{
+		graph: model.CardGraph{Root: root.GetID(), Depth: depth, Nodes: make([]model.GraphNode, 0), Edges: make([]model.GraphEdge, 0)},
+		nodes: make(map[string]struct{}),
+		edges: make(map[cardGraphEdgeKey]struct{}),
+	}
+	b.addNode(model.GraphNode{ID: root.GetID(), Type: model.CardNode, Label: root.GetName(), Depth: 0, Card: root})
+	return &b
+}
+
+// returns false if the node already exists or the node limit was reached
+func (b *cardGraphBuilder) addNode(node model.GraphNode) bool {
+	if _, isPresent := b.nodes[node.ID]; isPresent {
+		return false
+	}
+	if len(b.graph.Nodes) >= maxCardGraphNodes {
+		b.graph.Truncated = true
+		return false
+	}
+
+	b.nodes[node.ID] = struct{}{}
+	b.graph.Nodes = append(b.graph.Nodes, node)
+	return true
+}
+
+// adds the neighborhood to the graph and returns cards that weren't part of it yet - these are walked during the next hop
+func (b *cardGraphBuilder) merge(n *cardNeighborhood, hop int) []cModel.YGOCard {
+	newCards := make([]cModel.YGOCard, 0)
+
+	for _, archetype := range n.archetypes {
+		b.addNode(model.GraphNode{ID: model.ArchetypeNodeID(archetype), Type: model.ArchetypeNode, Label: archetype, Depth: hop})
+	}
+
+	for _, edge := range n.edges {
+		if edge.Type != model.ArchetypeEdge {
+			for _, id := range []string{edge.Source, edge.Target} {
+				if card, isPresent := n.cards[id]; isPresent {
+					if b.addNode(model.GraphNode{ID: id, Type: model.CardNode, Label: card.GetName(), Depth: hop, Card: card}) {
+						newCards = append(newCards, card)
+					}
+				}
+			}
+		}
+
+		key := cardGraphEdgeKey{source: edge.Source, target: edge.Target, edgeType: edge.Type}
+		_, sourceExists := b.nodes[edge.Source]
+		_, targetExists := b.nodes[edge.Target]
+		if _, isDuplicate := b.edges[key]; !isDuplicate && sourceExists && targetExists {
+			b.edges[key] = struct{}{}
+			b.graph.Edges = append(b.graph.Edges, edge)
+		}
+	}
+
+	return newCards
+}
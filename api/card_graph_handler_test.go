@@ -0,0 +1,88 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+)
+
+func TestParseCardGraphParams(t *testing.T) {
+	assert := assert.New(t)
+
+	depth, format, err := parseCardGraphParams(url.Values{})
+	assert.Nil(err)
+	assert.Equal(defaultCardGraphDepth, depth)
+	assert.Equal(jsonGraphFormat, format)
+
+	depth, format, err = parseCardGraphParams(url.Values{"depth": {"3"}, "format": {"dot"}})
+	assert.Nil(err)
+	assert.Equal(3, depth)
+	assert.Equal(dotGraphFormat, format)
+
+	for _, query := range []url.Values{{"depth": {"0"}}, {"depth": {"4"}}, {"depth": {"two"}}, {"format": {"svg"}}} {
+		_, _, err = parseCardGraphParams(query)
+		assert.Equal(http.StatusBadRequest, err.StatusCode, query)
+	}
+}
+
+func TestCardGraphBuilder(t *testing.T) {
+	assert := assert.New(t)
+
+	darkMagician := cModel.YGOCardREST{ID: "46986414", Name: "Dark Magician"}
+	darkMagicianGirl := cModel.YGOCardREST{ID: "38033121", Name: "Dark Magician Girl"}
+	darkPaladin := cModel.YGOCardREST{ID: "98502113", Name: "Dark Paladin"}
+
+	b := newCardGraphBuilder(darkMagician, 2)
+	newCards := b.merge(&cardNeighborhood{
+		cards: map[string]cModel.YGOCard{darkMagicianGirl.ID: darkMagicianGirl, darkPaladin.ID: darkPaladin},
+		edges: []model.GraphEdge{
+			{Source: darkMagicianGirl.ID, Target: darkMagician.ID, Type: model.ReferenceEdge, Occurrences: 1},
+			{Source: darkPaladin.ID, Target: darkMagician.ID, Type: model.MaterialEdge, Occurrences: 1},
+			{Source: darkMagician.ID, Target: model.ArchetypeNodeID("Dark Magician"), Type: model.ArchetypeEdge, Occurrences: 1},
+		},
+		archetypes: []string{"Dark Magician"},
+	}, 1)
+	assert.Equal([]cModel.YGOCard{darkMagicianGirl, darkPaladin}, newCards)
+
+	// second hop finds the same edge again along with the root - nothing should be duplicated
+	newCards = b.merge(&cardNeighborhood{
+		cards: map[string]cModel.YGOCard{darkMagician.ID: darkMagician},
+		edges: []model.GraphEdge{{Source: darkMagicianGirl.ID, Target: darkMagician.ID, Type: model.ReferenceEdge, Occurrences: 1}},
+	}, 2)
+	assert.Empty(newCards)
+	assert.Len(b.graph.Nodes, 4)
+	assert.Len(b.graph.Edges, 3)
+	assert.False(b.graph.Truncated)
+
+	dot := string(b.graph.DOT())
+	assert.True(strings.HasPrefix(dot, `digraph "46986414" {`))
+	assert.Contains(dot, `"38033121" -> "46986414" [label="reference", occurrences=1];`)
+	assert.Contains(dot, `"archetype:Dark Magician" [label="Dark Magician", shape=box, depth=1];`)
+
+	graphML, err := b.graph.GraphML()
+	assert.Nil(err)
+	assert.Contains(string(graphML), `<edge source="98502113" target="46986414">`)
+}
+
+func TestCardGraphBuilderNodeLimit(t *testing.T) {
+	assert := assert.New(t)
+
+	b := newCardGraphBuilder(cModel.YGOCardREST{ID: "00000000", Name: "Root"}, 1)
+	n := cardNeighborhood{cards: make(map[string]cModel.YGOCard)}
+	for i := range maxCardGraphNodes {
+		card := cModel.YGOCardREST{ID: fmt.Sprintf("1%06d1", i), Name: fmt.Sprintf("Card %d", i)}
+		n.cards[card.ID] = card
+		n.edges = append(n.edges, model.GraphEdge{Source: card.ID, Target: "00000000", Type: model.ReferenceEdge, Occurrences: 1})
+	}
+
+	b.merge(&n, 1)
+	assert.Len(b.graph.Nodes, maxCardGraphNodes)
+	assert.Len(b.graph.Edges, maxCardGraphNodes-1, "Edges to nodes that weren't added should be dropped")
+	assert.True(b.graph.Truncated)
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
+	"github.com/ygo-skc/skc-suggestion-engine/downstream"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+	"github.com/ygo-skc/skc-suggestion-engine/usage"
+	"github.com/ygo-skc/skc-suggestion-engine/validation"
+)
+
+const (
+	cardSearchOp = "Card Search"
+
+	cardSearchResultLimit = 10
+)
+
+// Finds cards whose text is semantically similar to a free text query - eg: "banish from the GY when summoned"
+func searchCardsHandler(res http.ResponseWriter, req *http.Request) {
+	query := model.CardSearchQuery{Query: strings.TrimSpace(req.URL.Query().Get("q"))}
+
+	logger, ctx := cUtil.InitRequest(req.Context(), apiName, cardSearchOp, slog.String("query", query.Query))
+	ctx = usage.WithOperation(ctx, cardSearchOp)
+	logger.Info("Searching cards")
+
+	if err := validation.ValidateCardSearchQuery(query); err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+
+	results, err := searchCards(ctx, query.Query)
+	if err != nil {
+		logger.Error("Could not search cards", slog.Any("err", err))
+		err.HandleServerResponse(res)
+		return
+	}
+
+	res.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(res).Encode(results); err != nil {
+		logger.Error("Could not encode card search response", slog.Any("err", err))
+	}
+}
+
+func searchCards(ctx context.Context, query string) (*model.CardSearchResults, *cModel.APIError) {
+	logger := cUtil.RetrieveLogger(ctx)
+
+	if usage.DailyTracker.CachedOnly() {
+		logger.Warn("Daily token budget exceeded, cannot embed search query")
+		return nil, newTokenBudgetExceededErr()
+	}
+
+	embeddingRes, err := downstream.EmbeddingClient.EmbedText(ctx, []string{query}, model.VoyageQueryInput)
+	if err != nil {
+		return nil, err
+	}
+
+	vectorSearchResults, err := skcSuggestionEngineDBInterface.VectorSearchUsingQuery(ctx, embeddingRes.Data[0].Embedding)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectorSearchResults) == 0 {
+		return &model.CardSearchResults{Query: query, Results: []model.ScoredCard{}, RankingStage: model.VectorStage}, nil
+	}
+
+	vectorSearchResults, rankingStage, err := rankResults(ctx, vectorSearchResults, query, cardSearchResultLimit)
+	if err != nil {
+		logger.Error("Error during re-ranking", slog.Any("err", err))
+		return nil, err
+	}
+
+	cardData, err := fetchVectorSearchCards(ctx, vectorSearchResults)
+	if err != nil {
+		return nil, err
+	}
+
+	results := model.CardSearchResults{Query: query, Results: make([]model.ScoredCard, 0, len(vectorSearchResults)), RankingStage: rankingStage}
+	for _, vectorSearchResult := range vectorSearchResults {
+		if card, isPresent := cardData[vectorSearchResult.ID]; isPresent {
+			results.Results = append(results.Results, model.ScoredCard{
+				Card:        card,
+				VectorScore: vectorSearchResult.CosineSimilarity,
+				RerankScore: vectorSearchResult.RerankScore,
+			})
+		}
+	}
+
+	return &results, nil
+}
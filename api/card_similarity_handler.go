@@ -3,26 +3,49 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	cModel "github.com/ygo-skc/skc-go/common/v3/model"
 	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
+	"github.com/ygo-skc/skc-suggestion-engine/db"
 	"github.com/ygo-skc/skc-suggestion-engine/downstream"
 	"github.com/ygo-skc/skc-suggestion-engine/model"
+	"github.com/ygo-skc/skc-suggestion-engine/usage"
+	"github.com/ygo-skc/skc-suggestion-engine/validation"
 )
 
 const (
 	similarCardsOp = "Similar Cards"
+
+	defaultSimilarCardsLimit = 20
+	defaultSimilarCardsTopK  = 30
 )
 
 func getSimilarCardsHandler(res http.ResponseWriter, req *http.Request) {
 	cardID := chi.URLParam(req, "cardID")
+	includeScores := req.URL.Query().Get("includeScores") == "true"
 
-	logger, ctx := cUtil.InitRequest(req.Context(), apiName, similarCardsOp, slog.String("card_id", cardID))
+	logger, ctx := cUtil.InitRequest(req.Context(), apiName, similarCardsOp, slog.String("card_id", cardID), slog.Bool("include_scores", includeScores))
+	ctx = usage.WithOperation(ctx, similarCardsOp)
 	logger.Info("Finding similar cards")
 
+	filter, err := parseSimilarCardsFilter(req.URL.Query())
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+	if err := validation.ValidateSimilarCardsFilter(filter); err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+
 	subject, embeddedQuery, err := retrieveAndEmbedCardEffect(ctx, cardID)
 	if err != nil {
 		logger.Error("Could not embed card text", slog.Any("err", err))
@@ -30,13 +53,17 @@ func getSimilarCardsHandler(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	similarCards := model.SimilarCards{Card: *subject}
-	if matches, err := getSimilarCards(ctx, *subject, embeddedQuery); err != nil {
+	similarCards, err := getSimilarCards(ctx, *subject, embeddedQuery, filter)
+	if err != nil {
 		logger.Error("Could not retrieve similar cards", slog.Any("err", err))
 		err.HandleServerResponse(res)
 		return
-	} else {
-		similarCards.Matches = matches
+	}
+	if !includeScores {
+		similarCards.Scores = nil
+	}
+	if parseIncludePopularity(req.URL.Query()) {
+		similarCards.Popularity = cardPopularity(similarCards.Matches)
 	}
 
 	res.WriteHeader(http.StatusOK)
@@ -45,6 +72,36 @@ func getSimilarCardsHandler(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// Query params: color, attribute, monsterType, excludeArchetype, limit, topK.
+// minLevel and maxLevel are rejected - ygo-service doesn't return levels so cardEmbedding documents don't have one to filter on.
+func parseSimilarCardsFilter(query url.Values) (model.SimilarCardsFilter, *cModel.APIError) {
+	filter := model.SimilarCardsFilter{
+		Color:            query.Get("color"),
+		Attribute:        strings.ToUpper(query.Get("attribute")),
+		MonsterType:      query.Get("monsterType"),
+		ExcludeArchetype: query.Get("excludeArchetype") == "true",
+		Limit:            defaultSimilarCardsLimit,
+		TopK:             defaultSimilarCardsTopK,
+	}
+
+	for _, param := range []string{"minLevel", "maxLevel"} {
+		if query.Has(param) {
+			return filter, &cModel.APIError{Message: fmt.Sprintf("%s is not supported - card levels are not available yet", param), StatusCode: http.StatusBadRequest}
+		}
+	}
+
+	for param, dest := range map[string]*int{"limit": &filter.Limit, "topK": &filter.TopK} {
+		if value := query.Get(param); value != "" {
+			var err error
+			if *dest, err = strconv.Atoi(value); err != nil {
+				return filter, &cModel.APIError{Message: fmt.Sprintf("%s should be a number", param), StatusCode: http.StatusBadRequest}
+			}
+		}
+	}
+
+	return filter, nil
+}
+
 func retrieveAndEmbedCardEffect(ctx context.Context, cardID string) (*cModel.YGOCard, []float32, *cModel.APIError) {
 	cardProto, err := downstream.YGO.CardService.GetCardByIDProto(ctx, cardID)
 	if err != nil {
@@ -52,52 +109,175 @@ func retrieveAndEmbedCardEffect(ctx context.Context, cardID string) (*cModel.YGO
 	}
 	subject := cModel.YGOCardRESTFromProto(cardProto)
 
-	voyageRes, err := downstream.EmbedText(ctx, []string{(subject).GetEffect()}, model.VoyageQueryInput)
+	embeddedQueries, err := embedCardEffects(ctx, []cModel.YGOCard{subject})
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return &subject, voyageRes.Data[0].Embedding, nil
+	return &subject, embeddedQueries[subject.GetID()], nil
 }
 
-func getSimilarCards(ctx context.Context, subject cModel.YGOCard, embeddedQuery []float32) ([]cModel.YGOCard, *cModel.APIError) {
-	logger := cUtil.RetrieveLogger(ctx)
+// Query embeddings keyed by card ID. Once the daily token budget is exceeded in cached only mode, cards that weren't embedded earlier can't be used.
+func embedCardEffects(ctx context.Context, cards []cModel.YGOCard) (map[string][]float32, *cModel.APIError) {
+	if !usage.DailyTracker.CachedOnly() {
+		return cardEmbeddingCache.EmbedCardEffects(ctx, cards)
+	}
+
+	embeddings, misses := cardEmbeddingCache.CachedCardEffects(ctx, cards)
+	if len(misses) > 0 {
+		cUtil.RetrieveLogger(ctx).Warn("Daily token budget exceeded, cannot embed cards", slog.Int("misses", len(misses)))
+		return nil, newTokenBudgetExceededErr()
+	}
+	return embeddings, nil
+}
+
+func newTokenBudgetExceededErr() *cModel.APIError {
+	return &cModel.APIError{Message: "Daily token budget exceeded - try again tomorrow", StatusCode: http.StatusServiceUnavailable}
+}
 
-	vectorSearchResults, err := skcSuggestionEngineDBInterface.VectorSearchOnCardEmbedding(ctx, subject, embeddedQuery)
+// returns similar cards and how each was scored - matches and scores use the same order
+func getSimilarCards(ctx context.Context, subject cModel.YGOCard, embeddedQuery []float32, filter model.SimilarCardsFilter) (*model.SimilarCards, *cModel.APIError) {
+	vectorSearchResults, rankingStage, err := searchSimilarCards(ctx, subject, embeddedQuery, filter, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	vectorSearchResults, err = rerank(ctx, vectorSearchResults, subject.GetEffect(), 20)
+	similarCards := model.SimilarCards{Card: subject, Matches: []cModel.YGOCard{}, Scores: []model.SimilarityScore{}, RankingStage: rankingStage}
+	if len(vectorSearchResults) == 0 {
+		return &similarCards, nil
+	}
+
+	similarCardData, err := fetchVectorSearchCards(ctx, vectorSearchResults)
 	if err != nil {
-		logger.Error("Error during re-ranking", slog.Any("err", err))
 		return nil, err
 	}
 
-	similarCardIDs := make(cModel.CardIDs, 0, len(vectorSearchResults))
+	similarCards.Matches, similarCards.Scores = toSimilarCards(vectorSearchResults, similarCardData)
+	return &similarCards, nil
+}
+
+// vector search followed by re-ranking - at most filter.Limit results are returned. Cards in excludedIDs are never considered.
+func searchSimilarCards(ctx context.Context, subject cModel.YGOCard, embeddedQuery []float32,
+	filter model.SimilarCardsFilter, excludedIDs []string) ([]model.VectorSearchResult, model.RankingStage, *cModel.APIError) {
+
+	vectorSearchFilter := model.VectorSearchFilter{
+		Color:       filter.Color,
+		Attribute:   filter.Attribute,
+		MonsterType: filter.MonsterType,
+		Limit:       filter.TopK,
+		ExcludedIDs: slices.Clone(excludedIDs),
+	}
+	if filter.ExcludeArchetype {
+		archetypeMemberIDs, err := getArchetypeMemberIDs(ctx, subject.GetID())
+		if err != nil {
+			return nil, "", err
+		}
+		vectorSearchFilter.ExcludedIDs = append(vectorSearchFilter.ExcludedIDs, archetypeMemberIDs...)
+	}
+
+	vectorSearchResults, err := skcSuggestionEngineDBInterface.VectorSearchOnCardEmbedding(ctx, subject, embeddedQuery, vectorSearchFilter)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(vectorSearchResults) == 0 {
+		return vectorSearchResults, model.VectorStage, nil
+	}
+
+	vectorSearchResults, rankingStage, err := rankResults(ctx, vectorSearchResults, subject.GetEffect(), uint8(filter.Limit))
+	if err != nil {
+		cUtil.RetrieveLogger(ctx).Error("Error during re-ranking", slog.Any("err", err))
+		return nil, "", err
+	}
+	return vectorSearchResults, rankingStage, nil
+}
+
+func toSimilarCards(vectorSearchResults []model.VectorSearchResult, cardData cModel.CardDataMap) ([]cModel.YGOCard, []model.SimilarityScore) {
+	similarCards := make([]cModel.YGOCard, 0, len(vectorSearchResults))
+	scores := make([]model.SimilarityScore, 0, len(vectorSearchResults))
 	for _, vectorSearchResult := range vectorSearchResults {
-		similarCardIDs = append(similarCardIDs, vectorSearchResult.ID)
+		if card, isPresent := cardData[vectorSearchResult.ID]; isPresent {
+			similarCards = append(similarCards, card)
+			scores = append(scores, newSimilarityScore(vectorSearchResult))
+		}
 	}
+	return similarCards, scores
+}
 
-	cardsProto, err := downstream.YGO.CardService.GetCardsByIDProto(ctx, similarCardIDs)
+// IDs of every card belonging to the same archetype(s) as the subject
+func getArchetypeMemberIDs(ctx context.Context, cardID string) ([]string, *cModel.APIError) {
+	archetypes, err := skcSuggestionEngineDBInterface.GetRelevantArchetypes(ctx, cModel.CardIDs{cardID})
+	if err != nil {
+		return nil, err
+	}
+
+	memberIDs := make([]string, 0)
+	for _, archetype := range archetypes {
+		inheritMembers, qualifiedMembers, _, err := skcSuggestionEngineDBInterface.GetArchetypeMembers(ctx, archetype)
+		if err != nil {
+			return nil, err
+		}
+		memberIDs = append(memberIDs, inheritMembers...)
+		memberIDs = append(memberIDs, qualifiedMembers...)
+	}
+
+	cUtil.RetrieveLogger(ctx).Info("Excluding archetype members from similar cards", slog.Any("archetypes", archetypes), slog.Int("total_members", len(memberIDs)))
+	return memberIDs, nil
+}
+
+func newSimilarityScore(r model.VectorSearchResult) model.SimilarityScore {
+	return model.SimilarityScore{
+		CardID:            r.ID,
+		VectorScore:       r.CosineSimilarity,
+		SharedType:        float64(r.SharedType) * db.SharedTypeBoost,
+		SharedAttribute:   float64(r.SharedAttribute) * db.SharedAttributeBoost,
+		SharedMonsterType: float64(r.SharedMonsterType) * db.SharedMonsterTypeBoost,
+		FinalScore:        r.FinalScore,
+		RerankScore:       r.RerankScore,
+	}
+}
+
+// vector search results only contain card ID and text - ygo-service is used to get the rest of the card info
+func fetchVectorSearchCards(ctx context.Context, vectorSearchResults []model.VectorSearchResult) (cModel.CardDataMap, *cModel.APIError) {
+	logger := cUtil.RetrieveLogger(ctx)
+
+	cardIDs := make(cModel.CardIDs, 0, len(vectorSearchResults))
+	for _, vectorSearchResult := range vectorSearchResults {
+		cardIDs = append(cardIDs, vectorSearchResult.ID)
+	}
+
+	cardsProto, err := downstream.YGO.CardService.GetCardsByIDProto(ctx, cardIDs)
 	if err != nil {
 		logger.Error("Could not retrieve information about cards from search results", slog.Any("err", err))
 		return nil, err
 	}
-	similarCardData := cModel.BatchCardDataFromProto[cModel.CardIDs](cardsProto, cModel.CardIDAsKey)
+	cardData := cModel.BatchCardDataFromProto[cModel.CardIDs](cardsProto, cModel.CardIDAsKey)
 
-	if len(similarCardData.UnknownResources) > 0 {
-		logger.Warn("Some vector search IDs had no matching metadata", slog.Any("unknown_card_ids", similarCardData.UnknownResources))
+	if len(cardData.UnknownResources) > 0 {
+		logger.Warn("Some vector search IDs had no matching metadata", slog.Any("unknown_card_ids", cardData.UnknownResources))
 	}
 
-	similarCards := make([]cModel.YGOCard, 0, len(similarCardIDs))
-	for _, id := range similarCardIDs {
-		if card, isPresent := similarCardData.CardInfo[id]; isPresent {
-			similarCards = append(similarCards, card)
-		}
+	return cardData.CardInfo, nil
+}
+
+// Reranks vector search results. If the reranker fails and fallback is enabled, the top K results are returned using the vector search order instead.
+// Reranking is skipped once the daily token budget is exceeded.
+func rankResults(ctx context.Context, vectorSearchResults []model.VectorSearchResult, query string, topK uint8) ([]model.VectorSearchResult, model.RankingStage, *cModel.APIError) {
+	if usage.DailyTracker.BudgetExceeded() {
+		cUtil.RetrieveLogger(ctx).Warn("Daily token budget exceeded, using vector search order")
+		return vectorSearchResults[:min(int(topK), len(vectorSearchResults))], model.VectorStage, nil
 	}
 
-	return similarCards, nil
+	rankedResults, err := rerank(ctx, vectorSearchResults, query, topK)
+	if err == nil {
+		return rankedResults, model.RerankStage, nil
+	}
+
+	if !rerankFallbackEnabled {
+		return nil, "", err
+	}
+
+	cUtil.RetrieveLogger(ctx).Warn("Re-ranking failed, using vector search order", slog.Any("err", err))
+	return vectorSearchResults[:min(int(topK), len(vectorSearchResults))], model.VectorStage, nil
 }
 
 func rerank(ctx context.Context, vectorSearchResults []model.VectorSearchResult, query string, topK uint8) ([]model.VectorSearchResult, *cModel.APIError) {
@@ -106,14 +286,20 @@ func rerank(ctx context.Context, vectorSearchResults []model.VectorSearchResult,
 		docs[i] = vectorSearchResult.Text
 	}
 
-	voyageRes, err := downstream.RerankVectorResults(ctx, docs, query, topK)
+	rerankRes, err := downstream.RerankClient.RerankVectorResults(ctx, docs, query, topK)
 	if err != nil {
 		return nil, err
 	}
 
 	rankedResults := make([]model.VectorSearchResult, 0, topK)
-	for _, rerankResult := range voyageRes.Data {
-		rankedResults = append(rankedResults, vectorSearchResults[rerankResult.Index])
+	for _, rerankResult := range rerankRes.Data {
+		if rerankResult.Index < 0 || rerankResult.Index >= len(vectorSearchResults) {
+			cUtil.RetrieveLogger(ctx).Error("Reranker returned an index that doesn't exist", slog.Int("index", rerankResult.Index), slog.Int("total_docs", len(docs)))
+			return nil, &cModel.APIError{Message: "Error occurred while re-ranking", StatusCode: http.StatusInternalServerError}
+		}
+		rankedResult := vectorSearchResults[rerankResult.Index]
+		rankedResult.RerankScore = rerankResult.Score
+		rankedResults = append(rankedResults, rankedResult)
 	}
 
 	return rankedResults, nil
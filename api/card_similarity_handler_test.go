@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	"github.com/ygo-skc/skc-suggestion-engine/db"
+	"github.com/ygo-skc/skc-suggestion-engine/downstream"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+	skc_testing "github.com/ygo-skc/skc-suggestion-engine/testing"
+	"github.com/ygo-skc/skc-suggestion-engine/validation"
+)
+
+func TestParseSimilarCardsFilter(t *testing.T) {
+	assert := assert.New(t)
+
+	filter, err := parseSimilarCardsFilter(url.Values{})
+	assert.Nil(err)
+	assert.Equal(defaultSimilarCardsLimit, filter.Limit)
+	assert.Equal(defaultSimilarCardsTopK, filter.TopK)
+	assert.Nil(validation.ValidateSimilarCardsFilter(filter), "Defaults should be valid")
+
+	filter, err = parseSimilarCardsFilter(url.Values{
+		"attribute": {"dark"}, "excludeArchetype": {"true"}, "limit": {"5"}, "topK": {"50"},
+	})
+	assert.Nil(err)
+	assert.Equal("DARK", filter.Attribute)
+	assert.True(filter.ExcludeArchetype)
+	assert.Equal(5, filter.Limit)
+	assert.Equal(50, filter.TopK)
+	assert.Nil(validation.ValidateSimilarCardsFilter(filter))
+
+	_, err = parseSimilarCardsFilter(url.Values{"limit": {"five"}})
+	assert.Equal(http.StatusBadRequest, err.StatusCode)
+
+	for _, param := range []string{"minLevel", "maxLevel"} {
+		_, err = parseSimilarCardsFilter(url.Values{param: {"4"}})
+		assert.NotNil(err, "Level filters would never match as cardEmbedding documents have no level")
+		assert.Equal(http.StatusBadRequest, err.StatusCode)
+	}
+}
+
+func TestSimilarCardsFilterValidation(t *testing.T) {
+	assert := assert.New(t)
+
+	for name, query := range map[string]url.Values{
+		"unknown attribute": {"attribute": {"SHADOW"}},
+		"limit above topK":  {"limit": {"40"}, "topK": {"30"}},
+		"topK too large":    {"topK": {"500"}},
+	} {
+		filter, _ := parseSimilarCardsFilter(query)
+		assert.NotNil(validation.ValidateSimilarCardsFilter(filter), name)
+	}
+}
+
+func TestSearchSimilarCardsExcludesIDs(t *testing.T) {
+	assert := assert.New(t)
+	originalDAO, originalReranker := skcSuggestionEngineDBInterface, downstream.RerankClient
+	t.Cleanup(func() { skcSuggestionEngineDBInterface, downstream.RerankClient = originalDAO, originalReranker })
+
+	dao, _ := db.NewSKCSuggestionEngineDAOInMemory("")
+	dao.UpsertCardEmbeddings(skc_testing.TestContext, []model.CardEmbedding{
+		{ID: "38033121", Text: "Dark Magician Girl", TextEmbedding: []float32{1, 0, 0}},
+		{ID: "71703785", Text: "Dark Magician of Chaos", TextEmbedding: []float32{0.9, 0.1, 0}},
+		{ID: "99789342", Text: "Dark Magic Curtain", TextEmbedding: []float32{0.8, 0.2, 0}},
+		{ID: "75380687", Text: "Dark Magic Attack", TextEmbedding: []float32{0, 1, 0}},
+	})
+	skcSuggestionEngineDBInterface, downstream.RerankClient = dao, downstream.NewHashingEmbedder()
+
+	subject := cModel.YGOCardREST{ID: "46986414", Name: "Dark Magician", Effect: "The ultimate wizard in terms of attack and defense."}
+	filter := model.SimilarCardsFilter{Limit: 2, TopK: 2}
+	results, _, err := searchSimilarCards(skc_testing.TestContext, subject, []float32{1, 0, 0}, filter, []string{"46986414", "38033121"})
+	assert.Nil(err)
+	assert.ElementsMatch([]string{"71703785", "99789342"}, []string{results[0].ID, results[1].ID},
+		"Excluded cards should not use up candidates - limit results should still be returned")
+}
+
+type failingReranker struct{}
+
+func (failingReranker) RerankVectorResults(context.Context, []string, string, uint8) (*model.RerankResponse, *cModel.APIError) {
+	return nil, &cModel.APIError{Message: "Reranker unavailable", StatusCode: http.StatusServiceUnavailable}
+}
+
+func TestRankResultsFallback(t *testing.T) {
+	assert := assert.New(t)
+	originalReranker, originalFallback := downstream.RerankClient, rerankFallbackEnabled
+	t.Cleanup(func() { downstream.RerankClient, rerankFallbackEnabled = originalReranker, originalFallback })
+
+	vectorSearchResults := []model.VectorSearchResult{{ID: "1", Text: "Draw 2 cards."}, {ID: "2", Text: "Draw 1 card."}, {ID: "3", Text: "Destroy 1 card."}}
+
+	downstream.RerankClient = downstream.NewHashingEmbedder()
+	results, rankingStage, err := rankResults(skc_testing.TestContext, vectorSearchResults, "Draw 2 cards.", 2)
+	assert.Nil(err)
+	assert.Equal(model.RerankStage, rankingStage)
+	assert.Len(results, 2)
+
+	downstream.RerankClient = failingReranker{}
+	rerankFallbackEnabled = true
+	results, rankingStage, err = rankResults(skc_testing.TestContext, vectorSearchResults, "Draw 2 cards.", 2)
+	assert.Nil(err)
+	assert.Equal(model.VectorStage, rankingStage)
+	assert.Equal([]string{"1", "2"}, []string{results[0].ID, results[1].ID}, "Vector search order should be kept")
+
+	rerankFallbackEnabled = false
+	_, _, err = rankResults(skc_testing.TestContext, vectorSearchResults, "Draw 2 cards.", 2)
+	assert.Equal(http.StatusServiceUnavailable, err.StatusCode)
+}
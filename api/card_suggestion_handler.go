@@ -28,6 +28,17 @@ func getCardSuggestionsHandler(res http.ResponseWriter, req *http.Request) {
 	logger, ctx := cUtil.InitRequest(req.Context(), apiName, cardSuggestionsOp, slog.String("card_id", cardID))
 	logger.Info("Card suggestions requested")
 
+	intents, err := parseIntentFilter(req.URL.Query())
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+	strategy, err := parseRankingStrategy(req.URL.Query())
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+
 	cardProto, err := downstream.YGO.CardService.GetCardByIDProto(ctx, cardID)
 	if err != nil {
 		err.HandleServerResponse(res)
@@ -43,7 +54,19 @@ func getCardSuggestionsHandler(res http.ResponseWriter, req *http.Request) {
 	}
 	// TODO: include exclusions?
 
-	suggestions := getCardSuggestions(ctx, cardToGetSuggestionsFor, ccIDs.GetValues(), relevantArchetypes)
+	suggestions, err := getCardSuggestions(ctx, cardToGetSuggestionsFor, ccIDs.GetValues(), relevantArchetypes)
+	if err != nil {
+		logger.Warn("Could not resolve named references - returning degraded suggestions", slog.Any("err", err))
+	}
+	suggestions.NamedMaterials = model.FilterByIntent(suggestions.NamedMaterials, intents)
+	suggestions.NamedReferences = model.FilterByIntent(suggestions.NamedReferences, intents)
+	if err := rankReferences(ctx, strategy, ccIDs.GetValues(), &suggestions.NamedMaterials, &suggestions.NamedReferences); err != nil {
+		logger.Warn("Could not rank card suggestions using every signal", slog.String("sort", string(strategy)), slog.Any("err", err))
+		suggestions.Fail(model.RankingSignalsStage, err)
+	}
+	if parseIncludePopularity(req.URL.Query()) {
+		setPopularity(&suggestions.NamedMaterials, &suggestions.NamedReferences)
+	}
 
 	logger.Info("Card suggestions generated",
 		slog.String("card_name", (cardToGetSuggestionsFor).GetName()),
@@ -55,16 +78,28 @@ func getCardSuggestionsHandler(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
-func getCardSuggestions(ctx context.Context, subject cModel.YGOCard, ccIDs map[string]uint32, relevantArchetypes []string) model.CardSuggestions {
-	usd := suggest.GenerateUnparsedSuggestionData(ctx,
-		suggest.QuotedStringRegex.FindAllString(subject.GetEffect(), -1), relevantArchetypes)
+// Suggestions are still returned when err is not nil - they are flagged as degraded and only contain what could be resolved
+func getCardSuggestions(ctx context.Context, subject cModel.YGOCard, ccIDs map[string]uint32, relevantArchetypes []string) (model.CardSuggestions, *cModel.APIError) {
+	return suggestFromCardText(ctx, subject, cModel.GetPotentialMaterialsAsString(subject), ccIDs, relevantArchetypes, relevantArchetypes)
+}
+
+// Parses named materials, named references and archetypes found in the subject's text.
+// Only archetypes found in knownArchetypes are recognized, relevantArchetypes are the archetypes the subject belongs to.
+func suggestFromCardText(ctx context.Context, subject cModel.YGOCard, materialText string, ccIDs map[string]uint32,
+	knownArchetypes []string, relevantArchetypes []string) (model.CardSuggestions, *cModel.APIError) {
+	usd, err := suggest.GenerateUnparsedSuggestionData(ctx,
+		suggest.TokenTexts(subject.GetEffect()), knownArchetypes, cardReferenceIndex)
 
-	materialText := cModel.GetPotentialMaterialsAsString(subject)
 	effectText := strings.ReplaceAll(subject.GetEffect(), materialText, "")
 
 	suggestions := suggest.ParseSuggestionData(subject.GetName(), materialText, effectText, usd)
 	suggestions.Card = subject
-	suggestions.RelevantArchetypes = relevantArchetypes // this is all archetypes the card belongs to
+	suggestions.RelevantArchetypes = slices.Clone(relevantArchetypes) // this is all archetypes the card belongs to
+	for _, archetype := range suggestions.TreatedAs {
+		if !slices.Contains(suggestions.RelevantArchetypes, archetype) {
+			suggestions.RelevantArchetypes = append(suggestions.RelevantArchetypes, archetype)
+		}
+	}
 
 	slices.SortStableFunc(suggestions.NamedMaterials, suggest.SortCardReferences(ccIDs))
 	slices.SortStableFunc(suggestions.NamedReferences, suggest.SortCardReferences(ccIDs))
@@ -73,5 +108,8 @@ func getCardSuggestions(ctx context.Context, subject cModel.YGOCard, ccIDs map[s
 	slices.Sort(suggestions.MaterialArchetypes)
 	suggestions.HasSelfReference = model.RemoveSelfReference(subject.GetName(), &suggestions.NamedReferences)
 
-	return suggestions
+	if err != nil {
+		suggestions.Fail(model.CardNameResolutionStage, err)
+	}
+	return suggestions, err
 }
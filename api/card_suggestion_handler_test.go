@@ -1,11 +1,18 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/ygo-skc/skc-go/common/v3/client"
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
 	"github.com/ygo-skc/skc-go/common/v3/parser"
+	"github.com/ygo-skc/skc-go/common/v3/ygo"
 	"github.com/ygo-skc/skc-suggestion-engine/downstream"
 	"github.com/ygo-skc/skc-suggestion-engine/model"
 	skc_testing "github.com/ygo-skc/skc-suggestion-engine/testing"
@@ -20,16 +27,126 @@ func TestGetSuggestions(t *testing.T) {
 	ccIDs := skc_testing.CardColors
 	for cardName := range cardSuggestionsWithSelfReferenceMock {
 		mock := skc_testing.CardMocks[cardName]
-		suggestions := getCardSuggestions(skc_testing.TestContext, mock, ccIDs, skc_testing.CardArchetypeMock[cardName])
+		suggestions, err := getCardSuggestions(skc_testing.TestContext, mock, ccIDs, skc_testing.CardArchetypeMock[cardName])
+		assert.Nil(err)
+		assert.False(suggestions.Degraded)
 
 		assert.Equal(cardSuggestionsWithSelfReferenceMock[cardName].NamedMaterials, suggestions.NamedMaterials, cardName+":Named Material values did not match")
 		assert.Equal(cardSuggestionsWithSelfReferenceMock[cardName].MaterialArchetypes, suggestions.MaterialArchetypes, cardName+":Material Archetype values did not match")
 
 		assert.Equal(cardSuggestionsWithoutSelfReferenceMock[cardName].NamedReferences, suggestions.NamedReferences, cardName+":Named References values did not match")
 		assert.Equal(cardSuggestionsWithoutSelfReferenceMock[cardName].ReferencedArchetypes, suggestions.ReferencedArchetypes, cardName+":Referenced Archetype values did not match")
+		assert.ElementsMatch(cardSuggestionsWithoutSelfReferenceMock[cardName].NameAliases, suggestions.NameAliases, cardName+":Name Alias values did not match")
 	}
 }
 
+func TestAliasSuggestions(t *testing.T) {
+	// setup
+	assert := assert.New(t)
+	downstream.YGO = client.YGOClientImpV1{CardService: skc_testing.YGOCardClientMock{}}
+
+	subject := cModel.YGOCardREST{Name: "Performapal Skullcrobat Joker", Effect: `(This card is always treated as a "Magician" card.)
+When this card is Normal Summoned: You can add 1 "Magician" Pendulum Monster from your Deck to your hand.`}
+	suggestions, err := suggestFromCardText(skc_testing.TestContext, subject, "", skc_testing.CardColors, []string{"Magician", "Performapal"}, []string{"Performapal"})
+	assert.Nil(err)
+	assert.Equal([]string{"Magician"}, suggestions.TreatedAs)
+	assert.Empty(suggestions.NameAliases)
+	assert.Equal([]string{"Magician", "Performapal"}, suggestions.RelevantArchetypes, "Card should be part of archetypes it is treated as")
+	assert.Equal([]string{"Magician"}, suggestions.ReferencedArchetypes, "Archetype is still referenced outside of the treated as clause")
+}
+
+// ygo-service is unavailable
+type failingYGOCardClient struct {
+	skc_testing.YGOCardClientMock
+}
+
+func (svc failingYGOCardClient) GetCardsByNameProto(ctx context.Context, cardNames cModel.CardNames) (*ygo.Cards, *cModel.APIError) {
+	return nil, &cModel.APIError{Message: "Service unavailable", StatusCode: http.StatusServiceUnavailable}
+}
+
+func (svc failingYGOCardClient) GetCardsReferencingNameInEffectProto(ctx context.Context, cards []string) (*ygo.CardList, *cModel.APIError) {
+	return nil, &cModel.APIError{Message: "Service unavailable", StatusCode: http.StatusServiceUnavailable}
+}
+
+func TestDegradedSuggestions(t *testing.T) {
+	// setup
+	assert := assert.New(t)
+	downstream.YGO = client.YGOClientImpV1{CardService: failingYGOCardClient{}}
+	t.Cleanup(func() { downstream.YGO = client.YGOClientImpV1{CardService: skc_testing.YGOCardClientMock{}} })
+
+	cardName := "The Legendary Fisherman II"
+	suggestions, err := getCardSuggestions(skc_testing.TestContext, skc_testing.CardMocks[cardName], skc_testing.CardColors, skc_testing.CardArchetypeMock[cardName])
+	assert.NotNil(err)
+	assert.True(suggestions.Degraded)
+	assert.True(suggestions.Failed(model.CardNameResolutionStage))
+	assert.Equal(http.StatusServiceUnavailable, suggestions.Errors[0].StatusCode)
+	assert.Empty(suggestions.NamedReferences, "Named references can't be resolved w/o ygo-service")
+	assert.Equal(cardSuggestionsWithoutSelfReferenceMock[cardName].ReferencedArchetypes, suggestions.ReferencedArchetypes, "Archetypes should still be parsed")
+
+	subjects := cModel.BatchCardData[cModel.CardIDs]{CardInfo: cModel.CardDataMap{skc_testing.CardMocks[cardName].ID: skc_testing.CardMocks[cardName]}}
+	support, err := getBatchSupport(skc_testing.TestContext, subjects, skc_testing.CardColors)
+	assert.NotNil(err)
+	assert.True(support.Degraded)
+	assert.Equal([]model.StageError{{Stage: model.SupportLookupStage, Message: "Service unavailable", StatusCode: http.StatusServiceUnavailable}}, support.Errors)
+	assert.NotNil(support.ReferencedBy)
+	assert.Empty(support.ReferencedBy)
+}
+
+// ygo-service can look up the subject of a suggestion request
+type subjectYGOCardClient struct {
+	skc_testing.YGOCardClientMock
+	subject cModel.YGOCardREST
+}
+
+func (svc subjectYGOCardClient) GetCardByIDProto(ctx context.Context, cardID string) (*ygo.Card, *cModel.APIError) {
+	return svc.subject.ToProto(), nil
+}
+
+// Only Uria has a release date - the other references were never embedded
+type missingReleaseDatesDAO struct {
+	skc_testing.SKCSuggestionEngineDAOImplementation
+}
+
+func (impl missingReleaseDatesDAO) GetRelevantArchetypes(ctx context.Context, subjects cModel.CardIDs) ([]string, *cModel.APIError) {
+	return []string{}, nil
+}
+
+func (impl missingReleaseDatesDAO) GetCardReleaseDates(ctx context.Context, cardIDs cModel.CardIDs) (map[string]string, *cModel.APIError) {
+	return map[string]string{skc_testing.CardMocks["Uria, Lord of Searing Flames"].ID: "2007-11-17"}, nil
+}
+
+func TestSuggestionsWithMissingReleaseDates(t *testing.T) {
+	// setup
+	assert := assert.New(t)
+	armityle := skc_testing.CardMocks["Armityle the Chaos Phantasm"]
+	downstream.YGO = client.YGOClientImpV1{CardService: subjectYGOCardClient{subject: armityle}}
+	skcSuggestionEngineDBInterface = missingReleaseDatesDAO{}
+	t.Cleanup(func() {
+		downstream.YGO = client.YGOClientImpV1{CardService: skc_testing.YGOCardClientMock{}}
+		skcSuggestionEngineDBInterface = skc_testing.SKCSuggestionEngineDAOImplementation{}
+	})
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("cardID", armityle.ID)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/suggestions/card/"+armityle.ID+"?sort=releaseDate", nil)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	res := httptest.NewRecorder()
+	getCardSuggestionsHandler(res, req)
+
+	var suggestions struct {
+		model.Degradation
+		NamedMaterials []struct {
+			Card cModel.YGOCardREST `json:"card"`
+		} `json:"namedMaterials"`
+	}
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Nil(json.NewDecoder(res.Body).Decode(&suggestions))
+	assert.True(suggestions.Degraded, "Suggestions should be flagged as degraded when most references have no release date")
+	assert.True(suggestions.Failed(model.RankingSignalsStage))
+	assert.Len(suggestions.NamedMaterials, 3, "References should still be returned")
+	assert.Equal("Uria, Lord of Searing Flames", suggestions.NamedMaterials[0].Card.Name, "Cards with a release date should be ranked first")
+}
+
 func TestCleanupReference(t *testing.T) {
 	assert := assert.New(t)
 
@@ -51,7 +168,7 @@ var (
 		"Elemental HERO Sunrise": {
 			NamedMaterials:       []model.CardReference{},
 			MaterialArchetypes:   []string{"HERO"},
-			NamedReferences:      []model.CardReference{{Occurrences: 1, Card: skc_testing.CardMocks["Elemental HERO Sunrise"]}, {Occurrences: 1, Card: skc_testing.CardMocks["Miracle Fusion"]}},
+			NamedReferences:      []model.CardReference{{Occurrences: 1, Card: skc_testing.CardMocks["Elemental HERO Sunrise"]}, {Occurrences: 1, Card: skc_testing.CardMocks["Miracle Fusion"], Intents: []model.ReferenceIntent{model.SearchIntent}}},
 			ReferencedArchetypes: []string{"HERO"},
 		},
 		"Gem-Knight Master Diamond": {
@@ -61,16 +178,21 @@ var (
 			ReferencedArchetypes: []string{"Gem-", "Gem-Knight"},
 		},
 		"A-to-Z-Dragon Buster Cannon": {
-			NamedMaterials:       []model.CardReference{{Occurrences: 1, Card: skc_testing.CardMocks["ABC-Dragon Buster"]}, {Occurrences: 1, Card: skc_testing.CardMocks["XYZ-Dragon Cannon"]}},
-			MaterialArchetypes:   []string{},
-			NamedReferences:      []model.CardReference{{Occurrences: 1, Card: skc_testing.CardMocks["ABC-Dragon Buster"]}, {Occurrences: 1, Card: skc_testing.CardMocks["XYZ-Dragon Cannon"]}, {Occurrences: 1, Card: skc_testing.CardMocks["Polymerization"]}},
+			NamedMaterials:     []model.CardReference{{Occurrences: 1, Card: skc_testing.CardMocks["ABC-Dragon Buster"]}, {Occurrences: 1, Card: skc_testing.CardMocks["XYZ-Dragon Cannon"]}},
+			MaterialArchetypes: []string{},
+			NamedReferences: []model.CardReference{
+				{Occurrences: 1, Card: skc_testing.CardMocks["ABC-Dragon Buster"], Intents: []model.ReferenceIntent{model.BanishIntent}},
+				{Occurrences: 1, Card: skc_testing.CardMocks["XYZ-Dragon Cannon"], Intents: []model.ReferenceIntent{model.BanishIntent}},
+				{Occurrences: 1, Card: skc_testing.CardMocks["Polymerization"]},
+			},
 			ReferencedArchetypes: []string{"Polymerization"},
 		},
 		"The Legendary Fisherman II": {
 			NamedMaterials:       []model.CardReference{},
 			MaterialArchetypes:   []string{},
-			NamedReferences:      []model.CardReference{{Occurrences: 1, Card: skc_testing.CardMocks["The Legendary Fisherman"]}, {Occurrences: 1, Card: skc_testing.CardMocks["Umi"]}},
-			ReferencedArchetypes: []string{"The Legendary Fisherman", "Umi"},
+			NamedReferences:      []model.CardReference{{Occurrences: 1, Card: skc_testing.CardMocks["Umi"]}},
+			ReferencedArchetypes: []string{"Umi"},
+			NameAliases:          []string{"The Legendary Fisherman"},
 		},
 		"Armityle the Chaos Phantasm": {
 			NamedMaterials: []model.CardReference{
@@ -92,9 +214,10 @@ var (
 			},
 			MaterialArchetypes: []string{},
 			NamedReferences: []model.CardReference{
-				{Occurrences: 2, Card: skc_testing.CardMocks["Armityle the Chaos Phantasm"]},
+				{Occurrences: 1, Card: skc_testing.CardMocks["Armityle the Chaos Phantasm"], Intents: []model.ReferenceIntent{model.SpecialSummonIntent}},
 			},
 			ReferencedArchetypes: []string{},
+			NameAliases:          []string{"Armityle the Chaos Phantasm"},
 		},
 		"King Dragun": {
 			NamedMaterials: []model.CardReference{
@@ -128,7 +251,7 @@ var (
 		"Elemental HERO Sunrise": {
 			NamedMaterials:       []model.CardReference{},
 			MaterialArchetypes:   []string{"HERO"},
-			NamedReferences:      []model.CardReference{{Occurrences: 1, Card: skc_testing.CardMocks["Miracle Fusion"]}},
+			NamedReferences:      []model.CardReference{{Occurrences: 1, Card: skc_testing.CardMocks["Miracle Fusion"], Intents: []model.ReferenceIntent{model.SearchIntent}}},
 			ReferencedArchetypes: []string{"HERO"},
 		},
 		"Gem-Knight Master Diamond": {
@@ -138,16 +261,21 @@ var (
 			ReferencedArchetypes: []string{"Gem-", "Gem-Knight"},
 		},
 		"A-to-Z-Dragon Buster Cannon": {
-			NamedMaterials:       []model.CardReference{{Occurrences: 1, Card: skc_testing.CardMocks["ABC-Dragon Buster"]}, {Occurrences: 1, Card: skc_testing.CardMocks["XYZ-Dragon Cannon"]}},
-			MaterialArchetypes:   []string{},
-			NamedReferences:      []model.CardReference{{Occurrences: 1, Card: skc_testing.CardMocks["ABC-Dragon Buster"]}, {Occurrences: 1, Card: skc_testing.CardMocks["XYZ-Dragon Cannon"]}, {Occurrences: 1, Card: skc_testing.CardMocks["Polymerization"]}},
+			NamedMaterials:     []model.CardReference{{Occurrences: 1, Card: skc_testing.CardMocks["ABC-Dragon Buster"]}, {Occurrences: 1, Card: skc_testing.CardMocks["XYZ-Dragon Cannon"]}},
+			MaterialArchetypes: []string{},
+			NamedReferences: []model.CardReference{
+				{Occurrences: 1, Card: skc_testing.CardMocks["ABC-Dragon Buster"], Intents: []model.ReferenceIntent{model.BanishIntent}},
+				{Occurrences: 1, Card: skc_testing.CardMocks["XYZ-Dragon Cannon"], Intents: []model.ReferenceIntent{model.BanishIntent}},
+				{Occurrences: 1, Card: skc_testing.CardMocks["Polymerization"]},
+			},
 			ReferencedArchetypes: []string{"Polymerization"},
 		},
 		"The Legendary Fisherman II": {
 			NamedMaterials:       []model.CardReference{},
 			MaterialArchetypes:   []string{},
-			NamedReferences:      []model.CardReference{{Occurrences: 1, Card: skc_testing.CardMocks["The Legendary Fisherman"]}, {Occurrences: 1, Card: skc_testing.CardMocks["Umi"]}},
-			ReferencedArchetypes: []string{"The Legendary Fisherman", "Umi"},
+			NamedReferences:      []model.CardReference{{Occurrences: 1, Card: skc_testing.CardMocks["Umi"]}},
+			ReferencedArchetypes: []string{"Umi"},
+			NameAliases:          []string{"The Legendary Fisherman"},
 		},
 		"Armityle the Chaos Phantasm": {
 			NamedMaterials: []model.CardReference{
@@ -169,9 +297,10 @@ var (
 			},
 			MaterialArchetypes: []string{},
 			NamedReferences: []model.CardReference{
-				{Occurrences: 2, Card: skc_testing.CardMocks["Armityle the Chaos Phantasm"]},
+				{Occurrences: 1, Card: skc_testing.CardMocks["Armityle the Chaos Phantasm"], Intents: []model.ReferenceIntent{model.SpecialSummonIntent}},
 			},
 			ReferencedArchetypes: []string{},
+			NameAliases:          []string{"Armityle the Chaos Phantasm"},
 		},
 		"King Dragun": {
 			NamedMaterials: []model.CardReference{
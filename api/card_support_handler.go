@@ -1,9 +1,13 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/go-chi/chi/v5"
@@ -12,6 +16,7 @@ import (
 	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
 	"github.com/ygo-skc/skc-suggestion-engine/downstream"
 	"github.com/ygo-skc/skc-suggestion-engine/model"
+	"github.com/ygo-skc/skc-suggestion-engine/suggest"
 )
 
 const (
@@ -24,6 +29,17 @@ func getCardSupportHandler(res http.ResponseWriter, req *http.Request) {
 	logger, ctx := cUtil.InitRequest(req.Context(), apiName, cardSupportOp, slog.String("card_id", cardID))
 	logger.Info("Getting support cards")
 
+	intents, err := parseIntentFilter(req.URL.Query())
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+	strategy, err := parseRankingStrategy(req.URL.Query())
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+
 	cardProto, err := downstream.YGO.CardService.GetCardByIDProto(ctx, cardID)
 	if err != nil {
 		err.HandleServerResponse(res)
@@ -31,22 +47,24 @@ func getCardSupportHandler(res http.ResponseWriter, req *http.Request) {
 	}
 	subject := cModel.YGOCardRESTFromProto(cardProto)
 
-	cardName := (subject).GetName()
-	support := model.CardSupport{Card: subject, ReferencedBy: make([]model.CardReference, 0), MaterialFor: make([]model.CardReference, 0)}
-
-	cr, err := downstream.YGO.CardService.GetCardsReferencingNameInEffectProto(ctx, []string{cardName})
+	supportByCardID, err := getSupportReferences(ctx, []cModel.YGOCard{subject})
 	if err != nil {
 		err.HandleServerResponse(res)
 		return
 	}
-	cardRefsProto := cModel.YGOCardListRESTFromProto(cr)
-
-	support.ReferencedBy, support.MaterialFor = determineSupportCards(support.Card, cardRefsProto)
-	numNamedReferences, numMaterialReferences := len(support.ReferencedBy), len(support.MaterialFor)
-	if numNamedReferences == 0 && numMaterialReferences == 0 {
+	support := filterSupportByIntent(supportByCardID[subject.GetID()], intents)
+	if err := rankReferences(ctx, strategy, nil, &support.ReferencedBy, &support.MaterialFor, &support.AliasedBy); err != nil {
+		logger.Warn("Could not rank card support using every signal", slog.String("sort", string(strategy)), slog.Any("err", err))
+	}
+	if parseIncludePopularity(req.URL.Query()) {
+		setPopularity(&support.ReferencedBy, &support.MaterialFor, &support.AliasedBy)
+	}
+	numNamedReferences, numMaterialReferences, numAliases := len(support.ReferencedBy), len(support.MaterialFor), len(support.AliasedBy)
+	if numNamedReferences == 0 && numMaterialReferences == 0 && numAliases == 0 {
 		logger.Warn("Card has no support")
 	} else {
-		logger.Info("Card support generated", slog.Int("referenced_by_count", numNamedReferences), slog.Int("material_for_count", numMaterialReferences))
+		logger.Info("Card support generated", slog.Int("referenced_by_count", numNamedReferences), slog.Int("material_for_count", numMaterialReferences),
+			slog.Int("aliased_by_count", numAliases))
 	}
 
 	res.WriteHeader(http.StatusOK)
@@ -55,14 +73,89 @@ func getCardSupportHandler(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// Intents used to filter references - query param intent can be repeated or contain comma separated values, eg: intent=search,specialSummon
+func parseIntentFilter(query url.Values) ([]model.ReferenceIntent, *cModel.APIError) {
+	intents := make([]model.ReferenceIntent, 0)
+	for _, value := range query["intent"] {
+		for intent := range strings.SplitSeq(value, ",") {
+			if intent = strings.TrimSpace(intent); intent == "" {
+				continue
+			}
+			if !slices.Contains(model.ReferenceIntents, model.ReferenceIntent(intent)) {
+				return nil, &cModel.APIError{Message: fmt.Sprintf("intent should be one of: %s", joinIntents(model.ReferenceIntents)), StatusCode: http.StatusBadRequest}
+			}
+			intents = append(intents, model.ReferenceIntent(intent))
+		}
+	}
+	return intents, nil
+}
+
+func joinIntents(intents []model.ReferenceIntent) string {
+	values := make([]string, len(intents))
+	for i, intent := range intents {
+		values[i] = string(intent)
+	}
+	return strings.Join(values, ", ")
+}
+
+// only cards doing one of intents with the subject are kept - material references rarely have an intent so they are usually removed
+func filterSupportByIntent(support model.CardSupport, intents []model.ReferenceIntent) model.CardSupport {
+	support.ReferencedBy = model.FilterByIntent(support.ReferencedBy, intents)
+	support.MaterialFor = model.FilterByIntent(support.MaterialFor, intents)
+	support.AliasedBy = model.FilterByIntent(support.AliasedBy, intents)
+	return support
+}
+
+// Support of each subject keyed by card ID. The reference index is used when possible - remaining subjects are looked up by asking ygo-service to search the text of every card.
+func getSupportReferences(ctx context.Context, subjects []cModel.YGOCard) (map[string]model.CardSupport, *cModel.APIError) {
+	supportByCardID := make(map[string]model.CardSupport, len(subjects))
+	unindexedNames := make([]string, 0)
+	for _, subject := range subjects {
+		if support, isIndexed := cardReferenceIndex.Support(subject); isIndexed {
+			supportByCardID[subject.GetID()] = support
+		} else {
+			unindexedNames = append(unindexedNames, subject.GetName())
+		}
+	}
+
+	if len(unindexedNames) == 0 {
+		return sortSupport(supportByCardID), nil
+	}
+
+	cardRefsProto, err := downstream.YGO.CardService.GetCardsReferencingNameInEffectProto(ctx, unindexedNames)
+	if err != nil {
+		return nil, err
+	}
+	cardRefs := cModel.YGOCardListRESTFromProto(cardRefsProto)
+	for _, subject := range subjects {
+		if _, isIndexed := supportByCardID[subject.GetID()]; !isIndexed {
+			referencedBy, materialFor := determineSupportCards(subject, cardRefs)
+			supportByCardID[subject.GetID()] = model.CardSupport{Card: subject, ReferencedBy: referencedBy, MaterialFor: materialFor,
+				AliasedBy: determineAliasingCards(subject, cardRefs)}
+		}
+	}
+	return sortSupport(supportByCardID), nil
+}
+
+// references are ordered by name (then ID) as neither the index nor ygo-service return them in a stable order
+func sortSupport(supportByCardID map[string]model.CardSupport) map[string]model.CardSupport {
+	for _, support := range supportByCardID {
+		slices.SortFunc(support.ReferencedBy, suggest.SortCardReferences(nil))
+		slices.SortFunc(support.MaterialFor, suggest.SortCardReferences(nil))
+		slices.SortFunc(support.AliasedBy, suggest.SortCardReferences(nil))
+	}
+	return supportByCardID
+}
+
 // Iterates over a list of support cards and attempts to determine if subject is found in material clause or within the body of the reference.
 // If the name is found in the material clause, we can assume the subject is a required or optional summoning material - otherwise its a support card.
+// References aliasing subject are not support - see determineAliasingCards.
 func determineSupportCards(subject cModel.YGOCard, references []cModel.YGOCard) ([]model.CardReference, []model.CardReference) {
 	referencedBy := []model.CardReference{}
 	materialFor := []model.CardReference{}
 
 	for _, reference := range references {
-		if reference.GetName() == subject.GetName() {
+		if reference.GetName() == subject.GetName() || isAliasOf(reference, subject) {
 			continue
 		}
 
@@ -74,9 +167,24 @@ func determineSupportCards(subject cModel.YGOCard, references []cModel.YGOCard)
 		}
 
 		if parser.TextContainsSubStr(effect, subject.GetName()) {
-			referencedBy = append(referencedBy, model.CardReference{Occurrences: 1, Card: reference})
+			referencedBy = append(referencedBy, model.CardReference{Occurrences: 1, Card: reference, Intents: suggest.ReferenceIntents(effect, subject.GetName())})
 		}
 	}
 
 	return referencedBy, materialFor
 }
+
+// References whose name becomes or is always treated as the name of subject, eg: This card's name becomes "Armityle the Chaos Phantasm" while on the field.
+func determineAliasingCards(subject cModel.YGOCard, references []cModel.YGOCard) []model.CardReference {
+	aliasedBy := []model.CardReference{}
+	for _, reference := range references {
+		if reference.GetName() != subject.GetName() && isAliasOf(reference, subject) {
+			aliasedBy = append(aliasedBy, model.CardReference{Occurrences: 1, Card: reference})
+		}
+	}
+	return aliasedBy
+}
+
+func isAliasOf(reference cModel.YGOCard, subject cModel.YGOCard) bool {
+	return slices.Contains(suggest.FindAliases(reference.GetEffect()).NameAliases, subject.GetName())
+}
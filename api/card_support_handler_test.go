@@ -1,12 +1,21 @@
 package api
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/ygo-skc/skc-go/common/v3/client"
 	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	"github.com/ygo-skc/skc-go/common/v3/ygo"
+	"github.com/ygo-skc/skc-suggestion-engine/db"
+	"github.com/ygo-skc/skc-suggestion-engine/downstream"
 	"github.com/ygo-skc/skc-suggestion-engine/model"
+	"github.com/ygo-skc/skc-suggestion-engine/reference"
 	skc_testing "github.com/ygo-skc/skc-suggestion-engine/testing"
 )
 
@@ -22,8 +31,8 @@ var (
 	expectedSupportCardsMocks = map[string]model.CardSupport{
 		"Dark Magician": {
 			ReferencedBy: []model.CardReference{
-				{Card: skc_testing.CardMocks["Magicians' Souls"], Occurrences: 1},
-				{Card: skc_testing.CardMocks["The Dark Magicians"], Occurrences: 1}},
+				{Card: skc_testing.CardMocks["Magicians' Souls"], Occurrences: 1, Intents: []model.ReferenceIntent{model.SpecialSummonIntent}},
+				{Card: skc_testing.CardMocks["The Dark Magicians"], Occurrences: 1, Intents: []model.ReferenceIntent{model.SpecialSummonIntent}}},
 			MaterialFor: []model.CardReference{
 				{Card: skc_testing.CardMocks["Dark Paladin"], Occurrences: 1},
 				{Card: skc_testing.CardMocks["The Dark Magicians"], Occurrences: 1}},
@@ -35,8 +44,9 @@ var (
 				{Card: skc_testing.CardMocks["Armityle the Chaos Phantasm - Phantom of Fury"], Occurrences: 1}},
 		},
 		"Elemental HERO Neos": {
-			ReferencedBy: []model.CardReference{{Card: skc_testing.CardMocks["Neos Wiseman"], Occurrences: 1}},
-			MaterialFor:  []model.CardReference{{Card: skc_testing.CardMocks["Elemental HERO Air Neos"], Occurrences: 1}},
+			ReferencedBy: []model.CardReference{
+				{Card: skc_testing.CardMocks["Neos Wiseman"], Occurrences: 1, Intents: []model.ReferenceIntent{model.SpecialSummonIntent, model.SendToGYIntent}}},
+			MaterialFor: []model.CardReference{{Card: skc_testing.CardMocks["Elemental HERO Air Neos"], Occurrences: 1}},
 		},
 	}
 )
@@ -61,3 +71,146 @@ func TestDetermineSupportCards(t *testing.T) {
 		assert.Equal(actualMaterialFor, expectedMaterialFor, "Expected contents of MaterialFor slice is different than what is actually received")
 	}
 }
+
+func TestReferenceIndexSupport(t *testing.T) {
+	// setup
+	assert := assert.New(t)
+	dao, _ := db.NewSKCSuggestionEngineDAOInMemory("")
+	index := reference.NewIndex(dao, determineSupportCards)
+
+	_, isIndexed := index.Support(skc_testing.CardMocks["Dark Magician"])
+	assert.False(isIndexed, "Index should not be used before it is built")
+
+	cards := make([]cModel.YGOCard, 0, len(skc_testing.CardMocks))
+	for _, card := range skc_testing.CardMocks {
+		cards = append(cards, card)
+	}
+	assert.Nil(index.Build(skc_testing.TestContext, cards))
+
+	// index should agree with ygo-service search + determineSupportCards
+	for cardName, expected := range expectedSupportCardsMocks {
+		support, isIndexed := index.Support(skc_testing.CardMocks[cardName])
+		assert.True(isIndexed)
+		assert.ElementsMatch(expected.ReferencedBy, support.ReferencedBy, fmt.Sprintf("ReferencedBy is incorrect for %s", cardName))
+		assert.ElementsMatch(expected.MaterialFor, support.MaterialFor, fmt.Sprintf("MaterialFor is incorrect for %s", cardName))
+	}
+
+	_, isIndexed = index.Support(cModel.YGOCardREST{ID: "99999999", Name: "Unknown Card"})
+	assert.False(isIndexed, "Cards missing from the index should be looked up using ygo-service")
+
+	resolved, isResolved := index.CardsByName([]string{"Dark Magician", "Dark Paladin"})
+	assert.True(isResolved)
+	assert.Equal(cModel.CardDataMap{"Dark Magician": skc_testing.CardMocks["Dark Magician"], "Dark Paladin": skc_testing.CardMocks["Dark Paladin"]}, resolved)
+	_, isResolved = index.CardsByName([]string{"Dark Magician", "Magician"})
+	assert.False(isResolved, "Names missing from the index might belong to cards it doesn't know about")
+
+	// snapshot
+	entries, _ := dao.GetReferenceIndex(skc_testing.TestContext)
+	assert.Len(entries, len(skc_testing.CardMocks))
+	for _, entry := range entries {
+		if entry.Name == "Hamon, Lord of Striking Thunder" {
+			assert.ElementsMatch([]string{skc_testing.CardMocks["Armityle the Chaos Phantasm"].ID, skc_testing.CardMocks["Armityle the Chaos Phantasm - Phantom of Fury"].ID}, entry.MaterialFor)
+			assert.Empty(entry.ReferencedBy)
+		}
+	}
+	assert.True(index.Status().Ready)
+}
+
+// ygo-service can look up cards by ID
+type cardsByIDClient struct {
+	skc_testing.YGOCardClientMock
+}
+
+func (svc cardsByIDClient) GetCardsByIDProto(ctx context.Context, cardIDs cModel.CardIDs) (*ygo.Cards, *cModel.APIError) {
+	found := make(map[string]*ygo.Card, len(cardIDs))
+	for _, card := range skc_testing.CardMocks {
+		if slices.Contains(cardIDs, card.ID) {
+			found[card.ID] = card.ToProto()
+		}
+	}
+	return &ygo.Cards{CardInfo: found}, nil
+}
+
+func TestEmbeddedCardsReferenceIndex(t *testing.T) {
+	// setup
+	assert := assert.New(t)
+	downstream.YGO = client.YGOClientImpV1{CardService: cardsByIDClient{}}
+	t.Cleanup(func() { downstream.YGO = client.YGOClientImpV1{CardService: skc_testing.YGOCardClientMock{}} })
+
+	// most cards referencing Dark Magician are not embedded
+	darkMagician, magiciansSouls := skc_testing.CardMocks["Dark Magician"], skc_testing.CardMocks["Magicians' Souls"]
+	dao, _ := db.NewSKCSuggestionEngineDAOInMemory("")
+	dao.UpsertCardEmbeddings(skc_testing.TestContext, []model.CardEmbedding{{ID: darkMagician.ID}, {ID: magiciansSouls.ID}})
+	index := reference.NewIndex(dao, determineSupportCards)
+
+	index.Refresh(skc_testing.TestContext)
+	assert.True(index.Status().Ready)
+	assert.Equal(2, index.Status().Cards)
+	support, isIndexed := index.Support(darkMagician)
+	assert.True(isIndexed)
+	assert.ElementsMatch(expectedSupportCardsMocks["Dark Magician"].ReferencedBy, support.ReferencedBy, "Cards that aren't embedded should be support")
+	assert.ElementsMatch(expectedSupportCardsMocks["Dark Magician"].MaterialFor, support.MaterialFor)
+	_, isIndexed = index.Support(skc_testing.CardMocks["Dark Paladin"])
+	assert.False(isIndexed, "Support of cards that aren't embedded should be looked up using ygo-service")
+
+	resolved, isResolved := index.CardsByName([]string{"Dark Magician", "Dark Paladin"})
+	assert.True(isResolved, "Referencing cards should be resolvable")
+	assert.Equal(cModel.CardDataMap{"Dark Magician": darkMagician, "Dark Paladin": skc_testing.CardMocks["Dark Paladin"]}, resolved)
+	_, isResolved = index.CardsByName([]string{"Dark Magician", "Elemental HERO Neos"})
+	assert.False(isResolved)
+
+	// snapshot
+	restored := reference.NewIndex(dao, determineSupportCards)
+	assert.Nil(restored.Load(skc_testing.TestContext))
+	support, isIndexed = restored.Support(darkMagician)
+	assert.True(isIndexed)
+	assert.ElementsMatch(expectedSupportCardsMocks["Dark Magician"].MaterialFor, support.MaterialFor, "Referencing cards should be fetched when loading a snapshot")
+
+	// snapshots built before references were searched in ygo-service only cover embedded cards
+	dao.ReplaceReferenceIndex(skc_testing.TestContext, []model.ReferenceIndexEntry{{CardID: darkMagician.ID, Name: darkMagician.Name}})
+	stale := reference.NewIndex(dao, determineSupportCards)
+	assert.Nil(stale.Load(skc_testing.TestContext))
+	assert.False(stale.Status().Ready, "Stale snapshots should be rebuilt instead of loaded")
+}
+
+func TestAliasSupport(t *testing.T) {
+	// setup
+	assert := assert.New(t)
+	fisherman, fishermanII := skc_testing.CardMocks["The Legendary Fisherman"], skc_testing.CardMocks["The Legendary Fisherman II"]
+	expectedAliasedBy := []model.CardReference{{Occurrences: 1, Card: fishermanII}}
+
+	referencedBy, materialFor := determineSupportCards(fisherman, []cModel.YGOCard{fishermanII})
+	assert.Empty(referencedBy, "Aliasing cards are not support")
+	assert.Empty(materialFor)
+	assert.Equal(expectedAliasedBy, determineAliasingCards(fisherman, []cModel.YGOCard{fishermanII}))
+	assert.Empty(determineAliasingCards(fishermanII, []cModel.YGOCard{fisherman}))
+
+	index := reference.NewIndex(nil, determineSupportCards)
+	assert.Nil(index.Build(skc_testing.TestContext, []cModel.YGOCard{fisherman, fishermanII, skc_testing.CardMocks["Umi"]}))
+	support, isIndexed := index.Support(fisherman)
+	assert.True(isIndexed)
+	assert.Equal(expectedAliasedBy, support.AliasedBy)
+	assert.Empty(support.ReferencedBy)
+}
+
+func TestIntentFilter(t *testing.T) {
+	assert := assert.New(t)
+
+	intents, err := parseIntentFilter(url.Values{"intent": {"search,specialSummon", "banish"}})
+	assert.Nil(err)
+	assert.Equal([]model.ReferenceIntent{model.SearchIntent, model.SpecialSummonIntent, model.BanishIntent}, intents)
+
+	_, err = parseIntentFilter(url.Values{"intent": {"draw"}})
+	assert.NotNil(err)
+	assert.Equal(http.StatusBadRequest, err.StatusCode)
+
+	support := filterSupportByIntent(expectedSupportCardsMocks["Elemental HERO Neos"], []model.ReferenceIntent{model.SendToGYIntent})
+	assert.Equal(expectedSupportCardsMocks["Elemental HERO Neos"].ReferencedBy, support.ReferencedBy)
+	assert.Empty(support.MaterialFor, "Material references have no intent")
+
+	support = filterSupportByIntent(expectedSupportCardsMocks["Dark Magician"], []model.ReferenceIntent{model.SearchIntent})
+	assert.Empty(support.ReferencedBy)
+
+	support = filterSupportByIntent(expectedSupportCardsMocks["Dark Magician"], []model.ReferenceIntent{})
+	assert.Len(support.MaterialFor, 2, "Nothing should be filtered w/o intents")
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+)
+
+const (
+	maxPageLimit = 500
+)
+
+// Query params: limit (1 - 500, everything is returned when not set) and cursor (nextCursor of the previous page)
+func parsePage(query url.Values) (model.Page, *cModel.APIError) {
+	var page model.Page
+	if value := query.Get("limit"); value != "" {
+		var err error
+		if page.Limit, err = strconv.Atoi(value); err != nil || page.Limit < 1 || page.Limit > maxPageLimit {
+			return model.Page{}, &cModel.APIError{Message: fmt.Sprintf("limit should be a number between 1 and %d", maxPageLimit), StatusCode: http.StatusBadRequest}
+		}
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		var err error
+		if page.Offset, err = model.DecodeCursor(cursor); err != nil {
+			return model.Page{}, &cModel.APIError{Message: "cursor is not valid - use the nextCursor value of a previous response", StatusCode: http.StatusBadRequest}
+		}
+	}
+	return page, nil
+}
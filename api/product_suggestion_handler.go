@@ -27,6 +27,17 @@ func getProductSuggestionsHandler(res http.ResponseWriter, req *http.Request) {
 		slog.String("product_id", productID))
 	logger.Info("Getting product card suggestions")
 
+	page, err := parsePage(req.URL.Query())
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+	strategy, err := parseRankingStrategy(req.URL.Query())
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+
 	cards, ccIDs, relevantArchetypes, err := loadPSData(ctx, productID)
 	if err != nil {
 		logger.Error("Failed to retrieve product data", slog.Any("err", err))
@@ -34,21 +45,43 @@ func getProductSuggestionsHandler(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	var suggestions model.BatchCardSuggestions[cModel.CardIDs]
-	var support model.BatchCardSupport[cModel.CardIDs]
+	var productSuggestions model.ProductSuggestions[cModel.CardIDs]
+	var suggestionsErr, supportErr *cModel.APIError
 
 	var wg sync.WaitGroup
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
-		suggestions = getBatchSuggestions(ctx, *cards, relevantArchetypes, ccIDs.Values)
+		productSuggestions.Suggestions, suggestionsErr = getBatchSuggestions(ctx, *cards, relevantArchetypes, ccIDs.Values)
+	}()
+	go func() {
+		defer wg.Done()
+		productSuggestions.Support, supportErr = getBatchSupport(ctx, *cards, ccIDs.Values)
 	}()
-	go func() { defer wg.Done(); support = getBatchSupport(ctx, *cards, ccIDs.Values) }()
 	wg.Wait()
 
-	logger.Info("Successfully retrieved product card suggestions")
-	res.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(res).Encode(model.ProductSuggestions[cModel.CardIDs]{Suggestions: suggestions, Support: support}); err != nil {
+	if err := rankReferences(ctx, strategy, ccIDs.Values, &productSuggestions.Suggestions.NamedMaterials, &productSuggestions.Suggestions.NamedReferences,
+		&productSuggestions.Support.ReferencedBy, &productSuggestions.Support.MaterialFor, &productSuggestions.Support.AliasedBy); err != nil {
+		logger.Warn("Could not rank product card suggestions using every signal", slog.String("sort", string(strategy)), slog.Any("err", err))
+		productSuggestions.Fail(model.RankingSignalsStage, err)
+	}
+	productSuggestions.Merge(productSuggestions.Suggestions.Degradation)
+	productSuggestions.Merge(productSuggestions.Support.Degradation)
+	productSuggestions.Paginate(page)
+	if parseIncludePopularity(req.URL.Query()) {
+		setPopularity(&productSuggestions.Suggestions.NamedMaterials, &productSuggestions.Suggestions.NamedReferences,
+			&productSuggestions.Support.ReferencedBy, &productSuggestions.Support.MaterialFor, &productSuggestions.Support.AliasedBy)
+	}
+	if productSuggestions.Degraded {
+		logger.Warn("Returning degraded product card suggestions", slog.Any("suggestions_err", suggestionsErr), slog.Any("support_err", supportErr),
+			slog.Any("errors", productSuggestions.Errors))
+	} else {
+		logger.Info("Successfully retrieved product card suggestions")
+	}
+
+	// archetypes are always available so product suggestions are never a total failure once product data is loaded
+	res.WriteHeader(batchStatusCode(productSuggestions.Degradation))
+	if err := json.NewEncoder(res).Encode(productSuggestions); err != nil {
 		logger.Error("Could not encode product suggestions response", slog.Any("err", err), slog.String("product_id", productID))
 	}
 }
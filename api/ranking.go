@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	"github.com/ygo-skc/skc-suggestion-engine/downstream"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+	"github.com/ygo-skc/skc-suggestion-engine/popularity"
+	"github.com/ygo-skc/skc-suggestion-engine/suggest"
+)
+
+// Query param sort - one of suggest.RankingStrategies, references use the default order when not set
+func parseRankingStrategy(query url.Values) (suggest.RankingStrategy, *cModel.APIError) {
+	strategy := suggest.RankingStrategy(query.Get("sort"))
+	if strategy == "" {
+		return suggest.DefaultRanking, nil
+	}
+
+	if !slices.Contains(suggest.RankingStrategies, strategy) {
+		names := make([]string, len(suggest.RankingStrategies))
+		for i, s := range suggest.RankingStrategies {
+			names[i] = string(s)
+		}
+		return "", &cModel.APIError{Message: fmt.Sprintf("sort should be one of: %s", strings.Join(names, ", ")), StatusCode: http.StatusBadRequest}
+	}
+	return strategy, nil
+}
+
+// Orders every list using the strategy - lists are expected to already be in the default order. Popularity breaks ties for every strategy.
+// Card colors are retrieved when ccIDs is nil and the strategy needs them. When the data the strategy needs can't be retrieved, lists keep the default order.
+// Release dates are only known for embedded cards - when most cards have none, lists are still ranked but an error is returned so responses can be flagged as degraded.
+func rankReferences(ctx context.Context, strategy suggest.RankingStrategy, ccIDs map[string]uint32, lists ...*[]model.CardReference) *cModel.APIError {
+	cardIDs := make(cModel.CardIDs, 0)
+	for _, refs := range lists {
+		for _, ref := range *refs {
+			if !slices.Contains(cardIDs, ref.Card.GetID()) {
+				cardIDs = append(cardIDs, ref.Card.GetID())
+			}
+		}
+	}
+	if len(cardIDs) == 0 {
+		return nil
+	}
+
+	signals := suggest.RankingSignals{CardColorIDs: ccIDs, Popularity: popularity.CardScores.Of(cardIDs)}
+	if strategy == suggest.DefaultRanking && !slices.ContainsFunc(cardIDs, func(cardID string) bool { return signals.Popularity[cardID] > 0 }) {
+		return nil // nothing to break ties with
+	}
+
+	if strategy.UsesCardColors() && ccIDs == nil {
+		cc, err := downstream.YGO.CardService.GetCardColorsProto(ctx)
+		if err != nil {
+			return err
+		}
+		signals.CardColorIDs = cc.GetValues()
+	}
+
+	if strategy.UsesReleaseDates() {
+		releaseDates, err := skcSuggestionEngineDBInterface.GetCardReleaseDates(ctx, cardIDs)
+		if err != nil {
+			return err
+		}
+		signals.ReleaseDates = releaseDates
+	}
+
+	for _, refs := range lists {
+		suggest.RankCardReferences(*refs, strategy, signals)
+	}
+
+	if strategy.UsesReleaseDates() && 2*len(signals.ReleaseDates) < len(cardIDs) {
+		return &cModel.APIError{
+			Message:    fmt.Sprintf("Release date is only known for %d of %d cards - cards without one are ranked last", len(signals.ReleaseDates), len(cardIDs)),
+			StatusCode: http.StatusNotFound,
+		}
+	}
+	return nil
+}
+
+// Query param includePopularity - popularity scores are only added to responses when true
+func parseIncludePopularity(query url.Values) bool {
+	return query.Get("includePopularity") == "true"
+}
+
+// Sets the popularity score of every reference
+func setPopularity(lists ...*[]model.CardReference) {
+	for _, refs := range lists {
+		for i := range *refs {
+			score := popularity.CardScores.Score((*refs)[i].Card.GetID())
+			(*refs)[i].Popularity = &score
+		}
+	}
+}
+
+// Popularity score of every card, keyed by card ID - used by responses listing cards instead of references
+func cardPopularity(lists ...[]cModel.YGOCard) map[string]float64 {
+	cardIDs := make([]string, 0)
+	for _, cards := range lists {
+		for _, card := range cards {
+			cardIDs = append(cardIDs, card.GetID())
+		}
+	}
+	return popularity.CardScores.Of(cardIDs)
+}
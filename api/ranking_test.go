@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ygo-skc/skc-go/common/v3/client"
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	"github.com/ygo-skc/skc-suggestion-engine/downstream"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+	"github.com/ygo-skc/skc-suggestion-engine/popularity"
+	"github.com/ygo-skc/skc-suggestion-engine/suggest"
+	skc_testing "github.com/ygo-skc/skc-suggestion-engine/testing"
+)
+
+// The Dark Magicians is the only card with views, release dates can't be retrieved
+type rankingSignalsDAO struct {
+	skc_testing.SKCSuggestionEngineDAOImplementation
+}
+
+func (impl rankingSignalsDAO) GetCardViews(ctx context.Context, from time.Time) (map[string]int, *cModel.APIError) {
+	return map[string]int{skc_testing.CardMocks["The Dark Magicians"].ID: 4}, nil
+}
+
+func (impl rankingSignalsDAO) GetCardReleaseDates(ctx context.Context, cardIDs cModel.CardIDs) (map[string]string, *cModel.APIError) {
+	return nil, &cModel.APIError{Message: "Could not get card embedding data.", StatusCode: http.StatusInternalServerError}
+}
+
+func TestParseRankingStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	strategy, err := parseRankingStrategy(url.Values{})
+	assert.Nil(err)
+	assert.Equal(suggest.DefaultRanking, strategy)
+
+	strategy, err = parseRankingStrategy(url.Values{"sort": {"releaseDate"}})
+	assert.Nil(err)
+	assert.Equal(suggest.ReleaseDateRanking, strategy)
+
+	_, err = parseRankingStrategy(url.Values{"sort": {"newest"}})
+	assert.NotNil(err)
+	assert.Equal(http.StatusBadRequest, err.StatusCode)
+}
+
+func TestRankReferences(t *testing.T) {
+	assert := assert.New(t)
+	skcSuggestionEngineDBInterface = rankingSignalsDAO{}
+	popularity.CardScores = popularity.NewScores(rankingSignalsDAO{}, time.Hour, time.Hour)
+	popularity.CardScores.Refresh(skc_testing.TestContext)
+	t.Cleanup(func() {
+		skcSuggestionEngineDBInterface = skc_testing.SKCSuggestionEngineDAOImplementation{}
+		popularity.CardScores = popularity.NewScores(nil, time.Hour, time.Hour)
+	})
+
+	darkMagician, theDarkMagicians, magiciansSouls := skc_testing.CardMocks["Dark Magician"], skc_testing.CardMocks["The Dark Magicians"],
+		skc_testing.CardMocks["Magicians' Souls"]
+	refs := func() []model.CardReference {
+		return []model.CardReference{{Card: darkMagician, Occurrences: 2}, {Card: magiciansSouls, Occurrences: 1}, {Card: theDarkMagicians, Occurrences: 1}}
+	}
+
+	popular := refs()
+	assert.Nil(rankReferences(skc_testing.TestContext, suggest.PopularityRanking, skc_testing.CardColors, &popular))
+	assert.Equal(theDarkMagicians.GetID(), popular[0].Card.GetID(), "Most popular card should be first")
+
+	tiebreak := refs()
+	assert.Nil(rankReferences(skc_testing.TestContext, suggest.DefaultRanking, map[string]uint32{}, &tiebreak))
+	assert.Equal([]string{darkMagician.GetID(), theDarkMagicians.GetID(), magiciansSouls.GetID()},
+		[]string{tiebreak[0].Card.GetID(), tiebreak[1].Card.GetID(), tiebreak[2].Card.GetID()}, "Popularity should break ties")
+
+	// support is sorted w/o card colors - the default order shouldn't bring them in
+	downstream.YGO = client.YGOClientImpV1{CardService: cardColorsFailingClient{}}
+	t.Cleanup(func() { downstream.YGO = client.YGOClientImpV1{CardService: skc_testing.YGOCardClientMock{}} })
+	support := []model.CardReference{{Card: darkMagician, Occurrences: 1}, {Card: magiciansSouls, Occurrences: 1}, {Card: theDarkMagicians, Occurrences: 1}}
+	assert.Nil(rankReferences(skc_testing.TestContext, suggest.DefaultRanking, nil, &support), "Card colors should not be retrieved")
+	assert.Equal([]string{theDarkMagicians.GetID(), darkMagician.GetID(), magiciansSouls.GetID()},
+		[]string{support[0].Card.GetID(), support[1].Card.GetID(), support[2].Card.GetID()}, "Popularity should only break ties")
+
+	unranked := refs()
+	assert.NotNil(rankReferences(skc_testing.TestContext, suggest.ReleaseDateRanking, nil, &unranked))
+	assert.Equal(refs(), unranked, "References should keep the default order when release dates can't be retrieved")
+
+	setPopularity(&popular)
+	assert.Equal(1.0, *popular[0].Popularity)
+	assert.Equal(0.0, *popular[1].Popularity, "Cards without views should have a score of 0")
+}
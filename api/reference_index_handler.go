@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
+)
+
+const (
+	referenceIndexRefreshOp = "Reference Index Refresh"
+	referenceIndexStatusOp  = "Reference Index Status"
+)
+
+// Rebuilds the reference index in the background using the current text of every card
+func refreshReferenceIndexHandler(res http.ResponseWriter, req *http.Request) {
+	logger, ctx := cUtil.InitRequest(req.Context(), apiName, referenceIndexRefreshOp)
+	logger.Info("Reference index refresh requested")
+
+	if !cardReferenceIndex.Start(ctx) {
+		cModel.HandleServerResponse(cModel.APIError{Message: "Reference index is already being refreshed", StatusCode: http.StatusConflict}, res)
+		return
+	}
+
+	res.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(res).Encode(cardReferenceIndex.Status()); err != nil {
+		logger.Error("Could not encode reference index refresh response", slog.Any("err", err))
+	}
+}
+
+func getReferenceIndexStatusHandler(res http.ResponseWriter, req *http.Request) {
+	logger, _ := cUtil.InitRequest(req.Context(), apiName, referenceIndexStatusOp)
+	logger.Info("Getting reference index status")
+
+	res.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(res).Encode(cardReferenceIndex.Status()); err != nil {
+		logger.Error("Could not encode reference index status response", slog.Any("err", err))
+	}
+}
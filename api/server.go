@@ -2,6 +2,7 @@ package api
 
 import (
 	"compress/gzip"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
@@ -18,7 +19,13 @@ import (
 	"github.com/rs/cors"
 	cModel "github.com/ygo-skc/skc-go/common/v3/model"
 	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
+	"github.com/ygo-skc/skc-suggestion-engine/covisit"
 	"github.com/ygo-skc/skc-suggestion-engine/db"
+	"github.com/ygo-skc/skc-suggestion-engine/downstream"
+	"github.com/ygo-skc/skc-suggestion-engine/embedding"
+	"github.com/ygo-skc/skc-suggestion-engine/popularity"
+	"github.com/ygo-skc/skc-suggestion-engine/reference"
+	"github.com/ygo-skc/skc-suggestion-engine/usage"
 	"golang.org/x/net/http2"
 )
 
@@ -33,6 +40,10 @@ var (
 	ipDB *ip2location.DB
 
 	skcSuggestionEngineDBInterface db.SKCSuggestionEngineDAO = db.SKCSuggestionEngineDAOImplementation{}
+	cardEmbeddingCache             *embedding.Cache
+	cardEmbeddingIngester          *embedding.Ingester
+	cardReferenceIndex             *reference.Index
+	rerankFallbackEnabled          = true
 
 	serverAPIKey    string
 	chicagoLocation *time.Location
@@ -136,8 +147,20 @@ func acceptsGzip(req *http.Request) bool {
 
 // Configures routes and their middle wares
 // This method should be called before the environment is set up as the API Key will be set according to the value found in environment
-func RunHttpServer() {
+func RunHttpServer(dao db.SKCSuggestionEngineDAO) {
 	serverAPIKey = cUtil.EnvMap["API_KEY"] // configure API Key
+	skcSuggestionEngineDBInterface = dao
+	cardEmbeddingCache = embedding.NewCacheFromEnv(downstream.EmbeddingClient, dao)
+	cardEmbeddingIngester = embedding.NewIngester(downstream.EmbeddingClient, dao)
+	rerankFallbackEnabled = cUtil.EnvMap["RERANK_FALLBACK"] != "none" // when the reranker fails, results are ordered using vector search
+	usage.DailyTracker = usage.NewTrackerFromEnv(dao)
+	usage.DailyTracker.Load(context.Background())
+	go usage.DailyTracker.Run(context.Background())
+	cardReferenceIndex = reference.NewIndexFromEnv(dao, determineSupportCards)
+	go cardReferenceIndex.Run(context.Background())
+	popularity.CardScores = popularity.NewScoresFromEnv(dao)
+	go popularity.CardScores.Run(context.Background())
+	go covisit.NewBuilderFromEnv(dao).Run(context.Background())
 	router := chi.NewRouter()
 
 	// common middleware
@@ -153,13 +176,20 @@ func RunHttpServer() {
 			// suggestions
 			r.Get(`/card/{cardID:\d{8}}`, getCardSuggestionsHandler)
 			r.Post("/card", getBatchSuggestionsHandler)
+			r.Post("/card/analyze", analyzeCardTextHandler)
 
 			// support
 			r.Get(`/card/support/{cardID:\d{8}}`, getCardSupportHandler)
 			r.Post("/card/support", getBatchSupportHandler)
 
+			// reference graph
+			r.Get(`/card/{cardID:\d{8}}/graph`, getCardGraphHandler)
+
 			// similar resources
 			r.Get(`/card/{cardID:\d{8}}/similar`, getSimilarCardsHandler)
+			r.Post("/card/similar", getBatchSimilarCardsHandler)
+			r.Get("/search", searchCardsHandler)
+			r.Get(`/card/{cardID:\d{8}}/also-viewed`, getAlsoViewedHandler)
 
 			r.Get(`/product/{productID:[0-9A-Z]{3,4}}`, getProductSuggestionsHandler)
 			r.Get("/archetype/{archetypeName}", getArchetypeSupportHandler)
@@ -170,6 +200,12 @@ func RunHttpServer() {
 		r.Group(func(r chi.Router) {
 			r.Use(verifyAPIKeyMiddleware)
 			r.Post("/traffic-analysis", submitNewTrafficDataHandler)
+			r.Post("/card-embeddings", submitCardEmbeddingIngestionHandler)
+			r.Get("/card-embeddings/{jobID}", getCardEmbeddingIngestionHandler)
+			r.Post("/card-embeddings/dedupe", dedupeCardEmbeddingsHandler)
+			r.Get("/usage", getTokenUsageHandler)
+			r.Post("/reference-index", refreshReferenceIndexHandler)
+			r.Get("/reference-index", getReferenceIndexStatusHandler)
 		})
 	})
 
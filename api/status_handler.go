@@ -20,7 +20,7 @@ const (
 func getAPIStatusHandler(res http.ResponseWriter, req *http.Request) {
 	logger, ctx := cUtil.InitRequest(req.Context(), apiName, statusOp)
 
-	downstreamHealth := make([]cModel.DownstreamItem, 2)
+	downstreamHealth := make([]cModel.DownstreamItem, 3)
 
 	var ygoServiceVersion string
 	var skcSuggestionDBVersion string
@@ -52,13 +52,19 @@ func getAPIStatusHandler(res http.ResponseWriter, req *http.Request) {
 
 	wg.Wait()
 
+	// reranker health is based on recent calls - pinging the provider would be billed
+	rerankerHealth := downstream.GetRerankerHealth()
+	downstreamHealth[2] = cModel.DownstreamItem{ServiceName: "Reranker", Status: rerankerHealth.Status}
+
 	status := cModel.APIHealth{Version: "3.1.2", Downstream: downstreamHealth}
 
 	logger.Info("API Status",
 		slog.String("ygo_service_status", string(downstreamHealth[0].Status)),
 		slog.String("ygo_service_version", ygoServiceVersion),
 		slog.String("skc_suggestion_db_status", string(downstreamHealth[1].Status)),
-		slog.String("skc_suggestion_db_version", skcSuggestionDBVersion))
+		slog.String("skc_suggestion_db_version", skcSuggestionDBVersion),
+		slog.String("reranker_status", string(rerankerHealth.Status)),
+		slog.Int("reranker_consecutive_failures", rerankerHealth.ConsecutiveFailures))
 	res.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(res).Encode(status); err != nil {
 		logger.Error("Could not encode API status response", slog.Any("err", err))
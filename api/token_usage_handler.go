@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
+	"github.com/ygo-skc/skc-suggestion-engine/usage"
+)
+
+const (
+	tokenUsageOp = "Token Usage"
+)
+
+// Tokens used by embedding/rerank providers, broken down by operation. Defaults to today (UTC) - use the date query param (YYYY-MM-DD) for previous days.
+func getTokenUsageHandler(res http.ResponseWriter, req *http.Request) {
+	date := req.URL.Query().Get("date")
+	if date == "" {
+		date = time.Now().UTC().Format(time.DateOnly)
+	}
+
+	logger, ctx := cUtil.InitRequest(req.Context(), apiName, tokenUsageOp, slog.String("date", date))
+	logger.Info("Getting token usage")
+
+	if _, err := time.Parse(time.DateOnly, date); err != nil {
+		cModel.HandleServerResponse(cModel.APIError{Message: "date should use the format YYYY-MM-DD", StatusCode: http.StatusBadRequest}, res)
+		return
+	}
+
+	report, err := usage.DailyTracker.Day(ctx, date)
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	} else if report == nil {
+		cModel.HandleServerResponse(cModel.APIError{Message: "No token usage recorded for date", StatusCode: http.StatusNotFound}, res)
+		return
+	}
+
+	res.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(res).Encode(report); err != nil {
+		logger.Error("Could not encode token usage response", slog.Any("err", err))
+	}
+}
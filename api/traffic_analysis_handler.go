@@ -1,10 +1,13 @@
 package api
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
 	"sync"
 	"time"
 
@@ -19,6 +22,9 @@ import (
 const (
 	trafficDataSubmissionOp = "Traffic Data Submission"
 	trendingDataOp          = "Trending Data"
+
+	defaultTrendingWindow = "10d"
+	defaultTrendingLimit  = 10
 )
 
 // Endpoint will allow clients to submit traffic data to be saved in a MongoDB instance.
@@ -96,16 +102,25 @@ func trending(res http.ResponseWriter, req *http.Request) {
 	resourceName := model.ResourceName(chi.URLParam(req, "resource"))
 
 	logger, ctx := cUtil.InitRequest(req.Context(), apiName, trendingDataOp, slog.String("resource", string(resourceName)))
-	logger.Info("Getting trending data")
+
+	tq, err := parseTrendingQuery(req.URL.Query())
+	if err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+	if err := validation.ValidateTrendingQuery(tq); err != nil {
+		err.HandleServerResponse(res)
+		return
+	}
+	logger.Info("Getting trending data", slog.String("window", tq.Window), slog.Int("limit", tq.Limit), slog.String("compare", string(tq.Compare)))
 
 	metricsForCurrentPeriod, metricsForLastPeriod := []model.TrafficResourceUtilizationMetric{}, []model.TrafficResourceUtilizationMetric{}
-	today := time.Now()
-	dateCutoff1, dateCutoff2 := today.AddDate(0, 0, -10), today.AddDate(0, 0, -20)
+	currentFrom, currentTo, lastFrom, lastTo := trendingPeriods(tq, time.Now())
 
 	var wg sync.WaitGroup
 	awg1, awg2 := cUtil.NewAtomicWaitGroup[cModel.APIError](&wg), cUtil.NewAtomicWaitGroup[cModel.APIError](&wg)
-	go getMetrics(ctx, resourceName, dateCutoff1, today, &metricsForCurrentPeriod, awg1)
-	go getMetrics(ctx, resourceName, dateCutoff2, dateCutoff1, &metricsForLastPeriod, awg2)
+	go getMetrics(ctx, resourceName, currentFrom, currentTo, tq.Limit, &metricsForCurrentPeriod, awg1)
+	go getMetrics(ctx, resourceName, lastFrom, lastTo, tq.Limit, &metricsForLastPeriod, awg2)
 
 	// verify go routines exited with no errors
 	if err := awg1.Load(); err != nil {
@@ -122,7 +137,7 @@ func trending(res http.ResponseWriter, req *http.Request) {
 		return
 	} else {
 		tm := determineTrendChange(metricsForCurrentPeriod, metricsForLastPeriod)
-		trending := model.Trending{ResourceName: resourceName, Metrics: tm}
+		trending := model.Trending{ResourceName: resourceName, Window: tq.Window, Compare: tq.Compare, Metrics: tm}
 
 		if err := awg.Load(); err != nil {
 			err.HandleServerResponse(res)
@@ -137,6 +152,35 @@ func trending(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// Query params: window (one of model.TrendingWindows, default 10d), limit (default 10) and compare (previous or lastWeek, default previous)
+func parseTrendingQuery(query url.Values) (model.TrendingQuery, *cModel.APIError) {
+	tq := model.TrendingQuery{
+		Window:  cmp.Or(query.Get("window"), defaultTrendingWindow),
+		Limit:   defaultTrendingLimit,
+		Compare: cmp.Or(model.TrendingComparison(query.Get("compare")), model.PreviousWindowComparison),
+	}
+
+	if value := query.Get("limit"); value != "" {
+		var err error
+		if tq.Limit, err = strconv.Atoi(value); err != nil {
+			return tq, &cModel.APIError{Message: "limit should be a number", StatusCode: http.StatusBadRequest}
+		}
+	}
+	return tq, nil
+}
+
+// Start and end of the current window ending now and of the window it's compared against. Query is expected to be valid.
+func trendingPeriods(tq model.TrendingQuery, now time.Time) (time.Time, time.Time, time.Time, time.Time) {
+	window := model.TrendingWindows[tq.Window]
+	currentFrom := now.Add(-window)
+
+	if tq.Compare == model.LastWeekComparison {
+		lastTo := now.AddDate(0, 0, -7)
+		return currentFrom, now, lastTo.Add(-window), lastTo
+	}
+	return currentFrom, now, currentFrom.Add(-window), currentFrom
+}
+
 func fetchResourceInfoAsync(ctx context.Context, r model.ResourceName,
 	metricsForCurrentPeriod []model.TrafficResourceUtilizationMetric, wg *sync.WaitGroup) (*cUtil.AtomicWaitGroup[cModel.APIError], func([]model.TrendingMetric)) {
 	awg := cUtil.NewAtomicWaitGroup[cModel.APIError](wg)
@@ -221,9 +265,9 @@ func determineTrendChange(metricsForCurrentPeriod []model.TrafficResourceUtiliza
 	return tm
 }
 
-func getMetrics(ctx context.Context, r model.ResourceName, from time.Time, to time.Time,
+func getMetrics(ctx context.Context, r model.ResourceName, from time.Time, to time.Time, limit int,
 	td *[]model.TrafficResourceUtilizationMetric, awg *cUtil.AtomicWaitGroup[cModel.APIError]) {
 	var err *cModel.APIError
-	*td, err = skcSuggestionEngineDBInterface.GetTrafficData(ctx, r, from, to)
+	*td, err = skcSuggestionEngineDBInterface.GetTrafficData(ctx, r, from, to, limit)
 	awg.Store(err)
 }
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+	"github.com/ygo-skc/skc-suggestion-engine/validation"
+)
+
+func TestParseTrendingQuery(t *testing.T) {
+	assert := assert.New(t)
+
+	tq, err := parseTrendingQuery(url.Values{})
+	assert.Nil(err)
+	assert.Equal(model.TrendingQuery{Window: defaultTrendingWindow, Limit: defaultTrendingLimit, Compare: model.PreviousWindowComparison}, tq)
+	assert.Nil(validation.ValidateTrendingQuery(tq), "Defaults should be valid")
+
+	tq, err = parseTrendingQuery(url.Values{"window": {"24h"}, "limit": {"5"}, "compare": {"lastWeek"}})
+	assert.Nil(err)
+	assert.Equal(model.TrendingQuery{Window: "24h", Limit: 5, Compare: model.LastWeekComparison}, tq)
+	assert.Nil(validation.ValidateTrendingQuery(tq))
+
+	_, err = parseTrendingQuery(url.Values{"limit": {"ten"}})
+	assert.Equal(http.StatusBadRequest, err.StatusCode)
+
+	for name, query := range map[string]url.Values{
+		"unknown window":               {"window": {"1y"}},
+		"limit too small":              {"limit": {"0"}},
+		"limit too large":              {"limit": {"51"}},
+		"unknown compare":              {"compare": {"lastYear"}},
+		"lastWeek overlaps 10d window": {"window": {"10d"}, "compare": {"lastWeek"}},
+		"lastWeek overlaps 30d window": {"window": {"30d"}, "compare": {"lastWeek"}},
+	} {
+		tq, _ := parseTrendingQuery(query)
+		assert.NotNil(validation.ValidateTrendingQuery(tq), name)
+	}
+
+	tq, _ = parseTrendingQuery(url.Values{"window": {"7d"}, "compare": {"lastWeek"}})
+	assert.Nil(validation.ValidateTrendingQuery(tq), "Windows of 7d or less don't overlap last week")
+
+	tq, _ = parseTrendingQuery(url.Values{"window": {"1y"}})
+	assert.Equal("Window should be one of: 24h, 7d, 10d, 30d.", validation.ValidateTrendingQuery(tq).Errors[0].Hint)
+}
+
+func TestTrendingPeriods(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	currentFrom, currentTo, lastFrom, lastTo := trendingPeriods(model.TrendingQuery{Window: "7d", Compare: model.PreviousWindowComparison}, now)
+	assert.Equal([]time.Time{now.AddDate(0, 0, -7), now, now.AddDate(0, 0, -14), now.AddDate(0, 0, -7)}, []time.Time{currentFrom, currentTo, lastFrom, lastTo})
+
+	currentFrom, currentTo, lastFrom, lastTo = trendingPeriods(model.TrendingQuery{Window: "24h", Compare: model.LastWeekComparison}, now)
+	assert.Equal([]time.Time{now.AddDate(0, 0, -1), now, now.AddDate(0, 0, -8), now.AddDate(0, 0, -7)}, []time.Time{currentFrom, currentTo, lastFrom, lastTo},
+		"Same window last week should be compared")
+}
@@ -0,0 +1,113 @@
+package covisit
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+)
+
+const (
+	maxSessionResources = 100 // sessions viewing more distinct resources are most likely crawlers and are ignored
+	maxCoViewedCards    = 50  // co-viewed cards kept per resource
+)
+
+// views of a single visitor (IP + source system) without a gap longer than the session gap between two consecutive views
+type session struct {
+	lastViewed time.Time
+	resources  map[model.TrafficResource]struct{}
+}
+
+// Groups views into sessions then counts, for every card and product, the number of sessions each other card was viewed in.
+// Views are expected to be sorted by timestamp - views without an IP can't be attributed to a visitor and are skipped.
+func Build(views []model.TrafficAnalysis, sessionGap time.Duration, builtAt time.Time) []model.AlsoViewedEntry {
+	c := NewCounter(sessionGap)
+	for _, view := range views {
+		c.Add(view)
+	}
+	return c.Entries(builtAt)
+}
+
+// Same as Build with views added one at a time so they don't need to be held in memory - only open sessions and counts are kept.
+// Views must be added in timestamp order.
+type Counter struct {
+	sessionGap time.Duration
+	open       map[string]*session // visitor -> current session
+	coViews    map[model.TrafficResource]map[string]int
+	views      int
+}
+
+func NewCounter(sessionGap time.Duration) *Counter {
+	return &Counter{sessionGap: sessionGap, open: make(map[string]*session), coViews: make(map[model.TrafficResource]map[string]int)}
+}
+
+func (c *Counter) Add(view model.TrafficAnalysis) {
+	c.views++
+	if view.UserData.IP == "" || (view.ResourceUtilized.Name != model.CardResource && view.ResourceUtilized.Name != model.ProductResource) {
+		return
+	}
+
+	visitor := view.UserData.IP + "|" + view.Source.SystemName
+	s, isPresent := c.open[visitor]
+	if !isPresent || view.Timestamp.Sub(s.lastViewed) > c.sessionGap {
+		if isPresent {
+			c.count(s)
+		}
+		s = &session{resources: make(map[model.TrafficResource]struct{})}
+		c.open[visitor] = s
+	}
+	s.lastViewed = view.Timestamp
+	s.resources[model.TrafficResource{Name: view.ResourceUtilized.Name, Value: view.ResourceUtilized.Value}] = struct{}{}
+}
+
+// views added so far, including skipped views
+func (c *Counter) Views() int {
+	return c.views
+}
+
+func (c *Counter) count(s *session) {
+	if len(s.resources) < 2 || len(s.resources) > maxSessionResources {
+		return
+	}
+	for resource := range s.resources {
+		for other := range s.resources {
+			if other.Name != model.CardResource || other == resource {
+				continue
+			}
+			if c.coViews[resource] == nil {
+				c.coViews[resource] = make(map[string]int)
+			}
+			c.coViews[resource][other.Value]++
+		}
+	}
+}
+
+// Closes every open session then returns the co-viewed cards of every resource - the counter shouldn't be used afterwards
+func (c *Counter) Entries(builtAt time.Time) []model.AlsoViewedEntry {
+	for _, s := range c.open {
+		c.count(s)
+	}
+	clear(c.open)
+
+	coViews := c.coViews
+	entries := make([]model.AlsoViewedEntry, 0, len(coViews))
+	for resource, sessions := range coViews {
+		cards := make([]model.CoViewedCard, 0, len(sessions))
+		for cardID, total := range sessions {
+			cards = append(cards, model.CoViewedCard{CardID: cardID, Sessions: total})
+		}
+		slices.SortFunc(cards, func(a, b model.CoViewedCard) int {
+			return cmp.Or(cmp.Compare(b.Sessions, a.Sessions), cmp.Compare(a.CardID, b.CardID))
+		})
+		if len(cards) > maxCoViewedCards {
+			cards = cards[:maxCoViewedCards]
+		}
+
+		entries = append(entries, model.AlsoViewedEntry{ResourceName: resource.Name, ResourceID: resource.Value, Cards: cards, BuiltAt: builtAt})
+	}
+	slices.SortFunc(entries, func(a, b model.AlsoViewedEntry) int {
+		return cmp.Or(cmp.Compare(a.ResourceName, b.ResourceName), cmp.Compare(a.ResourceID, b.ResourceID))
+	})
+	return entries
+}
@@ -0,0 +1,72 @@
+package covisit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ygo-skc/skc-suggestion-engine/db"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+)
+
+func view(ip string, system string, at time.Time, name model.ResourceName, value string) model.TrafficAnalysis {
+	return model.TrafficAnalysis{Timestamp: at, Source: model.TrafficSource{SystemName: system}, ResourceUtilized: model.TrafficResource{Name: name, Value: value},
+		UserData: model.UserData{IP: ip}}
+}
+
+func TestBuild(t *testing.T) {
+	assert := assert.New(t)
+	start, builtAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	views := []model.TrafficAnalysis{
+		view("1.1.1.1", "SKC", start, model.CardResource, "46986414"),
+		view("1.1.1.1", "SKC", start.Add(time.Minute), model.ProductResource, "LOB"),
+		view("2.2.2.2", "SKC", start.Add(time.Minute), model.CardResource, "46986414"),
+		view("1.1.1.1", "SKC", start.Add(2*time.Minute), model.CardResource, "38033121"),
+		view("1.1.1.1", "SKC", start.Add(3*time.Minute), model.CardResource, "46986414"), // repeat views count once per session
+		view("2.2.2.2", "SKC", start.Add(5*time.Minute), model.CardResource, "38033121"),
+		view("2.2.2.2", "SKC", start.Add(6*time.Minute), model.CardResource, "89631139"),
+		view("1.1.1.1", "SKC", start.Add(2*time.Hour), model.CardResource, "89631139"),      // new session - gap is too long
+		view("1.1.1.1", "Other", start.Add(2*time.Hour), model.CardResource, "50237654"),    // different source = different visitor
+		view("", "SKC", start.Add(2*time.Hour), model.CardResource, "46986414"),             // no IP
+		view("", "SKC", start.Add(2*time.Hour+time.Minute), model.CardResource, "50237654"), // no IP
+	}
+
+	entries := Build(views, 30*time.Minute, builtAt)
+	assert.Equal([]model.AlsoViewedEntry{
+		{ResourceName: model.CardResource, ResourceID: "38033121", Cards: []model.CoViewedCard{{CardID: "46986414", Sessions: 2}, {CardID: "89631139", Sessions: 1}}, BuiltAt: builtAt},
+		{ResourceName: model.CardResource, ResourceID: "46986414", Cards: []model.CoViewedCard{{CardID: "38033121", Sessions: 2}, {CardID: "89631139", Sessions: 1}}, BuiltAt: builtAt},
+		{ResourceName: model.CardResource, ResourceID: "89631139", Cards: []model.CoViewedCard{{CardID: "38033121", Sessions: 1}, {CardID: "46986414", Sessions: 1}}, BuiltAt: builtAt},
+		{ResourceName: model.ProductResource, ResourceID: "LOB", Cards: []model.CoViewedCard{{CardID: "38033121", Sessions: 1}, {CardID: "46986414", Sessions: 1}}, BuiltAt: builtAt},
+	}, entries)
+
+	crawler := make([]model.TrafficAnalysis, 0, maxSessionResources+1)
+	for i := range maxSessionResources + 1 {
+		crawler = append(crawler, view("3.3.3.3", "SKC", start.Add(time.Duration(i)*time.Second), model.CardResource, fmt.Sprintf("%08d", i)))
+	}
+	assert.Empty(Build(crawler, 30*time.Minute, builtAt), "Sessions viewing too many resources should be ignored")
+}
+
+func TestRefresh(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	dao, _ := db.NewSKCSuggestionEngineDAOInMemory("")
+	for _, v := range []model.TrafficAnalysis{
+		view("1.1.1.1", "SKC", now.Add(-2*time.Minute), model.CardResource, "38033121"), // inserted out of order - views are streamed oldest first
+		view("1.1.1.1", "SKC", now.Add(-3*time.Minute), model.CardResource, "46986414"),
+		view("1.1.1.1", "SKC", now.Add(-48*time.Hour), model.CardResource, "89631139"), // outside lookback
+	} {
+		dao.InsertTrafficData(ctx, v)
+	}
+
+	assert.Nil(NewBuilder(dao, 24*time.Hour, 30*time.Minute, time.Hour).Refresh(ctx))
+	entry, _ := dao.GetAlsoViewed(ctx, model.CardResource, "46986414")
+	assert.NotNil(entry)
+	assert.Equal([]model.CoViewedCard{{CardID: "38033121", Sessions: 1}}, entry.Cards)
+	entry, _ = dao.GetAlsoViewed(ctx, model.CardResource, "89631139")
+	assert.Nil(entry, "Views outside the lookback should be ignored")
+}
@@ -0,0 +1,90 @@
+package covisit
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"time"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
+	"github.com/ygo-skc/skc-suggestion-engine/db"
+)
+
+const (
+	defaultLookback        = 30 * 24 * time.Hour
+	defaultSessionGap      = 30 * time.Minute
+	defaultRefreshInterval = 6 * time.Hour
+)
+
+// Rebuilds the alsoViewed collection from trafficAnalysis on a schedule so requests only read precomputed co-views.
+type Builder struct {
+	dao             db.SKCSuggestionEngineDAO
+	lookback        time.Duration // views older than this are ignored
+	sessionGap      time.Duration // longest time between two views of the same session
+	refreshInterval time.Duration
+}
+
+func NewBuilder(dao db.SKCSuggestionEngineDAO, lookback time.Duration, sessionGap time.Duration, refreshInterval time.Duration) *Builder {
+	return &Builder{dao: dao, lookback: lookback, sessionGap: sessionGap, refreshInterval: refreshInterval}
+}
+
+// Uses ALSO_VIEWED_LOOKBACK (defaults to 720h), ALSO_VIEWED_SESSION_GAP (defaults to 30m) and ALSO_VIEWED_REFRESH_INTERVAL (defaults to 6h) env variables,
+// all Go durations, to configure the builder.
+func NewBuilderFromEnv(dao db.SKCSuggestionEngineDAO) *Builder {
+	b := NewBuilder(dao, defaultLookback, defaultSessionGap, defaultRefreshInterval)
+	for env, value := range map[string]*time.Duration{
+		"ALSO_VIEWED_LOOKBACK":         &b.lookback,
+		"ALSO_VIEWED_SESSION_GAP":      &b.sessionGap,
+		"ALSO_VIEWED_REFRESH_INTERVAL": &b.refreshInterval,
+	} {
+		if s := cUtil.EnvMap[env]; s != "" {
+			var err error
+			if *value, err = time.ParseDuration(s); err != nil || *value <= 0 {
+				log.Fatalf("%s is not a positive duration: %s", env, s)
+			}
+		}
+	}
+
+	slog.Info("Configured also viewed builder", slog.Duration("lookback", b.lookback), slog.Duration("session_gap", b.sessionGap),
+		slog.Duration("refresh_interval", b.refreshInterval))
+	return b
+}
+
+// Builds co-views then rebuilds them periodically until ctx is cancelled
+func (b *Builder) Run(ctx context.Context) {
+	b.refreshAndLog(ctx)
+
+	ticker := time.NewTicker(b.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.refreshAndLog(ctx)
+		}
+	}
+}
+
+func (b *Builder) refreshAndLog(ctx context.Context) {
+	if err := b.Refresh(ctx); err != nil {
+		cUtil.RetrieveLogger(ctx).Error("Could not rebuild also viewed cards - previous build is kept", slog.Any("err", err))
+	}
+}
+
+// Rebuilds co-views using views within the lookback. The previous build is kept when views can't be retrieved or when there are no co-views.
+func (b *Builder) Refresh(ctx context.Context) *cModel.APIError {
+	builtAt := time.Now().UTC().Truncate(time.Millisecond) // mongo stores ms precision - builtAt is compared when removing stale entries
+	counter := NewCounter(b.sessionGap)
+	if err := b.dao.StreamTrafficAnalysis(ctx, builtAt.Add(-b.lookback), counter.Add); err != nil {
+		return err
+	}
+
+	entries := counter.Entries(builtAt)
+	if err := b.dao.ReplaceAlsoViewed(ctx, entries); err != nil {
+		return err
+	}
+	cUtil.RetrieveLogger(ctx).Info("Rebuilt also viewed cards", slog.Int("views", counter.Views()), slog.Int("resources", len(entries)))
+	return nil
+}
@@ -20,6 +20,12 @@ const (
 	minPoolSize = 15
 	maxPoolSize = 30
 
+	MongoBackend    = "mongo"
+	InMemoryBackend = "memory"
+
+	embeddingCacheTTL         = 90 * 24 * time.Hour
+	cardEmbeddingIndexTimeout = 5 * time.Minute
+
 	certificateKeyFilePath = "./certs/skc-suggestion-engine-db.pem"
 	connectTimeout         = 2 * time.Second
 	serverSelectionTimeout = 5 * time.Second
@@ -31,6 +37,10 @@ var (
 	trafficAnalysisCollection *mongo.Collection
 	cardOfTheDayCollection    *mongo.Collection
 	archetypeCollection       *mongo.Collection
+	embeddingCacheCollection  *mongo.Collection
+	tokenUsageCollection      *mongo.Collection
+	referenceIndexCollection  *mongo.Collection
+	alsoViewedCollection      *mongo.Collection
 
 	vectorSearchDB          *mongo.Database
 	cardEmbeddingCollection *mongo.Collection
@@ -50,6 +60,28 @@ var (
 			SetAppName("SKC Suggestion Engine")
 )
 
+// Sets up the DB backend chosen using the DB_BACKEND env variable (mongo when not set) and returns the DAO that should be used to access it.
+// The in-memory backend can be pre-populated using a JSON file whose path is found in the DB_SEED_FILE env variable.
+func EstablishSKCSuggestionEngineDAO() SKCSuggestionEngineDAO {
+	switch backend := cUtil.EnvMap["DB_BACKEND"]; backend {
+	case "", MongoBackend:
+		EstablishSKCSuggestionEngineDBConn()
+		return SKCSuggestionEngineDAOImplementation{}
+	case InMemoryBackend:
+		impl, err := NewSKCSuggestionEngineDAOInMemory(cUtil.EnvMap["DB_SEED_FILE"])
+		if err != nil {
+			slog.Error("Error creating in-memory suggestion engine DB", slog.Any("err", err))
+			os.Exit(1)
+		}
+		slog.Warn("Using in-memory suggestion engine DB - data will not be persisted")
+		return impl
+	default:
+		slog.Error("Unknown DB backend", slog.String("backend", backend))
+		os.Exit(1)
+		return nil
+	}
+}
+
 func EstablishSKCSuggestionEngineDBConn() {
 	uri := fmt.Sprintf("%s/?tlsCertificateKeyFile=%s", cUtil.EnvMap["DB_HOST"], certificateKeyFilePath)
 	credential := options.Credential{
@@ -63,6 +95,10 @@ func EstablishSKCSuggestionEngineDBConn() {
 	trafficAnalysisCollection = skcSuggestionDB.Collection("trafficAnalysis")
 	cardOfTheDayCollection = skcSuggestionDB.Collection("cardOfTheDay")
 	archetypeCollection = skcSuggestionDB.Collection("archetype")
+	embeddingCacheCollection = skcSuggestionDB.Collection("embeddingCache")
+	tokenUsageCollection = skcSuggestionDB.Collection("tokenUsage")
+	referenceIndexCollection = skcSuggestionDB.Collection("referenceIndex")
+	alsoViewedCollection = skcSuggestionDB.Collection("alsoViewed")
 
 	// vector search connection - $vectorSearch aggregation stage requires ReadConcern local
 	vectorSearchClient := connect(uri, credential, readconcern.Local())
@@ -73,6 +109,7 @@ func EstablishSKCSuggestionEngineDBConn() {
 		slog.Error("Error creating indexes for skc-deck-api-db", slog.Any("err", err))
 		os.Exit(1)
 	}
+	go ensureCardEmbeddingIDIndex()
 
 	slog.Info("Connected to suggestion engine DB")
 }
@@ -97,6 +134,27 @@ func connect(uri string, credential options.Credential, rc *readconcern.ReadConc
 	return client
 }
 
+// card_embedding_id is unique - building it can take a while on large collections and fails while duplicates exist,
+// so it is built in the background and startup doesn't depend on it.
+func ensureCardEmbeddingIDIndex() {
+	ctx, cancel := context.WithTimeout(context.Background(), cardEmbeddingIndexTimeout)
+	defer cancel()
+
+	if err := createCardEmbeddingIDIndex(ctx); err != nil {
+		slog.Error("Could not create unique card embedding ID index - duplicates can be removed using POST /card-embeddings/dedupe", slog.Any("err", err))
+		return
+	}
+	slog.Info("Card embedding ID index is ready")
+}
+
+func createCardEmbeddingIDIndex(ctx context.Context) error {
+	_, err := cardEmbeddingCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "id", Value: 1}},
+		Options: options.Index().SetName("card_embedding_id").SetUnique(true),
+	})
+	return err
+}
+
 func createIndexes() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
@@ -122,6 +180,34 @@ func createIndexes() error {
 				Options: options.Index().SetName("archetype_qualified_members"),
 			},
 		},
+		embeddingCacheCollection: {
+			{
+				Keys:    bson.D{{Key: "key", Value: 1}},
+				Options: options.Index().SetName("embedding_cache_key").SetUnique(true),
+			},
+			{
+				Keys:    bson.D{{Key: "createdAt", Value: 1}},
+				Options: options.Index().SetName("embedding_cache_ttl").SetExpireAfterSeconds(int32(embeddingCacheTTL.Seconds())),
+			},
+		},
+		tokenUsageCollection: {
+			{
+				Keys:    bson.D{{Key: "date", Value: 1}},
+				Options: options.Index().SetName("token_usage_date").SetUnique(true),
+			},
+		},
+		referenceIndexCollection: {
+			{
+				Keys:    bson.D{{Key: "cardID", Value: 1}},
+				Options: options.Index().SetName("reference_index_card_id").SetUnique(true),
+			},
+		},
+		alsoViewedCollection: {
+			{
+				Keys:    bson.D{{Key: "resourceName", Value: 1}, {Key: "resourceID", Value: 1}},
+				Options: options.Index().SetName("also_viewed_resource").SetUnique(true),
+			},
+		},
 	}
 
 	for collection, indexes := range indexesByCollection {
@@ -0,0 +1,538 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+)
+
+const (
+	inMemoryDBVersion = "in-memory"
+)
+
+type inMemoryBlackListEntry struct {
+	Type   string `json:"type"`
+	Phrase string `json:"phrase"`
+}
+
+// model.CardOfTheDay hides the card ID from JSON, seed data uses its own shape
+type inMemoryCardOfTheDay struct {
+	Date    string `json:"date"`
+	Version int    `json:"version"`
+	CardID  string `json:"cardID"`
+}
+
+type inMemoryArchetype struct {
+	Archetype        string   `json:"archetype"`
+	InheritMembers   []string `json:"inheritMembers"`
+	QualifiedMembers []string `json:"qualifiedMembers"`
+	ExcludedMembers  []string `json:"excludedMembers"`
+}
+
+// contents of the optional seed file used to pre-populate the in-memory DB - mirrors the Mongo collections
+type inMemorySeed struct {
+	BlackList       []inMemoryBlackListEntry `json:"blackList"`
+	Archetypes      []inMemoryArchetype      `json:"archetypes"`
+	CardOfTheDay    []inMemoryCardOfTheDay   `json:"cardOfTheDay"`
+	TrafficAnalysis []model.TrafficAnalysis  `json:"trafficAnalysis"`
+	CardEmbeddings  []model.CardEmbedding    `json:"cardEmbeddings"`
+}
+
+// impl that keeps all suggestion engine data in process memory - used to run the API locally w/o MongoDB
+type SKCSuggestionEngineDAOInMemory struct {
+	mu sync.RWMutex
+
+	blackList       map[inMemoryBlackListEntry]struct{}
+	archetypes      map[string]inMemoryArchetype
+	cardOfTheDay    []model.CardOfTheDay
+	trafficAnalysis []model.TrafficAnalysis
+	cardEmbeddings  []model.CardEmbedding
+	embeddingCache  map[string]model.CachedEmbedding
+	tokenUsage      map[string]model.DailyTokenUsage
+	referenceIndex  []model.ReferenceIndexEntry
+	alsoViewed      []model.AlsoViewedEntry
+}
+
+// Creates an empty in-memory DB. If seedFile is not empty, the DB is pre-populated using the JSON contents of the file.
+func NewSKCSuggestionEngineDAOInMemory(seedFile string) (*SKCSuggestionEngineDAOInMemory, error) {
+	impl := &SKCSuggestionEngineDAOInMemory{
+		blackList:       make(map[inMemoryBlackListEntry]struct{}),
+		archetypes:      make(map[string]inMemoryArchetype),
+		cardOfTheDay:    make([]model.CardOfTheDay, 0),
+		trafficAnalysis: make([]model.TrafficAnalysis, 0),
+		cardEmbeddings:  make([]model.CardEmbedding, 0),
+		embeddingCache:  make(map[string]model.CachedEmbedding),
+		tokenUsage:      make(map[string]model.DailyTokenUsage),
+		referenceIndex:  make([]model.ReferenceIndexEntry, 0),
+		alsoViewed:      make([]model.AlsoViewedEntry, 0),
+	}
+
+	if seedFile == "" {
+		return impl, nil
+	}
+
+	contents, err := os.ReadFile(seedFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading in-memory DB seed file %s: %w", seedFile, err)
+	}
+
+	var seed inMemorySeed
+	if err := json.Unmarshal(contents, &seed); err != nil {
+		return nil, fmt.Errorf("error parsing in-memory DB seed file %s: %w", seedFile, err)
+	}
+
+	for _, entry := range seed.BlackList {
+		impl.blackList[inMemoryBlackListEntry{Type: entry.Type, Phrase: sanitizeQueryInput(entry.Phrase)}] = struct{}{}
+	}
+	for _, archetype := range seed.Archetypes {
+		impl.archetypes[archetype.Archetype] = archetype
+	}
+	for _, cotd := range seed.CardOfTheDay {
+		impl.cardOfTheDay = append(impl.cardOfTheDay, model.CardOfTheDay{Date: cotd.Date, Version: cotd.Version, CardID: cotd.CardID})
+	}
+	impl.trafficAnalysis = append(impl.trafficAnalysis, seed.TrafficAnalysis...)
+	impl.cardEmbeddings = append(impl.cardEmbeddings, seed.CardEmbeddings...)
+
+	slog.Info("Seeded in-memory suggestion engine DB", slog.String("seed_file", seedFile),
+		slog.Int("black_list", len(impl.blackList)), slog.Int("archetypes", len(impl.archetypes)),
+		slog.Int("card_of_the_day", len(impl.cardOfTheDay)), slog.Int("traffic_analysis", len(impl.trafficAnalysis)),
+		slog.Int("card_embeddings", len(impl.cardEmbeddings)))
+	return impl, nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) GetSKCSuggestionDBVersion(ctx context.Context) (string, error) {
+	return inMemoryDBVersion, nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) InsertTrafficData(ctx context.Context, ta model.TrafficAnalysis) *cModel.APIError {
+	cUtil.RetrieveLogger(ctx).Info("Inserting traffic data", slog.Any("resource", ta.ResourceUtilized), slog.Any("system", ta.Source))
+
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+	impl.trafficAnalysis = append(impl.trafficAnalysis, ta)
+	return nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) GetTrafficData(
+	ctx context.Context, resourceName model.ResourceName, from time.Time, to time.Time, limit int) ([]model.TrafficResourceUtilizationMetric, *cModel.APIError) {
+	impl.mu.RLock()
+	occurrencesByResource := make(map[string]int)
+	for _, ta := range impl.trafficAnalysis {
+		if ta.ResourceUtilized.Name == resourceName && !ta.Timestamp.Before(from) && !ta.Timestamp.After(to) {
+			occurrencesByResource[ta.ResourceUtilized.Value]++
+		}
+	}
+	impl.mu.RUnlock()
+
+	td := make([]model.TrafficResourceUtilizationMetric, 0, len(occurrencesByResource))
+	for resourceValue, occurrences := range occurrencesByResource {
+		td = append(td, model.TrafficResourceUtilizationMetric{ResourceValue: resourceValue, Occurrences: occurrences})
+	}
+
+	// same ordering as the Mongo pipeline - most occurrences first, ties broken by resource value descending
+	slices.SortFunc(td, func(a, b model.TrafficResourceUtilizationMetric) int {
+		if a.Occurrences != b.Occurrences {
+			return cmp.Compare(b.Occurrences, a.Occurrences)
+		}
+		return cmp.Compare(b.ResourceValue, a.ResourceValue)
+	})
+
+	return td[:min(len(td), limit)], nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) GetCardViews(ctx context.Context, from time.Time) (map[string]int, *cModel.APIError) {
+	impl.mu.RLock()
+	defer impl.mu.RUnlock()
+
+	viewsByID := make(map[string]int)
+	for _, ta := range impl.trafficAnalysis {
+		if ta.ResourceUtilized.Name == model.CardResource && !ta.Timestamp.Before(from) {
+			viewsByID[ta.ResourceUtilized.Value]++
+		}
+	}
+	return viewsByID, nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) StreamTrafficAnalysis(ctx context.Context, from time.Time, fn func(model.TrafficAnalysis)) *cModel.APIError {
+	impl.mu.RLock()
+	td := make([]model.TrafficAnalysis, 0)
+	for _, ta := range impl.trafficAnalysis {
+		isView := ta.ResourceUtilized.Name == model.CardResource || ta.ResourceUtilized.Name == model.ProductResource
+		if isView && ta.UserData.IP != "" && !ta.Timestamp.Before(from) {
+			td = append(td, ta)
+		}
+	}
+	impl.mu.RUnlock()
+
+	slices.SortStableFunc(td, func(a, b model.TrafficAnalysis) int { return a.Timestamp.Compare(b.Timestamp) })
+	for _, ta := range td {
+		fn(ta)
+	}
+	return nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) IsBlackListed(ctx context.Context, blackListType string, blackListPhrase string) (bool, *cModel.APIError) {
+	logger := cUtil.RetrieveLogger(ctx)
+
+	blackListPhrase = sanitizeQueryInput(blackListPhrase)
+	if blackListPhrase == "" || len(blackListPhrase) > maxBlackListPhraseLength {
+		logger.Error("Rejecting black list check for invalid phrase", slog.String("type", blackListType), slog.Int("phrase_length", len(blackListPhrase)))
+		return false, &cModel.APIError{Message: "Internal server error", StatusCode: http.StatusInternalServerError}
+	}
+
+	impl.mu.RLock()
+	defer impl.mu.RUnlock()
+	if _, isBlackListed := impl.blackList[inMemoryBlackListEntry{Type: blackListType, Phrase: blackListPhrase}]; isBlackListed {
+		logger.Info("Phrase is blacklisted", slog.String("phrase", blackListPhrase))
+		return true, nil
+	}
+	return false, nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) GetCardOfTheDay(ctx context.Context, date string, version int) (*string, *cModel.APIError) {
+	impl.mu.RLock()
+	defer impl.mu.RUnlock()
+
+	for _, cotd := range impl.cardOfTheDay {
+		if cotd.Date == date && cotd.Version == version {
+			return &cotd.CardID, nil
+		}
+	}
+	return nil, nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) GetHistoricalCardOfTheDayData(ctx context.Context, version int) ([]string, *cModel.APIError) {
+	impl.mu.RLock()
+	defer impl.mu.RUnlock()
+
+	historicalCOTD := make([]string, 0, len(impl.cardOfTheDay))
+	for _, cotd := range impl.cardOfTheDay {
+		if cotd.Version == version {
+			historicalCOTD = append(historicalCOTD, cotd.CardID)
+		}
+	}
+	return historicalCOTD, nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) InsertCardOfTheDay(ctx context.Context, cotd model.CardOfTheDay) *cModel.APIError {
+	cUtil.RetrieveLogger(ctx).Info("Inserting new COTD", slog.String("id", cotd.CardID), slog.Int("version", cotd.Version))
+
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+	impl.cardOfTheDay = append(impl.cardOfTheDay, cotd)
+	return nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) GetArchetypeMembers(ctx context.Context, archetype string) ([]string, []string, []string, *cModel.APIError) {
+	logger := cUtil.RetrieveLogger(ctx)
+	logger.Info("Fetching archetype members")
+
+	impl.mu.RLock()
+	defer impl.mu.RUnlock()
+
+	members, isPresent := impl.archetypes[archetype]
+	if !isPresent {
+		logger.Error("Could not find archetype in DB")
+		return nil, nil, nil, &cModel.APIError{StatusCode: http.StatusNotFound, Message: "Archetype does not exist"}
+	}
+	return members.InheritMembers, members.QualifiedMembers, members.ExcludedMembers, nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) GetRelevantArchetypes(ctx context.Context, subjects cModel.CardIDs) ([]string, *cModel.APIError) {
+	impl.mu.RLock()
+	defer impl.mu.RUnlock()
+
+	relevantArchetypes := make([]string, 0)
+	for name, archetype := range impl.archetypes {
+		for _, subject := range subjects {
+			if slices.Contains(archetype.InheritMembers, subject) || slices.Contains(archetype.QualifiedMembers, subject) {
+				relevantArchetypes = append(relevantArchetypes, name)
+				break
+			}
+		}
+	}
+	slices.Sort(relevantArchetypes) // map order is random
+	return relevantArchetypes, nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) GetArchetypesByName(ctx context.Context, names []string) ([]string, *cModel.APIError) {
+	impl.mu.RLock()
+	defer impl.mu.RUnlock()
+
+	existing := make([]string, 0)
+	for _, name := range names {
+		if _, isPresent := impl.archetypes[name]; isPresent && !slices.Contains(existing, name) {
+			existing = append(existing, name)
+		}
+	}
+	return existing, nil
+}
+
+// Brute force (exact) nearest neighbor search using cosine similarity. Boosts are applied the same way the Mongo pipeline applies them.
+func (impl *SKCSuggestionEngineDAOInMemory) VectorSearchOnCardEmbedding(ctx context.Context,
+	subject cModel.YGOCard, queryVector []float32, filter model.VectorSearchFilter) ([]model.VectorSearchResult, *cModel.APIError) {
+	cUtil.RetrieveLogger(ctx).Info("Performing vector search on card text")
+
+	impl.mu.RLock()
+	results := make([]model.VectorSearchResult, 0, len(impl.cardEmbeddings))
+	for _, embedding := range impl.cardEmbeddings {
+		if embedding.ID == subject.GetID() || !matchesVectorSearchFilter(embedding, filter) {
+			continue
+		}
+
+		// Atlas normalizes cosine scores to [0, 1] - do the same so boosts carry the same weight
+		r := model.VectorSearchResult{ID: embedding.ID, Text: embedding.Text, CosineSimilarity: (1 + cosineSimilarity(queryVector, embedding.TextEmbedding)) / 2}
+		if embedding.Type == subject.GetMonsterType() {
+			r.SharedType = 1
+		}
+		if embedding.Attribute == subject.GetAttribute() {
+			r.SharedAttribute = 1
+		}
+		if embedding.MonsterType == subject.GetMonsterType() {
+			r.SharedMonsterType = 1
+		}
+		r.FinalScore = r.CosineSimilarity + float64(r.SharedType)*SharedTypeBoost +
+			float64(r.SharedAttribute)*SharedAttributeBoost + float64(r.SharedMonsterType)*SharedMonsterTypeBoost
+		results = append(results, r)
+	}
+	impl.mu.RUnlock()
+
+	slices.SortStableFunc(results, func(a, b model.VectorSearchResult) int {
+		return cmp.Compare(b.FinalScore, a.FinalScore)
+	})
+	return results[:min(len(results), cmp.Or(filter.Limit, vectorSearchLimit))], nil
+}
+
+// same semantics as the Mongo pre-filter - documents w/o a level never match a level range
+func matchesVectorSearchFilter(embedding model.CardEmbedding, filter model.VectorSearchFilter) bool {
+	switch {
+	case slices.Contains(filter.ExcludedIDs, embedding.ID):
+		return false
+	case filter.Color != "" && embedding.Type != filter.Color:
+		return false
+	case filter.Attribute != "" && embedding.Attribute != filter.Attribute:
+		return false
+	case filter.MonsterType != "" && embedding.MonsterType != filter.MonsterType:
+		return false
+	case (filter.MinLevel != nil || filter.MaxLevel != nil) && embedding.Level == nil:
+		return false
+	case filter.MinLevel != nil && *embedding.Level < *filter.MinLevel:
+		return false
+	case filter.MaxLevel != nil && *embedding.Level > *filter.MaxLevel:
+		return false
+	}
+	return true
+}
+
+// Brute force (exact) nearest neighbor search using only cosine similarity
+func (impl *SKCSuggestionEngineDAOInMemory) VectorSearchUsingQuery(ctx context.Context, queryVector []float32) ([]model.VectorSearchResult, *cModel.APIError) {
+	cUtil.RetrieveLogger(ctx).Info("Performing vector search using query")
+
+	impl.mu.RLock()
+	results := make([]model.VectorSearchResult, 0, len(impl.cardEmbeddings))
+	for _, embedding := range impl.cardEmbeddings {
+		results = append(results, model.VectorSearchResult{
+			ID: embedding.ID, Text: embedding.Text, CosineSimilarity: (1 + cosineSimilarity(queryVector, embedding.TextEmbedding)) / 2,
+		})
+	}
+	impl.mu.RUnlock()
+
+	slices.SortStableFunc(results, func(a, b model.VectorSearchResult) int {
+		return cmp.Compare(b.CosineSimilarity, a.CosineSimilarity)
+	})
+	return results[:min(len(results), vectorSearchLimit)], nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) GetCardEmbedding(ctx context.Context, cardID string) (*model.CardEmbedding, *cModel.APIError) {
+	impl.mu.RLock()
+	defer impl.mu.RUnlock()
+
+	for _, cardEmbedding := range impl.cardEmbeddings {
+		if cardEmbedding.ID == cardID {
+			return &cardEmbedding, nil
+		}
+	}
+	return nil, nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) GetCardEmbeddingTexts(ctx context.Context, cardIDs cModel.CardIDs) (map[string]string, *cModel.APIError) {
+	impl.mu.RLock()
+	defer impl.mu.RUnlock()
+
+	textByID := make(map[string]string, len(cardIDs))
+	for _, cardEmbedding := range impl.cardEmbeddings {
+		if slices.Contains(cardIDs, cardEmbedding.ID) {
+			textByID[cardEmbedding.ID] = cardEmbedding.Text
+		}
+	}
+	return textByID, nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) UpsertCardEmbeddings(ctx context.Context, cardEmbeddings []model.CardEmbedding) *cModel.APIError {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	for _, cardEmbedding := range cardEmbeddings {
+		if i := slices.IndexFunc(impl.cardEmbeddings, func(e model.CardEmbedding) bool { return e.ID == cardEmbedding.ID }); i != -1 {
+			if cardEmbedding.ReleaseDate == "" {
+				cardEmbedding.ReleaseDate = impl.cardEmbeddings[i].ReleaseDate // same as the Mongo $set - an empty release date doesn't replace the existing one
+			}
+			if cardEmbedding.Level == nil {
+				cardEmbedding.Level = impl.cardEmbeddings[i].Level
+			}
+			impl.cardEmbeddings[i] = cardEmbedding
+		} else {
+			impl.cardEmbeddings = append(impl.cardEmbeddings, cardEmbedding)
+		}
+	}
+	return nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) GetCardEmbeddingIDs(ctx context.Context) (cModel.CardIDs, *cModel.APIError) {
+	impl.mu.RLock()
+	defer impl.mu.RUnlock()
+
+	cardIDs := make(cModel.CardIDs, len(impl.cardEmbeddings))
+	for i, cardEmbedding := range impl.cardEmbeddings {
+		cardIDs[i] = cardEmbedding.ID
+	}
+	return cardIDs, nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) GetCardReleaseDates(ctx context.Context, cardIDs cModel.CardIDs) (map[string]string, *cModel.APIError) {
+	impl.mu.RLock()
+	defer impl.mu.RUnlock()
+
+	releaseDateByID := make(map[string]string, len(cardIDs))
+	for _, cardEmbedding := range impl.cardEmbeddings {
+		if cardEmbedding.ReleaseDate != "" && slices.Contains(cardIDs, cardEmbedding.ID) {
+			releaseDateByID[cardEmbedding.ID] = cardEmbedding.ReleaseDate
+		}
+	}
+	return releaseDateByID, nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) UpdateCardReleaseDates(ctx context.Context, releaseDateByID map[string]string) *cModel.APIError {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	for i, cardEmbedding := range impl.cardEmbeddings {
+		if releaseDate, isPresent := releaseDateByID[cardEmbedding.ID]; isPresent && (cardEmbedding.ReleaseDate == "" || releaseDate < cardEmbedding.ReleaseDate) {
+			impl.cardEmbeddings[i].ReleaseDate = releaseDate
+		}
+	}
+	return nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) UpdateCardLevels(ctx context.Context, levelByID map[string]int) *cModel.APIError {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	for i, cardEmbedding := range impl.cardEmbeddings {
+		if level, isPresent := levelByID[cardEmbedding.ID]; isPresent {
+			impl.cardEmbeddings[i].Level = &level
+		}
+	}
+	return nil
+}
+
+// cardEmbeddings never holds more than one document per card - see UpsertCardEmbeddings
+func (impl *SKCSuggestionEngineDAOInMemory) DedupeCardEmbeddings(ctx context.Context, confirm bool) (*model.CardEmbeddingDedupe, *cModel.APIError) {
+	return &model.CardEmbeddingDedupe{CardIDs: []string{}, Confirmed: confirm}, nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) GetCachedEmbedding(ctx context.Context, key string) ([]float32, *cModel.APIError) {
+	impl.mu.RLock()
+	defer impl.mu.RUnlock()
+
+	if cachedEmbedding, isPresent := impl.embeddingCache[key]; isPresent {
+		return cachedEmbedding.Embedding, nil
+	}
+	return nil, nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) InsertCachedEmbedding(ctx context.Context, cachedEmbedding model.CachedEmbedding) *cModel.APIError {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+	impl.embeddingCache[cachedEmbedding.Key] = cachedEmbedding
+	return nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) GetTokenUsage(ctx context.Context, date string) (*model.DailyTokenUsage, *cModel.APIError) {
+	impl.mu.RLock()
+	defer impl.mu.RUnlock()
+
+	if usage, isPresent := impl.tokenUsage[date]; isPresent {
+		return &usage, nil
+	}
+	return nil, nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) UpsertTokenUsage(ctx context.Context, usage model.DailyTokenUsage) *cModel.APIError {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+	impl.tokenUsage[usage.Date] = usage
+	return nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) GetReferenceIndex(ctx context.Context) ([]model.ReferenceIndexEntry, *cModel.APIError) {
+	impl.mu.RLock()
+	defer impl.mu.RUnlock()
+	return slices.Clone(impl.referenceIndex), nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) ReplaceReferenceIndex(ctx context.Context, entries []model.ReferenceIndexEntry) *cModel.APIError {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+	impl.referenceIndex = slices.Clone(entries)
+	return nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) GetAlsoViewed(ctx context.Context, resourceName model.ResourceName, resourceID string) (*model.AlsoViewedEntry, *cModel.APIError) {
+	impl.mu.RLock()
+	defer impl.mu.RUnlock()
+
+	for _, entry := range impl.alsoViewed {
+		if entry.ResourceName == resourceName && entry.ResourceID == resourceID {
+			return &entry, nil
+		}
+	}
+	return nil, nil
+}
+
+func (impl *SKCSuggestionEngineDAOInMemory) ReplaceAlsoViewed(ctx context.Context, entries []model.AlsoViewedEntry) *cModel.APIError {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+	impl.alsoViewed = slices.Clone(entries)
+	return nil
+}
+
+func cosineSimilarity(a []float32, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,166 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+	skc_testing "github.com/ygo-skc/skc-suggestion-engine/testing"
+)
+
+func TestInMemoryTrafficData(t *testing.T) {
+	assert := assert.New(t)
+	impl, _ := NewSKCSuggestionEngineDAOInMemory("")
+
+	now := time.Now()
+	for _, cardID := range []string{"40044918", "46986414", "40044918", "97631303", "40044918", "46986414"} {
+		impl.InsertTrafficData(skc_testing.TestContext, model.TrafficAnalysis{
+			Timestamp:        now,
+			ResourceUtilized: model.TrafficResource{Name: model.CardResource, Value: cardID},
+		})
+	}
+	impl.InsertTrafficData(skc_testing.TestContext, model.TrafficAnalysis{
+		Timestamp:        now.AddDate(0, 0, -30),
+		ResourceUtilized: model.TrafficResource{Name: model.CardResource, Value: "97631303"},
+	})
+	impl.InsertTrafficData(skc_testing.TestContext, model.TrafficAnalysis{
+		Timestamp:        now,
+		ResourceUtilized: model.TrafficResource{Name: model.ProductResource, Value: "LOB"},
+	})
+
+	td, err := impl.GetTrafficData(skc_testing.TestContext, model.CardResource, now.AddDate(0, 0, -10), now, 10)
+	assert.Nil(err)
+	assert.Equal([]model.TrafficResourceUtilizationMetric{
+		{ResourceValue: "40044918", Occurrences: 3},
+		{ResourceValue: "46986414", Occurrences: 2},
+		{ResourceValue: "97631303", Occurrences: 1},
+	}, td, "Traffic data should only include card resources within the interval, ordered by occurrence")
+
+	td, err = impl.GetTrafficData(skc_testing.TestContext, model.CardResource, now.AddDate(0, 0, -10), now, 2)
+	assert.Nil(err)
+	assert.Len(td, 2, "Traffic data should be limited")
+
+	views, err := impl.GetCardViews(skc_testing.TestContext, now.AddDate(0, 0, -10))
+	assert.Nil(err)
+	assert.Equal(map[string]int{"40044918": 3, "46986414": 2, "97631303": 1}, views, "Only card views since from should be counted")
+}
+
+func TestInMemoryCardOfTheDay(t *testing.T) {
+	assert := assert.New(t)
+	impl, _ := NewSKCSuggestionEngineDAOInMemory("")
+
+	cardID, err := impl.GetCardOfTheDay(skc_testing.TestContext, "2026-10-18", 1)
+	assert.Nil(cardID, "No COTD should exist in an empty DB")
+	assert.Nil(err)
+
+	impl.InsertCardOfTheDay(skc_testing.TestContext, model.CardOfTheDay{Date: "2026-10-17", Version: 1, CardID: "46986414"})
+	impl.InsertCardOfTheDay(skc_testing.TestContext, model.CardOfTheDay{Date: "2026-10-18", Version: 1, CardID: "40044918"})
+
+	cardID, _ = impl.GetCardOfTheDay(skc_testing.TestContext, "2026-10-18", 1)
+	assert.Equal("40044918", *cardID)
+
+	history, _ := impl.GetHistoricalCardOfTheDayData(skc_testing.TestContext, 1)
+	assert.Equal([]string{"46986414", "40044918"}, history)
+}
+
+func TestInMemoryRelevantArchetypes(t *testing.T) {
+	assert := assert.New(t)
+	impl, _ := NewSKCSuggestionEngineDAOInMemory("")
+	for _, archetype := range []inMemoryArchetype{
+		{Archetype: "Dark Magician", InheritMembers: []string{"46986414"}},
+		{Archetype: "Magician", QualifiedMembers: []string{"46986414", "97631303"}},
+		{Archetype: "Chaos", InheritMembers: []string{"46986414"}},
+		{Archetype: "HERO", InheritMembers: []string{"89943723"}},
+	} {
+		impl.archetypes[archetype.Archetype] = archetype
+	}
+
+	for range 10 {
+		archetypes, err := impl.GetRelevantArchetypes(skc_testing.TestContext, cModel.CardIDs{"46986414"})
+		assert.Nil(err)
+		assert.Equal([]string{"Chaos", "Dark Magician", "Magician"}, archetypes, "Archetypes should be sorted")
+	}
+}
+
+func TestInMemoryVectorSearch(t *testing.T) {
+	assert := assert.New(t)
+	impl, _ := NewSKCSuggestionEngineDAOInMemory("")
+	impl.cardEmbeddings = []model.CardEmbedding{
+		{ID: "46986414", Text: "subject", TextEmbedding: []float32{1, 0, 0}},
+		{ID: "97631303", Text: "orthogonal", TextEmbedding: []float32{0, 1, 0}},
+		{ID: "98502113", Text: "close", TextEmbedding: []float32{0.9, 0.1, 0}},
+		{ID: "40044918", Text: "opposite", TextEmbedding: []float32{-1, 0, 0}},
+	}
+
+	subject := cModel.YGOCardREST{ID: "46986414", Name: "Dark Magician"}
+	results, err := impl.VectorSearchOnCardEmbedding(skc_testing.TestContext, subject, []float32{1, 0, 0}, model.VectorSearchFilter{})
+	assert.Nil(err)
+	assert.Equal([]string{"98502113", "97631303", "40044918"}, vectorSearchIDs(results), "Subject should be excluded and results should be ordered by similarity")
+	assert.InDelta(0.5, results[1].CosineSimilarity, 1e-9, "Cosine similarity should be normalized to [0, 1]")
+}
+
+func TestInMemoryVectorSearchBoosts(t *testing.T) {
+	assert := assert.New(t)
+	impl, _ := NewSKCSuggestionEngineDAOInMemory("")
+	impl.cardEmbeddings = []model.CardEmbedding{
+		{ID: "97631303", Type: "Effect", Attribute: "LIGHT", MonsterType: "Dragon/Effect", TextEmbedding: []float32{0.9, 0.1, 0}},
+		{ID: "98502113", Type: "Effect", Attribute: "DARK", MonsterType: "Spellcaster/Effect", TextEmbedding: []float32{0.9, 0.1, 0}},
+	}
+
+	monsterType := "Spellcaster/Effect"
+	subject := cModel.YGOCardREST{ID: "46986414", Color: "Effect", Attribute: "DARK", MonsterType: &monsterType}
+	results, _ := impl.VectorSearchOnCardEmbedding(skc_testing.TestContext, subject, []float32{1, 0, 0}, model.VectorSearchFilter{})
+
+	assert.Equal("98502113", results[0].ID, "Boosts should break ties between equally similar cards")
+	assert.Equal([3]int{0, 1, 1}, [3]int{results[0].SharedType, results[0].SharedAttribute, results[0].SharedMonsterType})
+	assert.Equal([3]int{0, 0, 0}, [3]int{results[1].SharedType, results[1].SharedAttribute, results[1].SharedMonsterType})
+	assert.InDelta(results[0].CosineSimilarity+SharedAttributeBoost+SharedMonsterTypeBoost, results[0].FinalScore, 1e-9)
+}
+
+func TestInMemoryVectorSearchUsingQuery(t *testing.T) {
+	assert := assert.New(t)
+	impl, _ := NewSKCSuggestionEngineDAOInMemory("")
+	impl.cardEmbeddings = []model.CardEmbedding{
+		{ID: "46986414", Text: "exact", TextEmbedding: []float32{1, 0, 0}},
+		{ID: "97631303", Text: "orthogonal", TextEmbedding: []float32{0, 1, 0}},
+		{ID: "98502113", Text: "close", TextEmbedding: []float32{0.9, 0.1, 0}},
+	}
+
+	results, err := impl.VectorSearchUsingQuery(skc_testing.TestContext, []float32{1, 0, 0})
+	assert.Nil(err)
+	assert.Equal([]string{"46986414", "98502113", "97631303"}, vectorSearchIDs(results), "No card should be excluded when searching using a query")
+	assert.InDelta(1.0, results[0].CosineSimilarity, 1e-9)
+}
+
+func vectorSearchIDs(results []model.VectorSearchResult) []string {
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+	return ids
+}
+
+func TestInMemoryVectorSearchFilter(t *testing.T) {
+	assert := assert.New(t)
+	impl, _ := NewSKCSuggestionEngineDAOInMemory("")
+	four, eight := 4, 8
+	impl.cardEmbeddings = []model.CardEmbedding{
+		{ID: "97631303", Type: "Effect", Attribute: "LIGHT", Level: &four, TextEmbedding: []float32{1, 0, 0}},
+		{ID: "98502113", Type: "Effect", Attribute: "DARK", Level: &eight, TextEmbedding: []float32{1, 0, 0}},
+		{ID: "40044918", Type: "Fusion", Attribute: "DARK", TextEmbedding: []float32{1, 0, 0}},
+	}
+	subject := cModel.YGOCardREST{ID: "46986414"}
+	search := func(filter model.VectorSearchFilter) []string {
+		results, _ := impl.VectorSearchOnCardEmbedding(skc_testing.TestContext, subject, []float32{1, 0, 0}, filter)
+		return vectorSearchIDs(results)
+	}
+
+	assert.ElementsMatch([]string{"98502113", "40044918"}, search(model.VectorSearchFilter{Attribute: "DARK"}))
+	assert.ElementsMatch([]string{"40044918"}, search(model.VectorSearchFilter{Color: "Fusion"}))
+	assert.ElementsMatch([]string{"98502113"}, search(model.VectorSearchFilter{MinLevel: &eight}), "Cards without a level should not match level ranges")
+	assert.ElementsMatch([]string{"97631303"}, search(model.VectorSearchFilter{MaxLevel: &four}))
+	assert.ElementsMatch([]string{"40044918"}, search(model.VectorSearchFilter{ExcludedIDs: []string{"97631303", "98502113"}}))
+	assert.Len(search(model.VectorSearchFilter{Limit: 1}), 1)
+}
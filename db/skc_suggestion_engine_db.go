@@ -1,6 +1,7 @@
 package db
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
@@ -22,6 +23,16 @@ const (
 	intervalFormat = "2006-01-02"
 
 	maxBlackListPhraseLength = 40
+
+	// co-view builds read up to a month of traffic
+	trafficAnalysisStreamTimeout = 5 * time.Minute
+	trafficAnalysisBatchSize     = 1000
+
+	// vector search tuning - shared by every DAO implementation so results stay comparable
+	vectorSearchLimit      = 30
+	SharedTypeBoost        = 0.03
+	SharedAttributeBoost   = 0.05
+	SharedMonsterTypeBoost = 0.08
 )
 
 // interface
@@ -29,7 +40,9 @@ type SKCSuggestionEngineDAO interface {
 	GetSKCSuggestionDBVersion(context.Context) (string, error)
 
 	InsertTrafficData(context.Context, model.TrafficAnalysis) *cModel.APIError
-	GetTrafficData(context.Context, model.ResourceName, time.Time, time.Time) ([]model.TrafficResourceUtilizationMetric, *cModel.APIError)
+	GetTrafficData(context.Context, model.ResourceName, time.Time, time.Time, int) ([]model.TrafficResourceUtilizationMetric, *cModel.APIError)
+	GetCardViews(context.Context, time.Time) (map[string]int, *cModel.APIError)
+	StreamTrafficAnalysis(context.Context, time.Time, func(model.TrafficAnalysis)) *cModel.APIError
 
 	IsBlackListed(context.Context, string, string) (bool, *cModel.APIError)
 
@@ -39,8 +52,30 @@ type SKCSuggestionEngineDAO interface {
 
 	GetArchetypeMembers(context.Context, string) ([]string, []string, []string, *cModel.APIError)
 	GetRelevantArchetypes(context.Context, cModel.CardIDs) ([]string, *cModel.APIError)
+	GetArchetypesByName(context.Context, []string) ([]string, *cModel.APIError)
+
+	VectorSearchOnCardEmbedding(context.Context, cModel.YGOCard, []float32, model.VectorSearchFilter) ([]model.VectorSearchResult, *cModel.APIError)
+	VectorSearchUsingQuery(context.Context, []float32) ([]model.VectorSearchResult, *cModel.APIError)
+	GetCardEmbedding(context.Context, string) (*model.CardEmbedding, *cModel.APIError)
+	GetCardEmbeddingTexts(context.Context, cModel.CardIDs) (map[string]string, *cModel.APIError)
+	UpsertCardEmbeddings(context.Context, []model.CardEmbedding) *cModel.APIError
+	GetCardEmbeddingIDs(context.Context) (cModel.CardIDs, *cModel.APIError)
+	GetCardReleaseDates(context.Context, cModel.CardIDs) (map[string]string, *cModel.APIError)
+	UpdateCardReleaseDates(context.Context, map[string]string) *cModel.APIError
+	UpdateCardLevels(context.Context, map[string]int) *cModel.APIError
+	DedupeCardEmbeddings(context.Context, bool) (*model.CardEmbeddingDedupe, *cModel.APIError)
+
+	GetCachedEmbedding(context.Context, string) ([]float32, *cModel.APIError)
+	InsertCachedEmbedding(context.Context, model.CachedEmbedding) *cModel.APIError
+
+	GetTokenUsage(context.Context, string) (*model.DailyTokenUsage, *cModel.APIError)
+	UpsertTokenUsage(context.Context, model.DailyTokenUsage) *cModel.APIError
+
+	GetReferenceIndex(context.Context) ([]model.ReferenceIndexEntry, *cModel.APIError)
+	ReplaceReferenceIndex(context.Context, []model.ReferenceIndexEntry) *cModel.APIError
 
-	VectorSearchOnCardEmbedding(context.Context, cModel.YGOCard, []float32) ([]model.VectorSearchResult, *cModel.APIError)
+	GetAlsoViewed(context.Context, model.ResourceName, string) (*model.AlsoViewedEntry, *cModel.APIError)
+	ReplaceAlsoViewed(context.Context, []model.AlsoViewedEntry) *cModel.APIError
 }
 
 // impl
@@ -79,8 +114,9 @@ func (impl SKCSuggestionEngineDAOImplementation) InsertTrafficData(ctx context.C
 	}
 }
 
+// Most utilized resources between from and to - at most limit resources are returned
 func (impl SKCSuggestionEngineDAOImplementation) GetTrafficData(
-	ctx context.Context, resourceName model.ResourceName, from time.Time, to time.Time) ([]model.TrafficResourceUtilizationMetric, *cModel.APIError) {
+	ctx context.Context, resourceName model.ResourceName, from time.Time, to time.Time, limit int) ([]model.TrafficResourceUtilizationMetric, *cModel.APIError) {
 	logger := cUtil.RetrieveLogger(ctx)
 	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
 	defer cancel()
@@ -112,7 +148,7 @@ func (impl SKCSuggestionEngineDAOImplementation) GetTrafficData(
 				{Key: "occurrences", Value: -1},
 				{Key: "_id", Value: -1},
 			}}},
-		{{Key: "$limit", Value: 10}},
+		{{Key: "$limit", Value: limit}},
 	}
 
 	if cursor, err := trafficAnalysisCollection.Aggregate(ctx, pipeline); err != nil {
@@ -131,6 +167,98 @@ func (impl SKCSuggestionEngineDAOImplementation) GetTrafficData(
 	}
 }
 
+// Number of times each card was viewed since from, keyed by card ID. Cards without views are not included.
+// Every viewed card is returned - this is meant for jobs that precompute popularity, not for requests.
+func (impl SKCSuggestionEngineDAOImplementation) GetCardViews(ctx context.Context, from time.Time) (map[string]int, *cModel.APIError) {
+	logger := cUtil.RetrieveLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{
+			{Key: "$match",
+				Value: bson.D{
+					{Key: "resourceUtilized.name", Value: model.CardResource},
+					{Key: "timestamp", Value: bson.D{{Key: "$gte", Value: from}}},
+				},
+			},
+		},
+		{
+			{Key: "$group",
+				Value: bson.D{
+					{Key: "_id", Value: "$resourceUtilized.value"},
+					{Key: "occurrences", Value: bson.D{{Key: "$sum", Value: 1}}},
+				},
+			},
+		},
+	}
+
+	cursor, err := trafficAnalysisCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.Error("Error retrieving card views", slog.String("from", from.Format(intervalFormat)), slog.Any("err", err))
+		return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Could not get traffic data."}
+	}
+
+	var td []model.TrafficResourceUtilizationMetric
+	if err := cursor.All(ctx, &td); err != nil {
+		logger.Error("Error retrieving card views", slog.String("from", from.Format(intervalFormat)), slog.Any("err", err))
+		return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Could not get traffic data."}
+	}
+
+	viewsByID := make(map[string]int, len(td))
+	for _, metric := range td {
+		viewsByID[metric.ResourceValue] = metric.Occurrences
+	}
+	return viewsByID, nil
+}
+
+// Calls fn with every card and product view since from that has an IP, oldest first. Only the timestamp, source, resource and IP of each record are retrieved.
+// Records are read in batches so the whole period is never held in memory.
+func (impl SKCSuggestionEngineDAOImplementation) StreamTrafficAnalysis(ctx context.Context, from time.Time, fn func(model.TrafficAnalysis)) *cModel.APIError {
+	logger := cUtil.RetrieveLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, trafficAnalysisStreamTimeout)
+	defer cancel()
+
+	query := bson.M{
+		"timestamp":             bson.M{"$gte": from},
+		"resourceUtilized.name": bson.M{"$in": bson.A{model.CardResource, model.ProductResource}},
+		"userData.ip":           bson.M{"$nin": bson.A{nil, ""}},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: 1}}).
+		SetAllowDiskUse(true).
+		SetBatchSize(trafficAnalysisBatchSize).
+		SetProjection(bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "timestamp", Value: 1},
+			{Key: "source", Value: 1},
+			{Key: "resourceUtilized", Value: 1},
+			{Key: "userData.ip", Value: 1},
+		})
+
+	cursor, err := trafficAnalysisCollection.Find(ctx, query, opts)
+	if err != nil {
+		logger.Error("Error retrieving traffic analysis", slog.String("from", from.Format(intervalFormat)), slog.Any("err", err))
+		return &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Could not get traffic data."}
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var ta model.TrafficAnalysis
+		if err := cursor.Decode(&ta); err != nil {
+			logger.Error("Error decoding traffic analysis", slog.Any("err", err))
+			return &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Could not get traffic data."}
+		}
+		fn(ta)
+	}
+
+	if err := cursor.Err(); err != nil {
+		logger.Error("Error retrieving traffic analysis", slog.String("from", from.Format(intervalFormat)), slog.Any("err", err))
+		return &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Could not get traffic data."}
+	}
+	return nil
+}
+
 func (impl SKCSuggestionEngineDAOImplementation) IsBlackListed(ctx context.Context, blackListType string, blackListPhrase string) (bool, *cModel.APIError) {
 	logger := cUtil.RetrieveLogger(ctx)
 	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
@@ -319,15 +447,50 @@ func (impl SKCSuggestionEngineDAOImplementation) GetRelevantArchetypes(ctx conte
 	return f, nil
 }
 
+// Returns the names that belong to an archetype in the DB - names that aren't archetypes are dropped.
+func (impl SKCSuggestionEngineDAOImplementation) GetArchetypesByName(ctx context.Context, names []string) ([]string, *cModel.APIError) {
+	logger := cUtil.RetrieveLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetProjection(
+		bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "archetype", Value: 1},
+		},
+	)
+
+	cursor, err := archetypeCollection.Find(ctx, bson.M{"archetype": bson.M{"$in": names}}, opts)
+	if err != nil {
+		logger.Error("Error retrieving archetypes by name from DB", slog.Any("err", err))
+		return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Error retrieving archetype data"}
+	}
+	defer cursor.Close(ctx)
+
+	var archetypes []struct {
+		Archetype string `bson:"archetype"`
+	}
+	if err := cursor.All(ctx, &archetypes); err != nil {
+		logger.Error("Error retrieving archetypes by name from DB", slog.Any("err", err))
+		return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Error retrieving archetype data"}
+	}
+
+	existing := make([]string, 0, len(archetypes))
+	for _, a := range archetypes {
+		existing = append(existing, a.Archetype)
+	}
+	return existing, nil
+}
+
 func (impl SKCSuggestionEngineDAOImplementation) VectorSearchOnCardEmbedding(ctx context.Context,
-	subject cModel.YGOCard, queryVector []float32) ([]model.VectorSearchResult, *cModel.APIError) {
+	subject cModel.YGOCard, queryVector []float32, filter model.VectorSearchFilter) ([]model.VectorSearchResult, *cModel.APIError) {
 	logger := cUtil.RetrieveLogger(ctx)
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
 	logger.Info("Performing vector search on card text")
 
-	limit := 30
+	limit := cmp.Or(filter.Limit, vectorSearchLimit)
 
 	pipeline := mongo.Pipeline{
 		{
@@ -336,11 +499,7 @@ func (impl SKCSuggestionEngineDAOImplementation) VectorSearchOnCardEmbedding(ctx
 					{Key: "index", Value: "text_embedding"},
 					{Key: "path", Value: "textEmbedding"},
 					{Key: "exact", Value: true}, // true = ENN search https://www.mongodb.com/docs/vector-search/query/aggregation-stages/vector-search-stage/?deployment-type=atlas&embedding=auto&interface=driver&language=go#enn-search
-					{Key: "filter", Value: bson.D{
-						{Key: "id", Value: bson.D{
-							{Key: "$ne", Value: subject.GetID()},
-						}},
-					}},
+					{Key: "filter", Value: vectorSearchPreFilter(subject.GetID(), filter)},
 					{Key: "queryVector", Value: queryVector},
 					{Key: "limit", Value: limit * 2},
 				},
@@ -379,9 +538,9 @@ func (impl SKCSuggestionEngineDAOImplementation) VectorSearchOnCardEmbedding(ctx
 				{Key: "finalScore", Value: bson.D{
 					{Key: "$add", Value: bson.A{
 						"$cosineSimilarity",
-						bson.D{{Key: "$multiply", Value: bson.A{"$sharedType", 0.03}}},
-						bson.D{{Key: "$multiply", Value: bson.A{"$sharedAttribute", 0.05}}},
-						bson.D{{Key: "$multiply", Value: bson.A{"$sharedMonsterType", 0.08}}},
+						bson.D{{Key: "$multiply", Value: bson.A{"$sharedType", SharedTypeBoost}}},
+						bson.D{{Key: "$multiply", Value: bson.A{"$sharedAttribute", SharedAttributeBoost}}},
+						bson.D{{Key: "$multiply", Value: bson.A{"$sharedMonsterType", SharedMonsterTypeBoost}}},
 					}},
 				}},
 			}},
@@ -400,6 +559,7 @@ func (impl SKCSuggestionEngineDAOImplementation) VectorSearchOnCardEmbedding(ctx
 				{Key: "id", Value: 1},
 				{Key: "text", Value: 1},
 				{Key: "cosineSimilarity", Value: 1},
+				{Key: "sharedType", Value: 1},
 				{Key: "sharedAttribute", Value: 1},
 				{Key: "sharedMonsterType", Value: 1},
 				{Key: "finalScore", Value: 1},
@@ -432,3 +592,471 @@ func (impl SKCSuggestionEngineDAOImplementation) VectorSearchOnCardEmbedding(ctx
 
 	return results, nil
 }
+
+// Filters applied by $vectorSearch before similarity is calculated - fields must be indexed as filter fields in the text_embedding index.
+func vectorSearchPreFilter(subjectID string, filter model.VectorSearchFilter) bson.D {
+	preFilter := bson.D{
+		{Key: "id", Value: bson.D{
+			{Key: "$nin", Value: append([]string{subjectID}, filter.ExcludedIDs...)},
+		}},
+	}
+
+	if filter.Color != "" {
+		preFilter = append(preFilter, bson.E{Key: "type", Value: bson.D{{Key: "$eq", Value: filter.Color}}})
+	}
+	if filter.Attribute != "" {
+		preFilter = append(preFilter, bson.E{Key: "attribute", Value: bson.D{{Key: "$eq", Value: filter.Attribute}}})
+	}
+	if filter.MonsterType != "" {
+		preFilter = append(preFilter, bson.E{Key: "monsterType", Value: bson.D{{Key: "$eq", Value: filter.MonsterType}}})
+	}
+	if filter.MinLevel != nil || filter.MaxLevel != nil {
+		levelFilter := bson.D{}
+		if filter.MinLevel != nil {
+			levelFilter = append(levelFilter, bson.E{Key: "$gte", Value: *filter.MinLevel})
+		}
+		if filter.MaxLevel != nil {
+			levelFilter = append(levelFilter, bson.E{Key: "$lte", Value: *filter.MaxLevel})
+		}
+		preFilter = append(preFilter, bson.E{Key: "level", Value: levelFilter})
+	}
+
+	return preFilter
+}
+
+// Vector search that isn't tied to a subject card - no cards are filtered out and no boosts are applied, results are ordered by cosine similarity.
+func (impl SKCSuggestionEngineDAOImplementation) VectorSearchUsingQuery(ctx context.Context, queryVector []float32) ([]model.VectorSearchResult, *cModel.APIError) {
+	logger := cUtil.RetrieveLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	logger.Info("Performing vector search using query")
+
+	pipeline := mongo.Pipeline{
+		{
+			{
+				Key: "$vectorSearch", Value: bson.D{
+					{Key: "index", Value: "text_embedding"},
+					{Key: "path", Value: "textEmbedding"},
+					{Key: "exact", Value: true},
+					{Key: "queryVector", Value: queryVector},
+					{Key: "limit", Value: vectorSearchLimit},
+				},
+			},
+		},
+		{
+			{Key: "$project", Value: bson.D{
+				{Key: "_id", Value: 0},
+				{Key: "id", Value: 1},
+				{Key: "text", Value: 1},
+				{Key: "cosineSimilarity", Value: bson.D{
+					{Key: "$meta", Value: "vectorSearchScore"},
+				}},
+			}},
+		},
+	}
+
+	cursor, err := cardEmbeddingCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.Error("Error while searching card embedding", slog.Any("err", err))
+		return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Error searching cards"}
+	}
+	defer cursor.Close(ctx)
+
+	results := make([]model.VectorSearchResult, 0, vectorSearchLimit)
+	if err := cursor.All(ctx, &results); err != nil {
+		logger.Error("There was an error parsing db results", slog.Any("err", err))
+		return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Error searching cards"}
+	}
+	return results, nil
+}
+
+// Retrieves the cardEmbedding document for a card. Nil is returned if the card has no document.
+func (impl SKCSuggestionEngineDAOImplementation) GetCardEmbedding(ctx context.Context, cardID string) (*model.CardEmbedding, *cModel.APIError) {
+	logger := cUtil.RetrieveLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	var cardEmbedding model.CardEmbedding
+	if err := cardEmbeddingCollection.FindOne(ctx, bson.M{"id": cardID}).Decode(&cardEmbedding); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		logger.Error("Error retrieving card embedding", slog.String("card_id", cardID), slog.Any("err", err))
+		return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Could not get card embedding."}
+	}
+
+	return &cardEmbedding, nil
+}
+
+// Retrieves the text currently embedded for each card, keyed by card ID. Cards without a document are not included.
+func (impl SKCSuggestionEngineDAOImplementation) GetCardEmbeddingTexts(ctx context.Context, cardIDs cModel.CardIDs) (map[string]string, *cModel.APIError) {
+	logger := cUtil.RetrieveLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetProjection( // select only these fields from collection - embeddings are large
+		bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "id", Value: 1},
+			{Key: "text", Value: 1},
+		},
+	)
+
+	cursor, err := cardEmbeddingCollection.Find(ctx, bson.M{"id": bson.M{"$in": cardIDs}}, opts)
+	if err != nil {
+		logger.Error("Error retrieving card embedding text", slog.Any("err", err))
+		return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Could not get card embedding data."}
+	}
+	defer cursor.Close(ctx)
+
+	var cardEmbeddings []model.CardEmbedding
+	if err := cursor.All(ctx, &cardEmbeddings); err != nil {
+		logger.Error("Error retrieving card embedding text", slog.Any("err", err))
+		return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Could not get card embedding data."}
+	}
+
+	textByID := make(map[string]string, len(cardEmbeddings))
+	for _, cardEmbedding := range cardEmbeddings {
+		textByID[cardEmbedding.ID] = cardEmbedding.Text
+	}
+	return textByID, nil
+}
+
+// Inserts or replaces (using card ID) documents in the cardEmbedding collection.
+func (impl SKCSuggestionEngineDAOImplementation) UpsertCardEmbeddings(ctx context.Context, cardEmbeddings []model.CardEmbedding) *cModel.APIError {
+	logger := cUtil.RetrieveLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// $set keeps the release date of existing documents when the card wasn't ingested using a product
+	writes := make([]mongo.WriteModel, len(cardEmbeddings))
+	for i, cardEmbedding := range cardEmbeddings {
+		writes[i] = mongo.NewUpdateOneModel().SetFilter(bson.M{"id": cardEmbedding.ID}).SetUpdate(bson.M{"$set": cardEmbedding}).SetUpsert(true)
+	}
+
+	if res, err := cardEmbeddingCollection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		logger.Error("Error upserting card embeddings", slog.Int("total", len(cardEmbeddings)), slog.Any("err", err))
+		return &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Error saving card embeddings."}
+	} else {
+		logger.Info("Upserted card embeddings", slog.Int64("inserted", res.UpsertedCount), slog.Int64("updated", res.ModifiedCount))
+		return nil
+	}
+}
+
+// Retrieves the ID of every card that has a cardEmbedding document.
+func (impl SKCSuggestionEngineDAOImplementation) GetCardEmbeddingIDs(ctx context.Context) (cModel.CardIDs, *cModel.APIError) {
+	logger := cUtil.RetrieveLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var cardIDs cModel.CardIDs
+	if err := cardEmbeddingCollection.Distinct(ctx, "id", bson.M{}).Decode(&cardIDs); err != nil {
+		logger.Error("Error retrieving card embedding IDs", slog.Any("err", err))
+		return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Could not get card embedding data."}
+	}
+	return cardIDs, nil
+}
+
+// Retrieves the release date of each card, keyed by card ID. Cards without a known release date are not included.
+func (impl SKCSuggestionEngineDAOImplementation) GetCardReleaseDates(ctx context.Context, cardIDs cModel.CardIDs) (map[string]string, *cModel.APIError) {
+	logger := cUtil.RetrieveLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetProjection( // select only these fields from collection - embeddings are large
+		bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "id", Value: 1},
+			{Key: "releaseDate", Value: 1},
+		},
+	)
+
+	cursor, err := cardEmbeddingCollection.Find(ctx, bson.M{"id": bson.M{"$in": cardIDs}, "releaseDate": bson.M{"$exists": true}}, opts)
+	if err != nil {
+		logger.Error("Error retrieving card release dates", slog.Any("err", err))
+		return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Could not get card embedding data."}
+	}
+	defer cursor.Close(ctx)
+
+	var cardEmbeddings []model.CardEmbedding
+	if err := cursor.All(ctx, &cardEmbeddings); err != nil {
+		logger.Error("Error retrieving card release dates", slog.Any("err", err))
+		return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Could not get card embedding data."}
+	}
+
+	releaseDateByID := make(map[string]string, len(cardEmbeddings))
+	for _, cardEmbedding := range cardEmbeddings {
+		releaseDateByID[cardEmbedding.ID] = cardEmbedding.ReleaseDate
+	}
+	return releaseDateByID, nil
+}
+
+// Sets the release date (YYYY-MM-DD) of cards that have a cardEmbedding document - an earlier date is never replaced by a later one.
+func (impl SKCSuggestionEngineDAOImplementation) UpdateCardReleaseDates(ctx context.Context, releaseDateByID map[string]string) *cModel.APIError {
+	logger := cUtil.RetrieveLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	writes := make([]mongo.WriteModel, 0, len(releaseDateByID))
+	for cardID, releaseDate := range releaseDateByID {
+		writes = append(writes, mongo.NewUpdateOneModel().SetFilter(bson.M{"id": cardID}).SetUpdate(bson.M{"$min": bson.M{"releaseDate": releaseDate}}))
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+
+	if _, err := cardEmbeddingCollection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		logger.Error("Error updating card release dates", slog.Int("total", len(writes)), slog.Any("err", err))
+		return &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Error saving card release dates."}
+	}
+	return nil
+}
+
+// Sets the level, rank or link rating of cards that have a cardEmbedding document
+func (impl SKCSuggestionEngineDAOImplementation) UpdateCardLevels(ctx context.Context, levelByID map[string]int) *cModel.APIError {
+	logger := cUtil.RetrieveLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	writes := make([]mongo.WriteModel, 0, len(levelByID))
+	for cardID, level := range levelByID {
+		writes = append(writes, mongo.NewUpdateOneModel().SetFilter(bson.M{"id": cardID}).SetUpdate(bson.M{"$set": bson.M{"level": level}}))
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+
+	if _, err := cardEmbeddingCollection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		logger.Error("Error updating card levels", slog.Int("total", len(writes)), slog.Any("err", err))
+		return &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Error saving card levels."}
+	}
+	return nil
+}
+
+// Finds cards with more than one cardEmbedding document, removing all but one document of each card when confirm is true.
+// Documents written by the ingester (ObjectID _id) are kept over documents written elsewhere, newest first.
+// The unique card_embedding_id index is created once no duplicates are left.
+func (impl SKCSuggestionEngineDAOImplementation) DedupeCardEmbeddings(ctx context.Context, confirm bool) (*model.CardEmbeddingDedupe, *cModel.APIError) {
+	logger := cUtil.RetrieveLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, cardEmbeddingIndexTimeout)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$addFields", Value: bson.D{{Key: "ingested", Value: bson.D{{Key: "$eq", Value: bson.A{bson.D{{Key: "$type", Value: "$_id"}}, "objectId"}}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "ingested", Value: -1}, {Key: "_id", Value: -1}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$id"},
+			{Key: "documentIDs", Value: bson.D{{Key: "$push", Value: "$_id"}}},
+		}}},
+		{{Key: "$match", Value: bson.D{{Key: "documentIDs.1", Value: bson.D{{Key: "$exists", Value: true}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+
+	cursor, err := cardEmbeddingCollection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		logger.Error("Error finding duplicate card embeddings", slog.Any("err", err))
+		return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Error finding duplicate card embeddings."}
+	}
+	defer cursor.Close(ctx)
+
+	var duplicates []struct {
+		CardID      string `bson:"_id"`
+		DocumentIDs []any  `bson:"documentIDs"`
+	}
+	if err := cursor.All(ctx, &duplicates); err != nil {
+		logger.Error("Error finding duplicate card embeddings", slog.Any("err", err))
+		return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Error finding duplicate card embeddings."}
+	}
+
+	dedupe := model.CardEmbeddingDedupe{CardIDs: make([]string, 0, len(duplicates)), Confirmed: confirm}
+	stale := make([]any, 0)
+	for _, duplicate := range duplicates {
+		dedupe.CardIDs = append(dedupe.CardIDs, duplicate.CardID)
+		stale = append(stale, duplicate.DocumentIDs[1:]...) // first document is kept
+	}
+	dedupe.Duplicates = len(stale)
+	if !confirm {
+		return &dedupe, nil
+	}
+
+	if len(stale) > 0 {
+		res, err := cardEmbeddingCollection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": stale}})
+		if err != nil {
+			logger.Error("Error removing duplicate card embeddings", slog.Int("duplicates", len(stale)), slog.Any("err", err))
+			return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Error removing duplicate card embeddings."}
+		}
+		dedupe.Removed = res.DeletedCount
+	}
+
+	if err := createCardEmbeddingIDIndex(ctx); err != nil {
+		logger.Error("Error creating unique card embedding ID index", slog.Any("err", err))
+		return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Error creating unique card embedding ID index."}
+	}
+	return &dedupe, nil
+}
+
+// Retrieves a previously persisted embedding. Nil is returned on cache miss.
+func (impl SKCSuggestionEngineDAOImplementation) GetCachedEmbedding(ctx context.Context, key string) ([]float32, *cModel.APIError) {
+	logger := cUtil.RetrieveLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	opts := options.FindOne().SetProjection( // select only these fields from collection
+		bson.D{
+			{Key: "embedding", Value: 1},
+		},
+	)
+
+	var cachedEmbedding model.CachedEmbedding
+	if err := embeddingCacheCollection.FindOne(ctx, bson.M{"key": key}, opts).Decode(&cachedEmbedding); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		logger.Error("Error retrieving cached embedding", slog.String("key", key), slog.Any("err", err))
+		return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Could not get cached embedding."}
+	}
+
+	return cachedEmbedding.Embedding, nil
+}
+
+func (impl SKCSuggestionEngineDAOImplementation) InsertCachedEmbedding(ctx context.Context, cachedEmbedding model.CachedEmbedding) *cModel.APIError {
+	logger := cUtil.RetrieveLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err := embeddingCacheCollection.ReplaceOne(ctx, bson.M{"key": cachedEmbedding.Key}, cachedEmbedding, opts); err != nil {
+		logger.Error("Could not persist embedding", slog.String("key", cachedEmbedding.Key), slog.Any("err", err))
+		return &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Error saving embedding."}
+	}
+	return nil
+}
+
+// Retrieves token usage for a given day (YYYY-MM-DD). Nil is returned if no usage was recorded that day.
+func (impl SKCSuggestionEngineDAOImplementation) GetTokenUsage(ctx context.Context, date string) (*model.DailyTokenUsage, *cModel.APIError) {
+	logger := cUtil.RetrieveLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	var usage model.DailyTokenUsage
+	if err := tokenUsageCollection.FindOne(ctx, bson.M{"date": date}).Decode(&usage); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		logger.Error("Error retrieving token usage", slog.String("date", date), slog.Any("err", err))
+		return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Could not get token usage."}
+	}
+
+	return &usage, nil
+}
+
+func (impl SKCSuggestionEngineDAOImplementation) UpsertTokenUsage(ctx context.Context, usage model.DailyTokenUsage) *cModel.APIError {
+	logger := cUtil.RetrieveLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err := tokenUsageCollection.ReplaceOne(ctx, bson.M{"date": usage.Date}, usage, opts); err != nil {
+		logger.Error("Could not persist token usage", slog.String("date", usage.Date), slog.Any("err", err))
+		return &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Error saving token usage."}
+	}
+	return nil
+}
+
+// Retrieves the last reference index snapshot. Empty when the index was never persisted.
+func (impl SKCSuggestionEngineDAOImplementation) GetReferenceIndex(ctx context.Context) ([]model.ReferenceIndexEntry, *cModel.APIError) {
+	logger := cUtil.RetrieveLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cursor, err := referenceIndexCollection.Find(ctx, bson.M{}, options.Find().SetProjection(bson.D{{Key: "_id", Value: 0}}))
+	if err != nil {
+		logger.Error("Error retrieving reference index", slog.Any("err", err))
+		return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Could not get reference index."}
+	}
+	defer cursor.Close(ctx)
+
+	entries := make([]model.ReferenceIndexEntry, 0)
+	if err := cursor.All(ctx, &entries); err != nil {
+		logger.Error("Error retrieving reference index", slog.Any("err", err))
+		return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Could not get reference index."}
+	}
+	return entries, nil
+}
+
+// Upserts (using card ID) every entry then removes entries left over from previous snapshots - all entries should share the same builtAt.
+func (impl SKCSuggestionEngineDAOImplementation) ReplaceReferenceIndex(ctx context.Context, entries []model.ReferenceIndexEntry) *cModel.APIError {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	logger := cUtil.RetrieveLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	writes := make([]mongo.WriteModel, len(entries))
+	for i, entry := range entries {
+		writes[i] = mongo.NewReplaceOneModel().SetFilter(bson.M{"cardID": entry.CardID}).SetReplacement(entry).SetUpsert(true)
+	}
+
+	if _, err := referenceIndexCollection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		logger.Error("Error saving reference index", slog.Int("total", len(entries)), slog.Any("err", err))
+		return &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Error saving reference index."}
+	}
+
+	if res, err := referenceIndexCollection.DeleteMany(ctx, bson.M{"builtAt": bson.M{"$ne": entries[0].BuiltAt}}); err != nil {
+		logger.Error("Error removing stale reference index entries", slog.Any("err", err))
+		return &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Error saving reference index."}
+	} else {
+		logger.Info("Saved reference index", slog.Int("total", len(entries)), slog.Int64("stale_removed", res.DeletedCount))
+		return nil
+	}
+}
+
+// Cards viewed along with a resource. Nil is returned when the resource was never viewed with a card.
+func (impl SKCSuggestionEngineDAOImplementation) GetAlsoViewed(ctx context.Context, resourceName model.ResourceName, resourceID string) (*model.AlsoViewedEntry, *cModel.APIError) {
+	logger := cUtil.RetrieveLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	var entry model.AlsoViewedEntry
+	if err := alsoViewedCollection.FindOne(ctx, bson.M{"resourceName": resourceName, "resourceID": resourceID}).Decode(&entry); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		logger.Error("Error retrieving also viewed cards", slog.String("resource", string(resourceName)), slog.String("resource_id", resourceID), slog.Any("err", err))
+		return nil, &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Could not get also viewed cards."}
+	}
+	return &entry, nil
+}
+
+// Upserts (using resource name and ID) every entry then removes entries left over from previous builds - all entries should share the same builtAt.
+func (impl SKCSuggestionEngineDAOImplementation) ReplaceAlsoViewed(ctx context.Context, entries []model.AlsoViewedEntry) *cModel.APIError {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	logger := cUtil.RetrieveLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	writes := make([]mongo.WriteModel, len(entries))
+	for i, entry := range entries {
+		writes[i] = mongo.NewReplaceOneModel().SetFilter(bson.M{"resourceName": entry.ResourceName, "resourceID": entry.ResourceID}).
+			SetReplacement(entry).SetUpsert(true)
+	}
+
+	if _, err := alsoViewedCollection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		logger.Error("Error saving also viewed cards", slog.Int("total", len(entries)), slog.Any("err", err))
+		return &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Error saving also viewed cards."}
+	}
+
+	if res, err := alsoViewedCollection.DeleteMany(ctx, bson.M{"builtAt": bson.M{"$ne": entries[0].BuiltAt}}); err != nil {
+		logger.Error("Error removing stale also viewed entries", slog.Any("err", err))
+		return &cModel.APIError{StatusCode: http.StatusInternalServerError, Message: "Error saving also viewed cards."}
+	} else {
+		logger.Info("Saved also viewed cards", slog.Int("total", len(entries)), slog.Int64("stale_removed", res.DeletedCount))
+		return nil
+	}
+}
@@ -7,8 +7,6 @@ import (
 	"log/slog"
 	"net/http"
 
-	cModel "github.com/ygo-skc/skc-go/common/v3/model"
-
 	"github.com/ygo-skc/skc-go/common/v3/client"
 	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
 )
@@ -17,7 +15,19 @@ var (
 	YGO client.YGOClientImpV1
 )
 
+// Connects to ygo-service using YGO_SERVICE_HOST.
+// If YGO_SERVICE_FIXTURE is set, a local stand-in backed by card (and optionally YGO_SERVICE_PRODUCT_FIXTURE product) fixtures is used instead.
 func ConnectToYGOService() {
+	if cardFixture := cUtil.EnvMap["YGO_SERVICE_FIXTURE"]; cardFixture != "" {
+		if c, err := NewLocalYGOServiceClients(cardFixture, cUtil.EnvMap["YGO_SERVICE_PRODUCT_FIXTURE"]); err != nil {
+			log.Fatalf("Failed to load ygo-service fixtures: %v", err)
+		} else {
+			slog.Warn("Using local ygo-service stand-in", slog.String("card_fixture", cardFixture))
+			YGO = *c
+		}
+		return
+	}
+
 	if c, err := client.NewYGOServiceClients("ygo-service.skc.cards", cUtil.EnvMap["YGO_SERVICE_HOST"]); err != nil {
 		log.Fatalf("Failed to connect to ygo-service: %v", err)
 	} else {
@@ -25,19 +35,19 @@ func ConnectToYGOService() {
 	}
 }
 
-func parseResponseBody(ctx context.Context, resp *http.Response) ([]byte, *cModel.APIError) {
+func parseResponseBody(ctx context.Context, resp *http.Response) ([]byte, *ProviderError) {
 	logger := cUtil.RetrieveLogger(ctx)
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		logger.Error("Error reading downstream response body", slog.Any("err", err), slog.Any("url", resp.Request.URL))
-		return nil, &cModel.APIError{Message: "Error reading response from downstream service", StatusCode: http.StatusInternalServerError}
+		return nil, &ProviderError{Kind: ProviderUnavailable, StatusCode: resp.StatusCode, Endpoint: resp.Request.URL.String()}
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		logger.Error("Downstream service returned non-200 response", slog.Int("status", resp.StatusCode), slog.Any("url", resp.Request.URL), slog.String("body", string(body)))
-		return nil, &cModel.APIError{Message: "Downstream service returned an unexpected response", StatusCode: http.StatusInternalServerError}
+		return nil, newProviderError(resp)
 	}
 
 	return body, nil
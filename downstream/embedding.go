@@ -0,0 +1,118 @@
+package downstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+)
+
+const (
+	VoyageProvider           = "voyage"
+	OpenAICompatibleProvider = "openai"
+	LocalProvider            = "local"
+)
+
+// Converts text to vectors that can be used in vector search
+type Embedder interface {
+	EmbedText(context.Context, []string, model.VoyageInputType) (*model.EmbeddingResponse, *cModel.APIError)
+	EmbeddingModel() string
+}
+
+// Orders documents by their relevance to a query
+type Reranker interface {
+	RerankVectorResults(context.Context, []string, string, uint8) (*model.RerankResponse, *cModel.APIError)
+}
+
+// every supported provider can embed and rerank
+type embeddingProvider interface {
+	Embedder
+	Reranker
+}
+
+var (
+	EmbeddingClient Embedder = trackUsage(NewVoyageClient())
+	RerankClient    Reranker = trackRerankerHealth(trackUsage(NewVoyageClient()))
+)
+
+// Configures EmbeddingClient and RerankClient using EMBEDDING_PROVIDER and RERANK_PROVIDER env variables.
+// Supported providers are voyage (default), openai (any OpenAI compatible API) and local (deterministic hashing embedder - no network calls).
+func ConfigureEmbeddingProviders() {
+	EmbeddingClient = trackUsage(newProvider(cUtil.EnvMap["EMBEDDING_PROVIDER"]))
+	RerankClient = trackRerankerHealth(trackUsage(newProvider(cUtil.EnvMap["RERANK_PROVIDER"])))
+}
+
+func newProvider(provider string) embeddingProvider {
+	var p embeddingProvider
+	switch provider {
+	case "", VoyageProvider:
+		p = NewVoyageClient()
+	case OpenAICompatibleProvider:
+		p = NewOpenAICompatibleClient()
+	case LocalProvider:
+		p = NewHashingEmbedder()
+	default:
+		log.Fatalf("Unknown embedding provider: %s", provider)
+	}
+
+	slog.Info("Configured embedding provider", slog.String("provider", provider))
+	return p
+}
+
+func envOrDefault(key string, defaultValue string) string {
+	if value := cUtil.EnvMap[key]; value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// POSTs a JSON body to an embedding/rerank provider and decodes the JSON response.
+// Rate limits and provider outages are retried with backoff, see withRetry.
+func doProviderRequest[T any](ctx context.Context, httpClient *http.Client, endpoint *url.URL, apiKey string,
+	reqBody any, newErr func() *cModel.APIError) (*T, *cModel.APIError) {
+	logger := cUtil.RetrieveLogger(ctx)
+
+	payload, err := json.Marshal(reqBody)
+	if err != nil {
+		logger.Error("Error marshalling provider request", slog.Any("err", err), slog.String("url", endpoint.String()))
+		return nil, newErr()
+	}
+
+	body, providerErr := withRetry(ctx, endpoint.String(), func() ([]byte, *ProviderError) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewReader(payload))
+		if err != nil {
+			logger.Error("Error building provider request", slog.Any("err", err), slog.String("url", endpoint.String()))
+			return nil, &ProviderError{Kind: ProviderRequestFail, Endpoint: endpoint.String()}
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+
+		providerRes, err := httpClient.Do(req)
+		if err != nil {
+			logger.Error("Error calling provider API", slog.Any("err", err), slog.String("url", endpoint.String()))
+			return nil, &ProviderError{Kind: ProviderUnavailable, Endpoint: endpoint.String()}
+		}
+		return parseResponseBody(ctx, providerRes)
+	})
+	if providerErr != nil {
+		logger.Error("Provider request failed", slog.Any("err", providerErr))
+		return nil, providerErr.APIError()
+	}
+
+	var result T
+	if err := json.Unmarshal(body, &result); err != nil {
+		logger.Error("Error unmarshalling provider response", slog.Any("err", err), slog.String("url", endpoint.String()))
+		return nil, newErr()
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,102 @@
+package downstream
+
+import (
+	"cmp"
+	"context"
+	"hash/fnv"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+)
+
+const (
+	hashingEmbedderModel = "local-hashing"
+)
+
+// Deterministic Embedder and Reranker that uses the hashing trick on word unigrams and bigrams - no network calls are made.
+// Vectors are far less meaningful than those produced by a real model but identical text always produces identical vectors, making it useful for tests and offline development.
+type HashingEmbedder struct {
+	dimensions int
+}
+
+func NewHashingEmbedder() HashingEmbedder {
+	return HashingEmbedder{dimensions: voyageEmbeddingDimensions}
+}
+
+func (e HashingEmbedder) EmbeddingModel() string {
+	return hashingEmbedderModel
+}
+
+func (e HashingEmbedder) EmbedText(ctx context.Context, input []string, inputType model.VoyageInputType) (*model.EmbeddingResponse, *cModel.APIError) {
+	res := model.EmbeddingResponse{Object: "list", Model: hashingEmbedderModel, Data: make([]model.Data, len(input))}
+	for i, text := range input {
+		res.Data[i] = model.Data{Embedding: e.embed(text), Index: i}
+	}
+	return &res, nil
+}
+
+// Orders documents using cosine similarity between the hashed query and hashed documents
+func (e HashingEmbedder) RerankVectorResults(ctx context.Context, input []string, query string, topK uint8) (*model.RerankResponse, *cModel.APIError) {
+	queryVector := e.embed(query)
+
+	results := make([]model.RerankResults, len(input))
+	for i, doc := range input {
+		results[i] = model.RerankResults{Index: i, Score: dot(queryVector, e.embed(doc))}
+	}
+
+	slices.SortStableFunc(results, func(a, b model.RerankResults) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return &model.RerankResponse{Data: results[:min(int(topK), len(results))]}, nil
+}
+
+func (e HashingEmbedder) embed(text string) []float32 {
+	vector := make([]float32, e.dimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, word := range words {
+		e.addFeature(vector, word)
+		if i > 0 {
+			e.addFeature(vector, words[i-1]+" "+word)
+		}
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range vector {
+			vector[i] = float32(float64(vector[i]) / norm)
+		}
+	}
+	return vector
+}
+
+// the feature hash picks the dimension, a bit of the hash picks the sign - reduces bias from collisions
+func (e HashingEmbedder) addFeature(vector []float32, feature string) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+
+	if sum>>63 == 1 {
+		vector[sum%uint64(e.dimensions)]--
+	} else {
+		vector[sum%uint64(e.dimensions)]++
+	}
+}
+
+func dot(a []float32, b []float32) float64 {
+	var sum float64
+	for i := range min(len(a), len(b)) {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
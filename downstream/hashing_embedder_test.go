@@ -0,0 +1,38 @@
+package downstream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+	skc_testing "github.com/ygo-skc/skc-suggestion-engine/testing"
+)
+
+func TestHashingEmbedder(t *testing.T) {
+	assert := assert.New(t)
+	e := NewHashingEmbedder()
+
+	text := "Special Summon 1 Dark Magician from your GY."
+	res, _ := e.EmbedText(skc_testing.TestContext, []string{text, text, "Draw 2 cards."}, model.VoyageDocumentInput)
+
+	assert.Len(res.Data, 3)
+	assert.Len(res.Data[0].Embedding, voyageEmbeddingDimensions)
+	assert.Equal(res.Data[0].Embedding, res.Data[1].Embedding, "Same text should always produce the same vector")
+	assert.NotEqual(res.Data[0].Embedding, res.Data[2].Embedding)
+	assert.InDelta(1.0, dot(res.Data[0].Embedding, res.Data[0].Embedding), 1e-5, "Vectors should be normalized")
+}
+
+func TestHashingReranker(t *testing.T) {
+	assert := assert.New(t)
+	e := NewHashingEmbedder()
+
+	docs := []string{
+		"Draw 2 cards.",
+		"Special Summon 1 Dark Magician from your Deck.",
+		"Destroy all monsters on the field.",
+	}
+	res, _ := e.RerankVectorResults(skc_testing.TestContext, docs, "Special Summon 1 Dark Magician from your GY.", 2)
+
+	assert.Len(res.Data, 2, "Results should be limited to top K")
+	assert.Equal(1, res.Data[0].Index, "Most similar document should be ranked first")
+}
@@ -0,0 +1,135 @@
+package downstream
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+)
+
+const (
+	openAICompatibleEmbeddingsPath = "embeddings"
+	openAICompatibleRerankPath     = "rerank"
+)
+
+var (
+	openAICompatibleHTTPClient = &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			MaxIdleConns:          10,
+			MaxIdleConnsPerHost:   10,
+			IdleConnTimeout:       60 * time.Second,
+			TLSHandshakeTimeout:   2 * time.Second,
+			ResponseHeaderTimeout: 2 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+			ForceAttemptHTTP2:     true,
+		},
+	}
+)
+
+// Embedder and Reranker for any provider exposing an OpenAI compatible /embeddings endpoint.
+// Reranking uses the /rerank shape shared by most providers that offer it (Cohere, Jina, vLLM, etc).
+type OpenAICompatibleClient struct {
+	baseURL        *url.URL
+	apiKey         string
+	embeddingModel string
+	rerankModel    string
+	dimensions     int
+}
+
+// Uses OPENAI_COMPATIBLE_* env variables to configure the client. Base URL and embedding model are required.
+func NewOpenAICompatibleClient() OpenAICompatibleClient {
+	baseURL, err := url.Parse(cUtil.EnvMap["OPENAI_COMPATIBLE_BASE_URL"])
+	if err != nil || baseURL.Host == "" {
+		log.Fatalf("OPENAI_COMPATIBLE_BASE_URL is missing or invalid: %v", err)
+	}
+
+	c := OpenAICompatibleClient{
+		baseURL:        baseURL,
+		apiKey:         cUtil.EnvMap["OPENAI_COMPATIBLE_API_KEY"],
+		embeddingModel: cUtil.EnvMap["OPENAI_COMPATIBLE_EMBEDDING_MODEL"],
+		rerankModel:    cUtil.EnvMap["OPENAI_COMPATIBLE_RERANK_MODEL"],
+		dimensions:     voyageEmbeddingDimensions, // the vector index expects this many dimensions
+	}
+
+	if c.embeddingModel == "" {
+		log.Fatalln("OPENAI_COMPATIBLE_EMBEDDING_MODEL is required")
+	}
+	if dimensions := cUtil.EnvMap["OPENAI_COMPATIBLE_EMBEDDING_DIMENSIONS"]; dimensions != "" {
+		if c.dimensions, err = strconv.Atoi(dimensions); err != nil {
+			log.Fatalf("OPENAI_COMPATIBLE_EMBEDDING_DIMENSIONS is not a number: %v", err)
+		}
+	}
+
+	return c
+}
+
+func newOpenAICompatibleEmbeddingErr() *cModel.APIError {
+	return &cModel.APIError{Message: "Error occurred while generating embeddings", StatusCode: http.StatusInternalServerError}
+}
+
+func newOpenAICompatibleRerankErr() *cModel.APIError {
+	return &cModel.APIError{Message: "Error occurred while re-ranking", StatusCode: http.StatusInternalServerError}
+}
+
+func (c OpenAICompatibleClient) EmbeddingModel() string {
+	return c.embeddingModel
+}
+
+// OpenAI compatible APIs have no notion of query vs document input, as such inputType is ignored.
+func (c OpenAICompatibleClient) EmbedText(ctx context.Context, input []string, inputType model.VoyageInputType) (*model.EmbeddingResponse, *cModel.APIError) {
+	logger := cUtil.RetrieveLogger(ctx)
+	logger.Info("Calling OpenAI compatible API to embed text", slog.String("model", c.embeddingModel))
+
+	reqBody := model.OpenAIEmbeddingRequest{
+		Input:          input,
+		Model:          c.embeddingModel,
+		Dimensions:     c.dimensions,
+		EncodingFormat: "float",
+	}
+
+	result, apiErr := doProviderRequest[model.EmbeddingResponse](ctx, openAICompatibleHTTPClient,
+		c.baseURL.JoinPath(openAICompatibleEmbeddingsPath), c.apiKey, reqBody, newOpenAICompatibleEmbeddingErr)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if len(result.Data) != len(input) {
+		logger.Error("OpenAI compatible API returned incorrect number of embeddings", slog.Int("num_input", len(input)), slog.Int("num_embeddings", len(result.Data)))
+		return nil, newOpenAICompatibleEmbeddingErr()
+	}
+
+	return result, nil
+}
+
+func (c OpenAICompatibleClient) RerankVectorResults(ctx context.Context, input []string, query string, topK uint8) (*model.RerankResponse, *cModel.APIError) {
+	logger := cUtil.RetrieveLogger(ctx)
+	logger.Info("Calling OpenAI compatible API to rerank vector results", slog.String("model", c.rerankModel))
+
+	reqBody := model.CompatibleRerankRequest{
+		Query:     query,
+		Documents: input,
+		Model:     c.rerankModel,
+		TopN:      topK,
+	}
+
+	result, apiErr := doProviderRequest[model.CompatibleRerankResponse](ctx, openAICompatibleHTTPClient,
+		c.baseURL.JoinPath(openAICompatibleRerankPath), c.apiKey, reqBody, newOpenAICompatibleRerankErr)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if expectedSize := min(int(topK), len(input)); len(result.Results) != expectedSize {
+		logger.Error("OpenAI compatible API returned incorrect number of re-ranked elements", slog.Int("expected_size", int(topK)), slog.Int("actual", len(result.Results)))
+		return nil, newOpenAICompatibleRerankErr()
+	}
+
+	return &model.RerankResponse{Data: result.Results, Usage: result.Usage}, nil
+}
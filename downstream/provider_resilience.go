@@ -0,0 +1,217 @@
+package downstream
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
+)
+
+const (
+	providerMaxAttempts   = 3
+	providerMaxRetryAfter = 2 * time.Second // Retry-After values above this are not waited on - the request would outlive the server write timeout
+
+	breakerFailureThreshold = 5
+	breakerCooldown         = 30 * time.Second
+)
+
+var (
+	providerRetryBaseDelay = 200 * time.Millisecond
+	providerRetryMaxDelay  = 1 * time.Second
+)
+
+type ProviderErrorKind string
+
+const (
+	ProviderRateLimited ProviderErrorKind = "RATE_LIMITED" // 429 - retried
+	ProviderAuthFailure ProviderErrorKind = "AUTH_FAILURE" // 401/403 - API key is missing or invalid, not retried
+	ProviderBadInput    ProviderErrorKind = "BAD_INPUT"    // other 4xx - provider rejected the payload, not retried
+	ProviderUnavailable ProviderErrorKind = "UNAVAILABLE"  // 5xx, network errors or open circuit - retried (except when circuit is open)
+	ProviderRequestFail ProviderErrorKind = "REQUEST_FAIL" // request couldn't be built, provider was never called - not retried
+)
+
+// Typed error for embedding/rerank provider calls so callers (and logs) can tell a rate limit apart from a misconfigured key or bad payload
+type ProviderError struct {
+	Kind       ProviderErrorKind
+	StatusCode int           // status returned by the provider, 0 if no response was received
+	RetryAfter time.Duration // parsed from Retry-After header, 0 if not present
+	Endpoint   string
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("provider error calling %s: kind=%s status=%d", e.Endpoint, e.Kind, e.StatusCode)
+}
+
+func (e *ProviderError) retryable() bool {
+	return e.Kind == ProviderRateLimited || e.Kind == ProviderUnavailable
+}
+
+// Converts to the error returned by the API - each kind maps to a different status code.
+// Payloads are built by this server so a rejected payload is a downstream failure, not a client error.
+func (e *ProviderError) APIError() *cModel.APIError {
+	switch e.Kind {
+	case ProviderRateLimited:
+		return &cModel.APIError{Message: "Downstream provider is rate limiting requests, try again later", StatusCode: http.StatusTooManyRequests}
+	case ProviderAuthFailure:
+		return &cModel.APIError{Message: "Could not authenticate with downstream provider", StatusCode: http.StatusBadGateway}
+	case ProviderBadInput:
+		return &cModel.APIError{Message: "Downstream provider rejected the input", StatusCode: http.StatusBadGateway}
+	case ProviderRequestFail:
+		return &cModel.APIError{Message: "Could not build downstream provider request", StatusCode: http.StatusInternalServerError}
+	default:
+		return &cModel.APIError{Message: "Downstream provider is unavailable", StatusCode: http.StatusServiceUnavailable}
+	}
+}
+
+func newProviderError(resp *http.Response) *ProviderError {
+	e := &ProviderError{StatusCode: resp.StatusCode, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")), Endpoint: resp.Request.URL.String()}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		e.Kind = ProviderRateLimited
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		e.Kind = ProviderAuthFailure
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		e.Kind = ProviderBadInput
+	default:
+		e.Kind = ProviderUnavailable
+	}
+	return e
+}
+
+// Retry-After can either be a number of seconds or an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
+
+// full jitter exponential backoff - a random delay between 0 and base * 2^attempt (capped), Retry-After is used as the minimum when present
+func retryDelay(attempt int, retryAfter time.Duration) time.Duration {
+	backoff := min(providerRetryBaseDelay<<attempt, providerRetryMaxDelay)
+	delay := rand.N(backoff + 1)
+	return max(delay, retryAfter)
+}
+
+// Calls attempt until it succeeds, returns a non retryable error or providerMaxAttempts is reached.
+// Every attempt goes through the circuit breaker of the endpoint.
+func withRetry(ctx context.Context, endpoint string, attempt func() ([]byte, *ProviderError)) ([]byte, *ProviderError) {
+	logger := cUtil.RetrieveLogger(ctx)
+	breaker := breakerFor(endpoint)
+
+	var err *ProviderError
+	for i := range providerMaxAttempts {
+		if !breaker.allow() {
+			logger.Warn("Circuit breaker is open, skipping provider call", slog.String("url", endpoint))
+			return nil, &ProviderError{Kind: ProviderUnavailable, Endpoint: endpoint}
+		}
+
+		var body []byte
+		body, err = attempt()
+		breaker.record(err == nil || !err.retryable()) // bad input or auth issues don't mean the provider is unhealthy
+		if err == nil {
+			return body, nil
+		}
+
+		if !err.retryable() || i == providerMaxAttempts-1 {
+			break
+		}
+		if err.RetryAfter > providerMaxRetryAfter {
+			logger.Warn("Provider asked to wait longer than allowed, not retrying", slog.String("url", endpoint), slog.Duration("retry_after", err.RetryAfter))
+			break
+		}
+
+		delay := retryDelay(i, err.RetryAfter)
+		logger.Warn("Retrying provider call", slog.String("url", endpoint), slog.Int("attempt", i+1), slog.String("kind", string(err.Kind)),
+			slog.Int("status", err.StatusCode), slog.Duration("delay", delay))
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
+	}
+
+	return nil, err
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// Stops calling an endpoint after consecutive failures. Once breakerCooldown passes a single probe call is let through - its outcome closes or re-opens the circuit.
+type circuitBreaker struct {
+	mu                  sync.Mutex
+	state               breakerState
+	consecutiveFailures int
+	openedAt            time.Time
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = make(map[string]*circuitBreaker)
+)
+
+// one breaker per endpoint so a failing rerank endpoint doesn't stop embedding calls
+func breakerFor(endpoint string) *circuitBreaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+
+	if b, isPresent := breakers[endpoint]; isPresent {
+		return b
+	}
+	b := &circuitBreaker{}
+	breakers[endpoint] = b
+	return b
+}
+
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < breakerCooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		return false // probe is already in flight
+	default:
+		return true
+	}
+}
+
+func (b *circuitBreaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		b.state, b.consecutiveFailures = breakerClosed, 0
+		return
+	}
+
+	b.consecutiveFailures++
+	if b.state == breakerHalfOpen || b.consecutiveFailures >= breakerFailureThreshold {
+		b.state, b.openedAt = breakerOpen, time.Now()
+		slog.Warn("Circuit breaker opened", slog.Int("consecutive_failures", b.consecutiveFailures))
+	}
+}
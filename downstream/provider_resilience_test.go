@@ -0,0 +1,105 @@
+package downstream
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+	skc_testing "github.com/ygo-skc/skc-suggestion-engine/testing"
+)
+
+// responds using statuses in order, the last status is used for any extra calls
+func newProviderServer(t *testing.T, statuses ...int) (*url.URL, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := statuses[min(int(calls.Add(1))-1, len(statuses)-1)]
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "0")
+		}
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			w.Write([]byte(`{"data": [{"index": 0, "relevance_score": 0.9}]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	endpoint, _ := url.Parse(server.URL)
+	return endpoint.JoinPath(t.Name()), &calls // unique path so every test gets its own circuit breaker
+}
+
+func useShortRetryDelays(t *testing.T) {
+	base, maxDelay := providerRetryBaseDelay, providerRetryMaxDelay
+	providerRetryBaseDelay, providerRetryMaxDelay = time.Millisecond, time.Millisecond
+	t.Cleanup(func() { providerRetryBaseDelay, providerRetryMaxDelay = base, maxDelay })
+}
+
+func doTestProviderRequest(endpoint *url.URL) *cModel.APIError {
+	_, err := doProviderRequest[model.RerankResponse](skc_testing.TestContext, http.DefaultClient, endpoint, "", model.RerankRequest{}, newVoyageRerankErr)
+	return err
+}
+
+func TestProviderRetries(t *testing.T) {
+	assert := assert.New(t)
+	useShortRetryDelays(t)
+
+	endpoint, calls := newProviderServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK)
+	assert.Nil(doTestProviderRequest(endpoint))
+	assert.Equal(int32(3), calls.Load(), "Rate limits and outages should be retried")
+
+	endpoint, calls = newProviderServer(t, http.StatusBadGateway)
+	assert.Equal(http.StatusServiceUnavailable, doTestProviderRequest(endpoint).StatusCode)
+	assert.Equal(int32(providerMaxAttempts), calls.Load())
+
+	endpoint, calls = newProviderServer(t, http.StatusTooManyRequests)
+	assert.Equal(http.StatusTooManyRequests, doTestProviderRequest(endpoint).StatusCode)
+	assert.Equal(int32(providerMaxAttempts), calls.Load())
+}
+
+func TestProviderErrorsThatAreNotRetried(t *testing.T) {
+	assert := assert.New(t)
+
+	endpoint, calls := newProviderServer(t, http.StatusUnauthorized)
+	assert.Equal(http.StatusBadGateway, doTestProviderRequest(endpoint).StatusCode)
+	assert.Equal(int32(1), calls.Load(), "Auth failures should not be retried")
+
+	endpoint, calls = newProviderServer(t, http.StatusUnprocessableEntity)
+	assert.Equal(http.StatusBadGateway, doTestProviderRequest(endpoint).StatusCode, "Payloads are built by the server - a rejection is not a client error")
+	assert.Equal(int32(1), calls.Load(), "Bad input should not be retried")
+
+	assert.Equal(http.StatusInternalServerError, (&ProviderError{Kind: ProviderRequestFail}).APIError().StatusCode)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	assert := assert.New(t)
+	useShortRetryDelays(t)
+
+	endpoint, calls := newProviderServer(t, http.StatusInternalServerError)
+	for range breakerFailureThreshold {
+		doTestProviderRequest(endpoint)
+	}
+	assert.Equal(int32(breakerFailureThreshold), calls.Load(), "Circuit should open once threshold is reached")
+
+	assert.Equal(http.StatusServiceUnavailable, doTestProviderRequest(endpoint).StatusCode)
+	assert.Equal(int32(breakerFailureThreshold), calls.Load(), "Open circuit should not call the provider")
+
+	// cooldown passed - the probe fails so the circuit opens again
+	breakerFor(endpoint.String()).openedAt = time.Now().Add(-breakerCooldown)
+	doTestProviderRequest(endpoint)
+	assert.Equal(int32(breakerFailureThreshold+1), calls.Load(), "Only a single probe should be let through")
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(3*time.Second, parseRetryAfter("3"))
+	assert.Equal(time.Duration(0), parseRetryAfter(""))
+	assert.Equal(time.Duration(0), parseRetryAfter("soon"))
+	assert.InDelta(float64(10*time.Second), float64(parseRetryAfter(time.Now().Add(10*time.Second).UTC().Format(http.TimeFormat))), float64(time.Second))
+	assert.GreaterOrEqual(retryDelay(0, 500*time.Millisecond), 500*time.Millisecond, "Retry-After should be honored")
+}
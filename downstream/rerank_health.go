@@ -0,0 +1,66 @@
+package downstream
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+)
+
+const (
+	rerankerDownThreshold = 3
+)
+
+// Reranker health is determined using the outcome of recent calls instead of pinging the provider - rerank calls are billed.
+type RerankerHealth struct {
+	Status              cModel.DownstreamStatus
+	ConsecutiveFailures int
+	LastSuccess         time.Time
+	LastFailure         time.Time
+}
+
+// records the outcome of every call made to the wrapped Reranker
+type healthTrackingReranker struct {
+	Reranker
+}
+
+var (
+	rerankerHealthMu sync.Mutex
+	rerankerHealth   = RerankerHealth{Status: cModel.Up}
+)
+
+func trackRerankerHealth(r Reranker) Reranker {
+	return healthTrackingReranker{Reranker: r}
+}
+
+func (r healthTrackingReranker) RerankVectorResults(ctx context.Context, input []string, query string, topK uint8) (*model.RerankResponse, *cModel.APIError) {
+	res, err := r.Reranker.RerankVectorResults(ctx, input, query, topK)
+	recordRerankOutcome(err == nil)
+	return res, err
+}
+
+func recordRerankOutcome(success bool) {
+	rerankerHealthMu.Lock()
+	defer rerankerHealthMu.Unlock()
+
+	if success {
+		rerankerHealth.ConsecutiveFailures, rerankerHealth.LastSuccess = 0, time.Now()
+	} else {
+		rerankerHealth.ConsecutiveFailures, rerankerHealth.LastFailure = rerankerHealth.ConsecutiveFailures+1, time.Now()
+	}
+
+	if rerankerHealth.ConsecutiveFailures >= rerankerDownThreshold {
+		rerankerHealth.Status = cModel.Down
+	} else {
+		rerankerHealth.Status = cModel.Up
+	}
+}
+
+// Health of RerankClient based on the most recent calls - the reranker is considered down after several consecutive failures
+func GetRerankerHealth() RerankerHealth {
+	rerankerHealthMu.Lock()
+	defer rerankerHealthMu.Unlock()
+	return rerankerHealth
+}
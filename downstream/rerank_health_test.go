@@ -0,0 +1,38 @@
+package downstream
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+	skc_testing "github.com/ygo-skc/skc-suggestion-engine/testing"
+)
+
+type failingReranker struct{}
+
+func (r failingReranker) RerankVectorResults(ctx context.Context, input []string, query string, topK uint8) (*model.RerankResponse, *cModel.APIError) {
+	return nil, &cModel.APIError{Message: "Error occurred while re-ranking", StatusCode: http.StatusInternalServerError}
+}
+
+func TestRerankerHealth(t *testing.T) {
+	assert := assert.New(t)
+	healthy, failing := trackRerankerHealth(NewHashingEmbedder()), trackRerankerHealth(failingReranker{})
+
+	healthy.RerankVectorResults(skc_testing.TestContext, []string{"Draw 2 cards."}, "Draw", 1)
+	assert.Equal(cModel.Up, GetRerankerHealth().Status)
+
+	for range rerankerDownThreshold - 1 {
+		failing.RerankVectorResults(skc_testing.TestContext, []string{"Draw 2 cards."}, "Draw", 1)
+	}
+	assert.Equal(cModel.Up, GetRerankerHealth().Status, "A few failures should not mark the reranker as down")
+
+	failing.RerankVectorResults(skc_testing.TestContext, []string{"Draw 2 cards."}, "Draw", 1)
+	assert.Equal(cModel.Down, GetRerankerHealth().Status)
+	assert.Equal(rerankerDownThreshold, GetRerankerHealth().ConsecutiveFailures)
+
+	healthy.RerankVectorResults(skc_testing.TestContext, []string{"Draw 2 cards."}, "Draw", 1)
+	assert.Equal(cModel.Up, GetRerankerHealth().Status, "A successful call should mark the reranker as up")
+}
@@ -0,0 +1,34 @@
+package downstream
+
+import (
+	"context"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+	"github.com/ygo-skc/skc-suggestion-engine/usage"
+)
+
+// records tokens used by every successful call made to the wrapped provider
+type usageTrackingProvider struct {
+	embeddingProvider
+}
+
+func trackUsage(p embeddingProvider) embeddingProvider {
+	return usageTrackingProvider{embeddingProvider: p}
+}
+
+func (p usageTrackingProvider) EmbedText(ctx context.Context, input []string, inputType model.VoyageInputType) (*model.EmbeddingResponse, *cModel.APIError) {
+	res, err := p.embeddingProvider.EmbedText(ctx, input, inputType)
+	if err == nil {
+		usage.DailyTracker.Record(ctx, model.EmbeddingUsage, res.Usage.TotalTokens)
+	}
+	return res, err
+}
+
+func (p usageTrackingProvider) RerankVectorResults(ctx context.Context, input []string, query string, topK uint8) (*model.RerankResponse, *cModel.APIError) {
+	res, err := p.embeddingProvider.RerankVectorResults(ctx, input, query, topK)
+	if err == nil {
+		usage.DailyTracker.Record(ctx, model.RerankUsage, res.Usage.TotalTokens)
+	}
+	return res, err
+}
@@ -1,10 +1,7 @@
 package downstream
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
@@ -19,9 +16,9 @@ const (
 	voyageEmbeddingsPath = "embeddings"
 	voyageRerankPath     = "rerank"
 
-	voyageEmbeddingModel      = "voyage-4"
-	voyageRerankModel         = "rerank-2.5"
-	voyageEmbeddingDimensions = 512
+	defaultVoyageEmbeddingModel = "voyage-4"
+	defaultVoyageRerankModel    = "rerank-2.5"
+	voyageEmbeddingDimensions   = 512
 )
 
 var (
@@ -41,6 +38,20 @@ var (
 	}
 )
 
+// Embedder and Reranker backed by Voyage AI
+type VoyageClient struct {
+	embeddingModel string
+	rerankModel    string
+}
+
+// Uses VOYAGE_EMBEDDING_MODEL and VOYAGE_RERANK_MODEL to determine which models to use, falling back to defaults when not set.
+func NewVoyageClient() VoyageClient {
+	return VoyageClient{
+		embeddingModel: envOrDefault("VOYAGE_EMBEDDING_MODEL", defaultVoyageEmbeddingModel),
+		rerankModel:    envOrDefault("VOYAGE_RERANK_MODEL", defaultVoyageRerankModel),
+	}
+}
+
 func newVoyageEmbeddingErr() *cModel.APIError {
 	return &cModel.APIError{Message: "Error occurred while generating embeddings", StatusCode: http.StatusInternalServerError}
 }
@@ -49,66 +60,23 @@ func newVoyageRerankErr() *cModel.APIError {
 	return &cModel.APIError{Message: "Error occurred while re-ranking", StatusCode: http.StatusInternalServerError}
 }
 
-func newVoyageRequest(ctx context.Context, method string, path string, body io.Reader) (*http.Request, *cModel.APIError) {
-	logger := cUtil.RetrieveLogger(ctx)
-
-	req, err := http.NewRequestWithContext(ctx, method, voyageBaseURL.JoinPath(path).String(), body)
-	if err != nil {
-		logger.Error("Error building Voyage request", slog.Any("err", err), slog.String("path", path))
-		return nil, &cModel.APIError{Message: "Error calling downstream service", StatusCode: http.StatusInternalServerError}
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+cUtil.EnvMap["VOYAGE_API_KEY"])
-	return req, nil
-}
-
-func doVoyageRequest[T any](ctx context.Context, path string, reqBody any, newErr func() *cModel.APIError) (*T, *cModel.APIError) {
-	logger := cUtil.RetrieveLogger(ctx)
-
-	payload, err := json.Marshal(reqBody)
-	if err != nil {
-		logger.Error("Error marshalling Voyage request", slog.Any("err", err), slog.String("path", path))
-		return nil, newErr()
-	}
-
-	req, apiErr := newVoyageRequest(ctx, http.MethodPost, path, bytes.NewReader(payload))
-	if apiErr != nil {
-		return nil, apiErr
-	}
-
-	voyageRes, err := voyageHTTPClient.Do(req)
-	if err != nil {
-		logger.Error("Error calling Voyage API", slog.Any("err", err), slog.String("path", path))
-		return nil, newErr()
-	}
-
-	body, apiErr := parseResponseBody(ctx, voyageRes)
-	if apiErr != nil {
-		return nil, apiErr
-	}
-
-	var result T
-	if err := json.Unmarshal(body, &result); err != nil {
-		logger.Error("Error unmarshalling Voyage response", slog.Any("err", err), slog.String("path", path))
-		return nil, newErr()
-	}
-
-	return &result, nil
+func (c VoyageClient) EmbeddingModel() string {
+	return c.embeddingModel
 }
 
-func EmbedText(ctx context.Context, input []string, inputType model.VoyageInputType) (*model.EmbeddingResponse, *cModel.APIError) {
+func (c VoyageClient) EmbedText(ctx context.Context, input []string, inputType model.VoyageInputType) (*model.EmbeddingResponse, *cModel.APIError) {
 	logger := cUtil.RetrieveLogger(ctx)
 	logger.Info("Calling Voyage API to embed text")
 
 	reqBody := model.EmbeddingRequest{
 		Input:           input,
-		Model:           voyageEmbeddingModel,
+		Model:           c.embeddingModel,
 		InputType:       inputType,
 		OutputDimension: voyageEmbeddingDimensions,
 	}
 
-	result, apiErr := doVoyageRequest[model.EmbeddingResponse](ctx, voyageEmbeddingsPath, reqBody, newVoyageEmbeddingErr)
+	result, apiErr := doProviderRequest[model.EmbeddingResponse](ctx, voyageHTTPClient,
+		voyageBaseURL.JoinPath(voyageEmbeddingsPath), cUtil.EnvMap["VOYAGE_API_KEY"], reqBody, newVoyageEmbeddingErr)
 	if apiErr != nil {
 		return nil, apiErr
 	}
@@ -121,18 +89,19 @@ func EmbedText(ctx context.Context, input []string, inputType model.VoyageInputT
 	return result, nil
 }
 
-func RerankVectorResults(ctx context.Context, input []string, query string, topK uint8) (*model.RerankResponse, *cModel.APIError) {
+func (c VoyageClient) RerankVectorResults(ctx context.Context, input []string, query string, topK uint8) (*model.RerankResponse, *cModel.APIError) {
 	logger := cUtil.RetrieveLogger(ctx)
 	logger.Info("Calling Voyage API to rerank vector results")
 
 	reqBody := model.RerankRequest{
 		Query:     query,
 		Documents: input,
-		Model:     voyageRerankModel,
+		Model:     c.rerankModel,
 		TopK:      topK,
 	}
 
-	result, apiErr := doVoyageRequest[model.RerankResponse](ctx, voyageRerankPath, reqBody, newVoyageRerankErr)
+	result, apiErr := doProviderRequest[model.RerankResponse](ctx, voyageHTTPClient,
+		voyageBaseURL.JoinPath(voyageRerankPath), cUtil.EnvMap["VOYAGE_API_KEY"], reqBody, newVoyageRerankErr)
 	if apiErr != nil {
 		return nil, apiErr
 	}
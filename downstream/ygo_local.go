@@ -0,0 +1,409 @@
+package downstream
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ygo-skc/skc-go/common/v3/client"
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	"github.com/ygo-skc/skc-go/common/v3/ygo"
+)
+
+const (
+	localYGOServiceVersion = "local"
+)
+
+var (
+	localCardColors = map[string]uint32{
+		"Normal":           1,
+		"Effect":           2,
+		"Fusion":           3,
+		"Ritual":           4,
+		"Synchro":          5,
+		"Xyz":              6,
+		"Pendulum-Normal":  7,
+		"Pendulum-Effect":  8,
+		"Pendulum-Ritual":  9,
+		"Pendulum-Fusion":  10,
+		"Pendulum-Xyz":     11,
+		"Pendulum-Synchro": 12,
+		"Link":             13,
+		"Spell":            14,
+		"Trap":             15,
+		"Token":            16,
+	}
+
+	alwaysTreatedAsRegex = regexp.MustCompile(`\(This card is (?:also )?always treated as [^)]*\)`)
+	notTreatedAsRegex    = regexp.MustCompile(`\(This card is not treated as [^)]*\)`)
+)
+
+// product as it is found in the product fixture - contents reference cards from the card fixture by ID
+type localProduct struct {
+	ID          string   `json:"productId"`
+	Locale      string   `json:"productLocale"`
+	Name        string   `json:"productName"`
+	Type        string   `json:"productType"`
+	SubType     string   `json:"productSubType"`
+	ReleaseDate string   `json:"productReleaseDate"`
+	CardIDs     []string `json:"cardIDs"`
+}
+
+// card and product data shared by all local services - read only once loaded
+type localYGOData struct {
+	cardsByID   map[string]cModel.YGOCardREST
+	cardsByName map[string]cModel.YGOCardREST
+	cardIDs     []string // sorted, used to keep results deterministic
+	products    map[string]localProduct
+}
+
+// Builds ygo-service clients that answer every call using card/product fixture files instead of the real gRPC service.
+// Fixtures can either be a JSON array or JSONL (one object per line). Cards use the same JSON shape as the SKC API, products reference cards by ID.
+func NewLocalYGOServiceClients(cardFixture string, productFixture string) (*client.YGOClientImpV1, error) {
+	data := &localYGOData{
+		cardsByID:   make(map[string]cModel.YGOCardREST),
+		cardsByName: make(map[string]cModel.YGOCardREST),
+		products:    make(map[string]localProduct),
+	}
+
+	cards, err := readFixture[cModel.YGOCardREST](cardFixture)
+	if err != nil {
+		return nil, err
+	}
+	for _, card := range cards {
+		data.cardsByID[card.ID] = card
+		data.cardsByName[card.Name] = card
+		data.cardIDs = append(data.cardIDs, card.ID)
+	}
+	slices.Sort(data.cardIDs)
+
+	if productFixture != "" {
+		products, err := readFixture[localProduct](productFixture)
+		if err != nil {
+			return nil, err
+		}
+		for _, product := range products {
+			data.products[product.ID] = product
+		}
+	}
+
+	return &client.YGOClientImpV1{
+		CardService:    LocalCardService{data: data},
+		ProductService: LocalProductService{data: data},
+		HealthService:  LocalHealthService{},
+	}, nil
+}
+
+func readFixture[T any](path string) ([]T, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading fixture %s: %w", path, err)
+	}
+
+	contents = bytes.TrimSpace(contents)
+	if len(contents) > 0 && contents[0] == '[' {
+		var values []T
+		if err := json.Unmarshal(contents, &values); err != nil {
+			return nil, fmt.Errorf("error parsing fixture %s: %w", path, err)
+		}
+		return values, nil
+	}
+
+	values := make([]T, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var value T
+		if err := json.Unmarshal(scanner.Bytes(), &value); err != nil {
+			return nil, fmt.Errorf("error parsing line %d of fixture %s: %w", line, path, err)
+		}
+		values = append(values, value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading fixture %s: %w", path, err)
+	}
+	return values, nil
+}
+
+func newLocalCardNotFoundErr(cardID string) *cModel.APIError {
+	return &cModel.APIError{Message: fmt.Sprintf("No results found in DB for card ID %s", cardID), StatusCode: http.StatusNotFound}
+}
+
+// filters cards in the fixture, results are ordered by card ID
+func (data *localYGOData) filterCards(include func(cModel.YGOCardREST) bool) []cModel.YGOCardREST {
+	cards := make([]cModel.YGOCardREST, 0)
+	for _, cardID := range data.cardIDs {
+		if card := data.cardsByID[cardID]; include(card) {
+			cards = append(cards, card)
+		}
+	}
+	return cards
+}
+
+func cardsToCardListProto(cards []cModel.YGOCardREST) *ygo.CardList {
+	cardList := &ygo.CardList{Cards: make([]*ygo.Card, len(cards))}
+	for i, card := range cards {
+		cardList.Cards[i] = card.ToProto()
+	}
+	return cardList
+}
+
+func cardsToYGOCards(cards []cModel.YGOCardREST) []cModel.YGOCard {
+	ygoCards := make([]cModel.YGOCard, len(cards))
+	for i, card := range cards {
+		ygoCards[i] = card
+	}
+	return ygoCards
+}
+
+// checks whether one of the "(This card is ... treated as ...)" clauses in text names the archetype
+func clauseReferencesArchetype(clauseRegex *regexp.Regexp, text string, archetype string) bool {
+	for _, clause := range clauseRegex.FindAllString(text, -1) {
+		if strings.Contains(clause, `"`+archetype+`"`) || strings.Contains(clause, `'`+archetype+`'`) {
+			return true
+		}
+	}
+	return false
+}
+
+// card service
+type LocalCardService struct {
+	data *localYGOData
+}
+
+func (svc LocalCardService) GetCardColorsProto(ctx context.Context) (*ygo.CardColors, *cModel.APIError) {
+	return &ygo.CardColors{Values: localCardColors}, nil
+}
+
+func (svc LocalCardService) GetCardByIDProto(ctx context.Context, cardID string) (*ygo.Card, *cModel.APIError) {
+	if card, isPresent := svc.data.cardsByID[cardID]; isPresent {
+		return card.ToProto(), nil
+	}
+	return nil, newLocalCardNotFoundErr(cardID)
+}
+
+func (svc LocalCardService) GetCardByID(ctx context.Context, cardID string) (*cModel.YGOCard, *cModel.APIError) {
+	if card, isPresent := svc.data.cardsByID[cardID]; isPresent {
+		var ygoCard cModel.YGOCard = card
+		return &ygoCard, nil
+	}
+	return nil, newLocalCardNotFoundErr(cardID)
+}
+
+func (svc LocalCardService) GetCardsByIDProto(ctx context.Context, cardIDs cModel.CardIDs) (*ygo.Cards, *cModel.APIError) {
+	found, notFound := make(map[string]*ygo.Card, len(cardIDs)), make(cModel.CardIDs, 0)
+	for _, cardID := range cardIDs {
+		if card, isPresent := svc.data.cardsByID[cardID]; isPresent {
+			found[card.ID] = card.ToProto()
+		} else {
+			notFound = append(notFound, cardID)
+		}
+	}
+	return &ygo.Cards{CardInfo: found, UnknownResources: notFound}, nil
+}
+
+func (svc LocalCardService) GetCardsByID(ctx context.Context, cardIDs cModel.CardIDs) (*cModel.BatchCardData[cModel.CardIDs], *cModel.APIError) {
+	found, notFound := make(cModel.CardDataMap, len(cardIDs)), make(cModel.CardIDs, 0)
+	for _, cardID := range cardIDs {
+		if card, isPresent := svc.data.cardsByID[cardID]; isPresent {
+			found[card.ID] = card
+		} else {
+			notFound = append(notFound, cardID)
+		}
+	}
+	return &cModel.BatchCardData[cModel.CardIDs]{CardInfo: found, UnknownResources: notFound}, nil
+}
+
+func (svc LocalCardService) GetCardsByNameProto(ctx context.Context, cardNames cModel.CardNames) (*ygo.Cards, *cModel.APIError) {
+	found, notFound := make(map[string]*ygo.Card, len(cardNames)), make(cModel.CardNames, 0)
+	for _, cardName := range cardNames {
+		if card, isPresent := svc.data.cardsByName[cardName]; isPresent {
+			found[card.Name] = card.ToProto()
+		} else {
+			notFound = append(notFound, cardName)
+		}
+	}
+	return &ygo.Cards{CardInfo: found, UnknownResources: notFound}, nil
+}
+
+func (svc LocalCardService) GetCardsByName(ctx context.Context, cardNames cModel.CardNames) (*cModel.BatchCardData[cModel.CardNames], *cModel.APIError) {
+	found, notFound := make(cModel.CardDataMap, len(cardNames)), make(cModel.CardNames, 0)
+	for _, cardName := range cardNames {
+		if card, isPresent := svc.data.cardsByName[cardName]; isPresent {
+			found[card.Name] = card
+		} else {
+			notFound = append(notFound, cardName)
+		}
+	}
+	return &cModel.BatchCardData[cModel.CardNames]{CardInfo: found, UnknownResources: notFound}, nil
+}
+
+func (svc LocalCardService) cardsReferencingNameInEffect(cardNames []string) []cModel.YGOCardREST {
+	return svc.data.filterCards(func(card cModel.YGOCardREST) bool {
+		return slices.ContainsFunc(cardNames, func(cardName string) bool {
+			return card.Name != cardName && strings.Contains(card.Effect, cardName)
+		})
+	})
+}
+
+func (svc LocalCardService) GetCardsReferencingNameInEffectProto(ctx context.Context, cardNames []string) (*ygo.CardList, *cModel.APIError) {
+	return cardsToCardListProto(svc.cardsReferencingNameInEffect(cardNames)), nil
+}
+
+func (svc LocalCardService) GetCardsReferencingNameInEffect(ctx context.Context, cardNames []string) ([]cModel.YGOCard, *cModel.APIError) {
+	return cardsToYGOCards(svc.cardsReferencingNameInEffect(cardNames)), nil
+}
+
+func (svc LocalCardService) archetypalCardsUsingCardName(archetype string) []cModel.YGOCardREST {
+	return svc.data.filterCards(func(card cModel.YGOCardREST) bool {
+		return strings.Contains(card.Name, archetype)
+	})
+}
+
+func (svc LocalCardService) GetArchetypalCardsUsingCardNameProto(ctx context.Context, archetype string) (*ygo.CardList, *cModel.APIError) {
+	return cardsToCardListProto(svc.archetypalCardsUsingCardName(archetype)), nil
+}
+
+func (svc LocalCardService) GetArchetypalCardsUsingCardName(ctx context.Context, archetype string) ([]cModel.YGOCard, *cModel.APIError) {
+	return cardsToYGOCards(svc.archetypalCardsUsingCardName(archetype)), nil
+}
+
+func (svc LocalCardService) explicitArchetypalInclusions(archetype string) []cModel.YGOCardREST {
+	return svc.data.filterCards(func(card cModel.YGOCardREST) bool {
+		return clauseReferencesArchetype(alwaysTreatedAsRegex, card.Effect, archetype)
+	})
+}
+
+func (svc LocalCardService) GetExplicitArchetypalInclusionsProto(ctx context.Context, archetype string) (*ygo.CardList, *cModel.APIError) {
+	return cardsToCardListProto(svc.explicitArchetypalInclusions(archetype)), nil
+}
+
+func (svc LocalCardService) GetExplicitArchetypalInclusions(ctx context.Context, archetype string) ([]cModel.YGOCard, *cModel.APIError) {
+	return cardsToYGOCards(svc.explicitArchetypalInclusions(archetype)), nil
+}
+
+func (svc LocalCardService) explicitArchetypalExclusions(archetype string) []cModel.YGOCardREST {
+	return svc.data.filterCards(func(card cModel.YGOCardREST) bool {
+		return clauseReferencesArchetype(notTreatedAsRegex, card.Effect, archetype)
+	})
+}
+
+func (svc LocalCardService) GetExplicitArchetypalExclusionsProto(ctx context.Context, archetype string) (*ygo.CardList, *cModel.APIError) {
+	return cardsToCardListProto(svc.explicitArchetypalExclusions(archetype)), nil
+}
+
+func (svc LocalCardService) GetExplicitArchetypalExclusions(ctx context.Context, archetype string) ([]cModel.YGOCard, *cModel.APIError) {
+	return cardsToYGOCards(svc.explicitArchetypalExclusions(archetype)), nil
+}
+
+func (svc LocalCardService) randomCard(blackListedIDs []string) (*cModel.YGOCardREST, *cModel.APIError) {
+	candidates := svc.data.filterCards(func(card cModel.YGOCardREST) bool {
+		return !slices.Contains(blackListedIDs, card.ID)
+	})
+
+	if len(candidates) == 0 {
+		return nil, &cModel.APIError{Message: "No cards available to choose from", StatusCode: http.StatusNotFound}
+	}
+	return &candidates[rand.IntN(len(candidates))], nil
+}
+
+func (svc LocalCardService) GetRandomCardProto(ctx context.Context, blackListedIDs []string) (*ygo.Card, *cModel.APIError) {
+	card, err := svc.randomCard(blackListedIDs)
+	if err != nil {
+		return nil, err
+	}
+	return card.ToProto(), nil
+}
+
+func (svc LocalCardService) GetRandomCard(ctx context.Context, blackListedIDs []string) (*cModel.YGOCard, *cModel.APIError) {
+	card, err := svc.randomCard(blackListedIDs)
+	if err != nil {
+		return nil, err
+	}
+	var ygoCard cModel.YGOCard = *card
+	return &ygoCard, nil
+}
+
+// product service
+type LocalProductService struct {
+	data *localYGOData
+}
+
+func newLocalProductNotFoundErr(productID string) *cModel.APIError {
+	return &cModel.APIError{Message: fmt.Sprintf("No results found in DB for product ID %s", productID), StatusCode: http.StatusNotFound}
+}
+
+func (product localProduct) toSummaryProto() *ygo.ProductSummary {
+	return &ygo.ProductSummary{
+		ID:          product.ID,
+		Locale:      product.Locale,
+		Name:        product.Name,
+		Type:        product.Type,
+		SubType:     product.SubType,
+		ReleaseDate: product.ReleaseDate,
+		TotalItems:  uint32(len(product.CardIDs)),
+	}
+}
+
+func (svc LocalProductService) GetCardsByProductIDProto(ctx context.Context, productID string) (*ygo.Product, *cModel.APIError) {
+	product, isPresent := svc.data.products[productID]
+	if !isPresent {
+		return nil, newLocalProductNotFoundErr(productID)
+	}
+
+	items := make([]*ygo.ProductItem, 0, len(product.CardIDs))
+	for position, cardID := range product.CardIDs {
+		if card, isPresent := svc.data.cardsByID[cardID]; isPresent {
+			items = append(items, &ygo.ProductItem{Card: card.ToProto(), Position: fmt.Sprintf("%03d", position+1)})
+		}
+	}
+
+	return &ygo.Product{
+		ID:          product.ID,
+		Locale:      product.Locale,
+		Name:        product.Name,
+		Type:        product.Type,
+		SubType:     product.SubType,
+		ReleaseDate: product.ReleaseDate,
+		TotalItems:  uint32(len(items)),
+		Items:       items,
+	}, nil
+}
+
+func (svc LocalProductService) GetProductSummaryByIDProto(ctx context.Context, productID string) (*ygo.ProductSummary, *cModel.APIError) {
+	if product, isPresent := svc.data.products[productID]; isPresent {
+		return product.toSummaryProto(), nil
+	}
+	return nil, newLocalProductNotFoundErr(productID)
+}
+
+func (svc LocalProductService) GetProductsSummaryByIDProto(ctx context.Context, productIDs cModel.ProductIDs) (*ygo.Products, *cModel.APIError) {
+	found, notFound := make(map[string]*ygo.ProductSummary, len(productIDs)), make([]string, 0)
+	for _, productID := range productIDs {
+		if product, isPresent := svc.data.products[productID]; isPresent {
+			found[product.ID] = product.toSummaryProto()
+		} else {
+			notFound = append(notFound, productID)
+		}
+	}
+	return &ygo.Products{Products: found, UnknownResources: notFound}, nil
+}
+
+// health service
+type LocalHealthService struct{}
+
+func (svc LocalHealthService) GetAPIStatus(ctx context.Context) (*ygo.APIStatus, *cModel.APIError) {
+	return &ygo.APIStatus{Version: localYGOServiceVersion}, nil
+}
@@ -0,0 +1,65 @@
+package downstream
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	skc_testing "github.com/ygo-skc/skc-suggestion-engine/testing"
+)
+
+const (
+	localCardFixture = `{"cardID":"46986414","cardColor":"Normal","cardName":"Dark Magician","cardAttribute":"DARK","cardEffect":"The ultimate wizard in terms of attack and defense."}
+{"cardID":"97631303","cardColor":"Effect","cardName":"Magicians' Souls","cardAttribute":"DARK","cardEffect":"Send this card to the GY, then, you can Special Summon 1 \"Dark Magician\" from your GY."}
+
+{"cardID":"38033121","cardColor":"Effect","cardName":"Dark Magician Girl","cardAttribute":"DARK","cardEffect":"Gains 300 ATK for every \"Dark Magician\" or \"Magician of Black Chaos\" in the GY."}
+{"cardID":"71703785","cardColor":"Effect","cardName":"Performapal Skullcrobat Joker","cardAttribute":"DARK","cardEffect":"(This card is always treated as a \"Magician\" card.)\nWhen this card is Normal Summoned: You can add 1 \"Performapal\" monster from your Deck to your hand."}
+`
+)
+
+func newLocalCardServiceFromFixture(t *testing.T) LocalCardService {
+	fixture := filepath.Join(t.TempDir(), "cards.jsonl")
+	if err := os.WriteFile(fixture, []byte(localCardFixture), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewLocalYGOServiceClients(fixture, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.CardService.(LocalCardService)
+}
+
+func cardNames(cards []cModel.YGOCard) []string {
+	names := make([]string, len(cards))
+	for i, card := range cards {
+		names[i] = card.GetName()
+	}
+	return names
+}
+
+func TestLocalCardService(t *testing.T) {
+	assert := assert.New(t)
+	svc := newLocalCardServiceFromFixture(t)
+
+	batch, _ := svc.GetCardsByName(skc_testing.TestContext, cModel.CardNames{"Dark Magician", "Blue-Eyes White Dragon"})
+	assert.Len(batch.CardInfo, 1)
+	assert.Equal(cModel.CardNames{"Blue-Eyes White Dragon"}, batch.UnknownResources)
+
+	references, _ := svc.GetCardsReferencingNameInEffect(skc_testing.TestContext, []string{"Dark Magician"})
+	assert.Equal([]string{"Dark Magician Girl", "Magicians' Souls"}, cardNames(references), "Cards should not reference themselves and results should be ordered by ID")
+
+	usingName, _ := svc.GetArchetypalCardsUsingCardName(skc_testing.TestContext, "Magician")
+	assert.Equal([]string{"Dark Magician Girl", "Dark Magician", "Magicians' Souls"}, cardNames(usingName))
+
+	inclusions, _ := svc.GetExplicitArchetypalInclusions(skc_testing.TestContext, "Magician")
+	assert.Equal([]string{"Performapal Skullcrobat Joker"}, cardNames(inclusions))
+
+	random, _ := svc.GetRandomCard(skc_testing.TestContext, []string{"46986414", "97631303", "38033121"})
+	assert.Equal("Performapal Skullcrobat Joker", (*random).GetName(), "Black listed cards should never be chosen")
+
+	_, err := svc.GetRandomCard(skc_testing.TestContext, []string{"46986414", "97631303", "38033121", "71703785"})
+	assert.NotNil(err, "An error is expected when every card is black listed")
+}
@@ -0,0 +1,217 @@
+package embedding
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"log/slog"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
+	"github.com/ygo-skc/skc-suggestion-engine/db"
+	"github.com/ygo-skc/skc-suggestion-engine/downstream"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+)
+
+const (
+	defaultCacheCapacity = 1000
+)
+
+type cacheEntry struct {
+	key       string
+	embedding []float32
+}
+
+// Caches embeddings of card effects so popular cards don't need to be embedded on every request.
+// Lookups go through an in memory LRU, then (optionally) the persisted cache in the DB, then the card's own document in the cardEmbedding collection before finally calling the embedding provider.
+type Cache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	lru      *list.List // front = most recently used
+
+	embedder downstream.Embedder
+	dao      db.SKCSuggestionEngineDAO
+	persist  bool
+}
+
+func NewCache(capacity int, embedder downstream.Embedder, dao db.SKCSuggestionEngineDAO, persist bool) *Cache {
+	return &Cache{
+		capacity: max(capacity, 1),
+		entries:  make(map[string]*list.Element, capacity),
+		lru:      list.New(),
+		embedder: embedder,
+		dao:      dao,
+		persist:  persist,
+	}
+}
+
+// Uses EMBEDDING_CACHE_SIZE (max number of embeddings kept in memory) and EMBEDDING_CACHE_PERSIST (true to persist embeddings in the DB) env variables to configure the cache.
+func NewCacheFromEnv(embedder downstream.Embedder, dao db.SKCSuggestionEngineDAO) *Cache {
+	capacity := defaultCacheCapacity
+	if size := cUtil.EnvMap["EMBEDDING_CACHE_SIZE"]; size != "" {
+		var err error
+		if capacity, err = strconv.Atoi(size); err != nil {
+			log.Fatalf("EMBEDDING_CACHE_SIZE is not a number: %v", err)
+		}
+	}
+
+	persist := cUtil.EnvMap["EMBEDDING_CACHE_PERSIST"] == "true"
+	slog.Info("Configured embedding cache", slog.Int("capacity", capacity), slog.Bool("persist", persist))
+	return NewCache(capacity, embedder, dao, persist)
+}
+
+// Cache key changes whenever card text, model or input type changes - stale embeddings are never reused
+func Key(cardID string, text string, embeddingModel string, inputType model.VoyageInputType) string {
+	return cardID + ":" + TextHash(text, embeddingModel, inputType)
+}
+
+func TextHash(text string, embeddingModel string, inputType model.VoyageInputType) string {
+	h := sha256.New()
+	h.Write([]byte(embeddingModel))
+	h.Write([]byte{0})
+	h.Write([]byte(inputType))
+	h.Write([]byte{0})
+	h.Write([]byte(text))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Returns the query embedding of the card's effect
+func (c *Cache) EmbedCardEffect(ctx context.Context, card cModel.YGOCard) ([]float32, *cModel.APIError) {
+	embeddings, err := c.EmbedCardEffects(ctx, []cModel.YGOCard{card})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[card.GetID()], nil
+}
+
+// Returns the query embedding of each card's effect keyed by card ID. Cards not found in any cache are embedded using a single provider call.
+func (c *Cache) EmbedCardEffects(ctx context.Context, cards []cModel.YGOCard) (map[string][]float32, *cModel.APIError) {
+	embeddingModel := c.embedder.EmbeddingModel()
+
+	embeddings, misses := c.CachedCardEffects(ctx, cards)
+	if len(misses) == 0 {
+		return embeddings, nil
+	}
+
+	text := make([]string, len(misses))
+	for i, card := range misses {
+		text[i] = card.GetEffect()
+	}
+
+	embeddingRes, err := c.embedder.EmbedText(ctx, text, model.VoyageQueryInput)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, card := range misses {
+		embedding := embeddingRes.Data[i].Embedding
+		embeddings[card.GetID()] = embedding
+		c.store(ctx, card, embeddingModel, embedding)
+	}
+	return embeddings, nil
+}
+
+// Returns cached query embeddings keyed by card ID along with the cards that weren't found in any cache - the embedding provider is never called
+func (c *Cache) CachedCardEffects(ctx context.Context, cards []cModel.YGOCard) (map[string][]float32, []cModel.YGOCard) {
+	embeddingModel := c.embedder.EmbeddingModel()
+
+	embeddings := make(map[string][]float32, len(cards))
+	misses := make([]cModel.YGOCard, 0, len(cards))
+	for _, card := range cards {
+		if embedding := c.lookup(ctx, card, Key(card.GetID(), card.GetEffect(), embeddingModel, model.VoyageQueryInput)); embedding != nil {
+			embeddings[card.GetID()] = embedding
+		} else {
+			misses = append(misses, card)
+		}
+	}
+	cUtil.RetrieveLogger(ctx).Info("Embedding cache lookup complete", slog.Int("hits", len(embeddings)), slog.Int("misses", len(misses)))
+	return embeddings, misses
+}
+
+// checks in memory cache, persisted cache and finally the card's own document - nil is returned on cache miss
+func (c *Cache) lookup(ctx context.Context, card cModel.YGOCard, key string) []float32 {
+	logger := cUtil.RetrieveLogger(ctx)
+
+	if embedding := c.get(key); embedding != nil {
+		logger.Info("Using embedding from in memory cache", slog.String("card_id", card.GetID()))
+		return embedding
+	}
+
+	if c.persist {
+		if embedding, err := c.dao.GetCachedEmbedding(ctx, key); err != nil {
+			logger.Warn("Could not read persisted embedding cache - skipping", slog.Any("err", err))
+		} else if embedding != nil {
+			logger.Info("Using embedding from persisted cache", slog.String("card_id", card.GetID()))
+			c.put(key, embedding)
+			return embedding
+		}
+	}
+
+	// the vector index only works if all vectors share the same embedding space, as such the card's own document embedding can be used as long as the text didn't change
+	if cardEmbedding, err := c.dao.GetCardEmbedding(ctx, card.GetID()); err != nil {
+		logger.Warn("Could not read card embedding document - skipping", slog.Any("err", err))
+	} else if cardEmbedding != nil && cardEmbedding.Text == card.GetEffect() && len(cardEmbedding.TextEmbedding) != 0 {
+		logger.Info("Using embedding from card embedding document", slog.String("card_id", card.GetID()))
+		c.put(key, cardEmbedding.TextEmbedding)
+		return cardEmbedding.TextEmbedding
+	}
+
+	return nil
+}
+
+func (c *Cache) store(ctx context.Context, card cModel.YGOCard, embeddingModel string, embedding []float32) {
+	key := Key(card.GetID(), card.GetEffect(), embeddingModel, model.VoyageQueryInput)
+	c.put(key, embedding)
+
+	if c.persist {
+		ce := model.CachedEmbedding{
+			Key: key, CardID: card.GetID(), Model: embeddingModel, TextHash: TextHash(card.GetEffect(), embeddingModel, model.VoyageQueryInput),
+			Embedding: embedding, CreatedAt: time.Now(),
+		}
+		if err := c.dao.InsertCachedEmbedding(ctx, ce); err != nil {
+			cUtil.RetrieveLogger(ctx).Warn("Could not persist embedding", slog.Any("err", err))
+		}
+	}
+}
+
+func (c *Cache) get(key string) []float32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, isPresent := c.entries[key]; isPresent {
+		c.lru.MoveToFront(element)
+		return element.Value.(*cacheEntry).embedding
+	}
+	return nil
+}
+
+func (c *Cache) put(key string, embedding []float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, isPresent := c.entries[key]; isPresent {
+		element.Value.(*cacheEntry).embedding = embedding
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, embedding: slices.Clip(embedding)})
+	if c.lru.Len() > c.capacity {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
@@ -0,0 +1,107 @@
+package embedding
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	"github.com/ygo-skc/skc-suggestion-engine/db"
+	"github.com/ygo-skc/skc-suggestion-engine/downstream"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+	skc_testing "github.com/ygo-skc/skc-suggestion-engine/testing"
+)
+
+// counts calls made to the underlying embedder
+type countingEmbedder struct {
+	downstream.HashingEmbedder
+	calls int
+}
+
+func (e *countingEmbedder) EmbedText(ctx context.Context, input []string, inputType model.VoyageInputType) (*model.EmbeddingResponse, *cModel.APIError) {
+	e.calls++
+	return e.HashingEmbedder.EmbedText(ctx, input, inputType)
+}
+
+func newCard(id string, effect string) cModel.YGOCard {
+	return cModel.YGOCardREST{ID: id, Effect: effect}
+}
+
+// in-memory DAO seeded with testdata/seed.json - the path is resolved using this file as skc_testing changes the working directory
+func newSeededDAO(t *testing.T) *db.SKCSuggestionEngineDAOInMemory {
+	_, filename, _, _ := runtime.Caller(0)
+	dao, err := db.NewSKCSuggestionEngineDAOInMemory(filepath.Join(filepath.Dir(filename), "testdata", "seed.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dao
+}
+
+func TestCacheAvoidsRepeatedProviderCalls(t *testing.T) {
+	assert := assert.New(t)
+	dao, _ := db.NewSKCSuggestionEngineDAOInMemory("")
+	embedder := &countingEmbedder{HashingEmbedder: downstream.NewHashingEmbedder()}
+	cache := NewCache(2, embedder, dao, false)
+
+	darkMagician := newCard("46986414", "The ultimate wizard in terms of attack and defense.")
+	first, _ := cache.EmbedCardEffect(skc_testing.TestContext, darkMagician)
+	second, _ := cache.EmbedCardEffect(skc_testing.TestContext, darkMagician)
+	assert.Equal(first, second)
+	assert.Equal(1, embedder.calls, "Second lookup should be served from memory")
+
+	// text change produces a new key
+	cache.EmbedCardEffect(skc_testing.TestContext, newCard("46986414", "Errata'd text."))
+	assert.Equal(2, embedder.calls)
+
+	// capacity is 2, least recently used entry should be evicted
+	cache.EmbedCardEffect(skc_testing.TestContext, newCard("89631139", "This legendary dragon is a powerful engine of destruction."))
+	assert.Equal(2, cache.Len())
+	cache.EmbedCardEffect(skc_testing.TestContext, darkMagician)
+	assert.Equal(4, embedder.calls, "Evicted entry should be embedded again")
+}
+
+func TestCacheUsesCardEmbeddingDocument(t *testing.T) {
+	assert := assert.New(t)
+	dao := newSeededDAO(t)
+	embedder := &countingEmbedder{HashingEmbedder: downstream.NewHashingEmbedder()}
+	cache := NewCache(10, embedder, dao, false)
+
+	embedding, _ := cache.EmbedCardEffect(skc_testing.TestContext, newCard("46986414", "The ultimate wizard in terms of attack and defense."))
+	assert.Equal([]float32{1, 0, 0}, embedding, "Document embedding should be reused when the text matches")
+	assert.Equal(0, embedder.calls)
+
+	cache.EmbedCardEffect(skc_testing.TestContext, newCard("89631139", "Text that differs from the stored document."))
+	assert.Equal(1, embedder.calls, "Document embedding should not be used when the text changed")
+}
+
+func TestCachePersistsEmbeddings(t *testing.T) {
+	assert := assert.New(t)
+	dao, _ := db.NewSKCSuggestionEngineDAOInMemory("")
+	embedder := &countingEmbedder{HashingEmbedder: downstream.NewHashingEmbedder()}
+	card := newCard("46986414", "The ultimate wizard in terms of attack and defense.")
+
+	NewCache(10, embedder, dao, true).EmbedCardEffect(skc_testing.TestContext, card)
+
+	// simulates a restart - memory is empty but the DB isn't
+	embedding, _ := NewCache(10, embedder, dao, true).EmbedCardEffect(skc_testing.TestContext, card)
+	assert.Len(embedding, 512)
+	assert.Equal(1, embedder.calls, "Persisted embedding should be used after restart")
+}
+
+func TestCacheEmbedsMissesInOneCall(t *testing.T) {
+	assert := assert.New(t)
+	dao := newSeededDAO(t)
+	embedder := &countingEmbedder{HashingEmbedder: downstream.NewHashingEmbedder()}
+	cache := NewCache(10, embedder, dao, false)
+
+	embeddings, _ := cache.EmbedCardEffects(skc_testing.TestContext, []cModel.YGOCard{
+		newCard("46986414", "The ultimate wizard in terms of attack and defense."), // document hit
+		newCard("38033121", "Gains 500 ATK for every Dark Magician in the GY."),
+		newCard("71703785", "Must be Special Summoned by banishing 1 Dark Magician."),
+	})
+	assert.Len(embeddings, 3)
+	assert.Equal([]float32{1, 0, 0}, embeddings["46986414"])
+	assert.Equal(1, embedder.calls, "Every cache miss should be embedded using a single call")
+}
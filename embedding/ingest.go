@@ -0,0 +1,267 @@
+package embedding
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
+	"github.com/ygo-skc/skc-suggestion-engine/db"
+	"github.com/ygo-skc/skc-suggestion-engine/downstream"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+)
+
+const (
+	ingestionBatchSize = 64
+	maxRetainedJobs    = 20
+
+	textUnchangedReason = "Text unchanged since last embedding"
+	noTextReason        = "Card has no text to embed"
+	cardNotFoundReason  = "Card not found"
+)
+
+// Embeds card text and upserts the results into the cardEmbedding collection so new/updated cards can be found using vector search.
+// Jobs run in the background, one at a time - the state of recent jobs is kept in memory so progress can be reported.
+type Ingester struct {
+	mu      sync.Mutex
+	jobs    map[string]*model.CardEmbeddingIngestionJob
+	jobIDs  []string // oldest first
+	running bool
+
+	embedder  downstream.Embedder
+	dao       db.SKCSuggestionEngineDAO
+	batchSize int
+}
+
+func NewIngester(embedder downstream.Embedder, dao db.SKCSuggestionEngineDAO) *Ingester {
+	return &Ingester{
+		jobs:      make(map[string]*model.CardEmbeddingIngestionJob),
+		embedder:  embedder,
+		dao:       dao,
+		batchSize: ingestionBatchSize,
+	}
+}
+
+// Starts a new job in the background using the given cards. IDs that could not be resolved to a card are reported as failures.
+// releaseDates (card ID -> YYYY-MM-DD) are saved for every card with a document, including cards whose text didn't change.
+// False is returned if another job is still running.
+func (i *Ingester) Start(ctx context.Context, cards []cModel.YGOCard, unknownCardIDs cModel.CardIDs,
+	releaseDates map[string]string) (model.CardEmbeddingIngestionJob, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.running {
+		return model.CardEmbeddingIngestionJob{}, false
+	}
+
+	job := &model.CardEmbeddingIngestionJob{
+		ID:        newJobID(),
+		Status:    model.IngestionRunning,
+		Model:     i.embedder.EmbeddingModel(),
+		Requested: len(cards) + len(unknownCardIDs),
+		Skipped:   make([]model.IngestionSkip, 0),
+		Failures:  make([]model.IngestionFailure, 0, len(unknownCardIDs)),
+		StartedAt: time.Now(),
+	}
+	for _, cardID := range unknownCardIDs {
+		job.Failures = append(job.Failures, model.IngestionFailure{CardID: cardID, Reason: cardNotFoundReason})
+	}
+	job.Processed = len(unknownCardIDs)
+
+	i.retain(job)
+	i.running = true
+
+	// job outlives the request that started it
+	go i.run(context.WithoutCancel(ctx), job, cards, releaseDates)
+	return copyJob(job), true
+}
+
+// Returns a snapshot of a job started by this Ingester
+func (i *Ingester) Job(id string) (model.CardEmbeddingIngestionJob, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if job, isPresent := i.jobs[id]; isPresent {
+		return copyJob(job), true
+	}
+	return model.CardEmbeddingIngestionJob{}, false
+}
+
+func (i *Ingester) run(ctx context.Context, job *model.CardEmbeddingIngestionJob, cards []cModel.YGOCard, releaseDates map[string]string) {
+	logger := cUtil.RetrieveLogger(ctx)
+	logger.Info("Starting card embedding ingestion", slog.String("job_id", job.ID), slog.Int("total_cards", len(cards)))
+
+	defer func() {
+		i.mu.Lock()
+		defer i.mu.Unlock()
+
+		completedAt := time.Now()
+		job.Status, job.CompletedAt = model.IngestionCompleted, &completedAt
+		i.running = false
+		logger.Info("Finished card embedding ingestion", slog.String("job_id", job.ID), slog.Int("embedded", job.Embedded),
+			slog.Int("skipped", len(job.Skipped)), slog.Int("failed", len(job.Failures)))
+	}()
+
+	for batch := range slices.Chunk(cards, i.batchSize) {
+		i.ingestBatch(ctx, job, batch)
+		i.saveReleaseDates(ctx, batch, releaseDates)
+		logger.Info("Card embedding ingestion progress", slog.String("job_id", job.ID), slog.Int("processed", job.Processed), slog.Int("requested", job.Requested))
+	}
+}
+
+func (i *Ingester) ingestBatch(ctx context.Context, job *model.CardEmbeddingIngestionJob, batch []cModel.YGOCard) {
+	cardIDs := make(cModel.CardIDs, len(batch))
+	for ind, card := range batch {
+		cardIDs[ind] = card.GetID()
+	}
+
+	existingText, err := i.dao.GetCardEmbeddingTexts(ctx, cardIDs)
+	if err != nil {
+		i.fail(job, cardIDs, err.Message)
+		return
+	}
+
+	var skipped []model.IngestionSkip
+	toEmbed := make([]cModel.YGOCard, 0, len(batch))
+	backfillLevels := make(map[string]int)
+	for _, card := range batch {
+		if card.GetEffect() == "" {
+			skipped = append(skipped, model.IngestionSkip{CardID: card.GetID(), Reason: noTextReason})
+		} else if text, isPresent := existingText[card.GetID()]; isPresent && text == card.GetEffect() {
+			skipped = append(skipped, model.IngestionSkip{CardID: card.GetID(), Reason: textUnchangedReason})
+			if level := cardLevel(card); level != nil {
+				backfillLevels[card.GetID()] = *level // documents created before levels were stored
+			}
+		} else {
+			toEmbed = append(toEmbed, card)
+		}
+	}
+	i.skip(job, skipped)
+	i.saveLevels(ctx, backfillLevels)
+
+	if len(toEmbed) == 0 {
+		return
+	}
+
+	text := make([]string, len(toEmbed))
+	toEmbedIDs := make(cModel.CardIDs, len(toEmbed))
+	for ind, card := range toEmbed {
+		text[ind], toEmbedIDs[ind] = card.GetEffect(), card.GetID()
+	}
+
+	embeddingRes, err := i.embedder.EmbedText(ctx, text, model.VoyageDocumentInput)
+	if err != nil {
+		i.fail(job, toEmbedIDs, err.Message)
+		return
+	}
+
+	cardEmbeddings := make([]model.CardEmbedding, len(toEmbed))
+	for ind, card := range toEmbed {
+		cardEmbeddings[ind] = model.CardEmbedding{
+			ID:            card.GetID(),
+			Text:          card.GetEffect(),
+			Type:          card.GetColor(),
+			Attribute:     card.GetAttribute(),
+			MonsterType:   card.GetMonsterType(),
+			Level:         cardLevel(card),
+			TextEmbedding: embeddingRes.Data[ind].Embedding,
+		}
+	}
+
+	if err := i.dao.UpsertCardEmbeddings(ctx, cardEmbeddings); err != nil {
+		i.fail(job, toEmbedIDs, err.Message)
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	job.Embedded += len(cardEmbeddings)
+	job.Processed += len(cardEmbeddings)
+}
+
+// Cards exposing their level, rank or link rating - ygo-service cards only implement this once it returns the value
+type levelledCard interface {
+	GetLevel() *uint32
+}
+
+// level, rank or link rating of the card, nil when the card doesn't expose one
+func cardLevel(card cModel.YGOCard) *int {
+	if c, ok := card.(levelledCard); ok && c.GetLevel() != nil {
+		level := int(*c.GetLevel())
+		return &level
+	}
+	return nil
+}
+
+// levels are only used to filter similar cards so failing to save them doesn't fail the cards
+func (i *Ingester) saveLevels(ctx context.Context, levels map[string]int) {
+	if len(levels) == 0 {
+		return
+	}
+
+	if err := i.dao.UpdateCardLevels(ctx, levels); err != nil {
+		cUtil.RetrieveLogger(ctx).Warn("Could not save card levels", slog.Int("total", len(levels)), slog.String("err", err.Message))
+	}
+}
+
+// release dates are only used to rank references so failing to save them doesn't fail the cards
+func (i *Ingester) saveReleaseDates(ctx context.Context, batch []cModel.YGOCard, releaseDates map[string]string) {
+	batchReleaseDates := make(map[string]string)
+	for _, card := range batch {
+		if releaseDate, isPresent := releaseDates[card.GetID()]; isPresent {
+			batchReleaseDates[card.GetID()] = releaseDate
+		}
+	}
+	if len(batchReleaseDates) == 0 {
+		return
+	}
+
+	if err := i.dao.UpdateCardReleaseDates(ctx, batchReleaseDates); err != nil {
+		cUtil.RetrieveLogger(ctx).Warn("Could not save card release dates", slog.Int("total", len(batchReleaseDates)), slog.String("err", err.Message))
+	}
+}
+
+func (i *Ingester) skip(job *model.CardEmbeddingIngestionJob, skipped []model.IngestionSkip) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	job.Skipped = append(job.Skipped, skipped...)
+	job.Processed += len(skipped)
+}
+
+func (i *Ingester) fail(job *model.CardEmbeddingIngestionJob, cardIDs cModel.CardIDs, reason string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, cardID := range cardIDs {
+		job.Failures = append(job.Failures, model.IngestionFailure{CardID: cardID, Reason: reason})
+	}
+	job.Processed += len(cardIDs)
+}
+
+// keeps the most recent jobs, older ones are forgotten - caller must hold lock
+func (i *Ingester) retain(job *model.CardEmbeddingIngestionJob) {
+	i.jobs[job.ID] = job
+	i.jobIDs = append(i.jobIDs, job.ID)
+	if len(i.jobIDs) > maxRetainedJobs {
+		delete(i.jobs, i.jobIDs[0])
+		i.jobIDs = i.jobIDs[1:]
+	}
+}
+
+// caller must hold lock
+func copyJob(job *model.CardEmbeddingIngestionJob) model.CardEmbeddingIngestionJob {
+	c := *job
+	c.Skipped = slices.Clone(job.Skipped)
+	c.Failures = slices.Clone(job.Failures)
+	return c
+}
+
+func newJobID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,107 @@
+package embedding
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	"github.com/ygo-skc/skc-suggestion-engine/downstream"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+	skc_testing "github.com/ygo-skc/skc-suggestion-engine/testing"
+)
+
+func waitForJob(t *testing.T, ingester *Ingester, id string) model.CardEmbeddingIngestionJob {
+	var job model.CardEmbeddingIngestionJob
+	assert.Eventually(t, func() bool {
+		job, _ = ingester.Job(id)
+		return job.Status == model.IngestionCompleted
+	}, time.Second, 5*time.Millisecond)
+	return job
+}
+
+func TestIngestion(t *testing.T) {
+	assert := assert.New(t)
+	dao := newSeededDAO(t)
+	embedder := &countingEmbedder{HashingEmbedder: downstream.NewHashingEmbedder()}
+	ingester := NewIngester(embedder, dao)
+	ingester.batchSize = 2
+
+	cards := []cModel.YGOCard{
+		cModel.YGOCardREST{ID: "46986414", Color: "Normal", Effect: "The ultimate wizard in terms of attack and defense."}, // unchanged
+		cModel.YGOCardREST{ID: "89631139", Color: "Normal", Effect: "Errata'd text."},                                      // changed
+		cModel.YGOCardREST{ID: "38033121", Color: "Effect", Effect: "Gains 500 ATK for every Dark Magician in the GY."},    // new
+		cModel.YGOCardREST{ID: "00000001", Color: "Token"},                                                                 // no text
+	}
+
+	job, started := ingester.Start(skc_testing.TestContext, cards, cModel.CardIDs{"99999999"}, map[string]string{"46986414": "2002-03-08", "38033121": "2004-05-13"})
+	assert.True(started)
+	assert.Equal(model.IngestionRunning, job.Status)
+	assert.Equal(5, job.Requested)
+
+	job = waitForJob(t, ingester, job.ID)
+	assert.Equal(5, job.Processed)
+	assert.Equal(2, job.Embedded)
+	assert.ElementsMatch([]model.IngestionSkip{
+		{CardID: "46986414", Reason: textUnchangedReason},
+		{CardID: "00000001", Reason: noTextReason},
+	}, job.Skipped)
+	assert.Equal([]model.IngestionFailure{{CardID: "99999999", Reason: cardNotFoundReason}}, job.Failures)
+	assert.Equal(2, embedder.calls, "Each batch with changed text should be embedded once")
+
+	newCard, _ := dao.GetCardEmbedding(skc_testing.TestContext, "38033121")
+	assert.Equal("Effect", newCard.Type)
+	assert.Len(newCard.TextEmbedding, 512)
+
+	updatedCard, _ := dao.GetCardEmbedding(skc_testing.TestContext, "89631139")
+	assert.Equal("Errata'd text.", updatedCard.Text)
+
+	releaseDates, _ := dao.GetCardReleaseDates(skc_testing.TestContext, cModel.CardIDs{"46986414", "89631139", "38033121"})
+	assert.Equal(map[string]string{"46986414": "2002-03-08", "38033121": "2004-05-13"}, releaseDates, "Release dates should be saved for skipped and embedded cards")
+
+	// running again should be a no-op - a later release date doesn't replace an earlier one
+	job, _ = ingester.Start(skc_testing.TestContext, cards[:3], nil, map[string]string{"46986414": "2010-01-01"})
+	job = waitForJob(t, ingester, job.ID)
+	assert.Equal(0, job.Embedded)
+	assert.Len(job.Skipped, 3)
+	assert.Equal(2, embedder.calls)
+
+	releaseDates, _ = dao.GetCardReleaseDates(skc_testing.TestContext, cModel.CardIDs{"46986414"})
+	assert.Equal("2002-03-08", releaseDates["46986414"])
+}
+
+// card exposing its level, as ygo-service cards do once they return one
+type levelledTestCard struct {
+	cModel.YGOCardREST
+	level uint32
+}
+
+func (c levelledTestCard) GetLevel() *uint32 {
+	return &c.level
+}
+
+func TestIngestionSavesLevels(t *testing.T) {
+	assert := assert.New(t)
+	dao := newSeededDAO(t)
+	ingester := NewIngester(&countingEmbedder{HashingEmbedder: downstream.NewHashingEmbedder()}, dao)
+
+	cards := []cModel.YGOCard{
+		levelledTestCard{YGOCardREST: cModel.YGOCardREST{ID: "46986414", Color: "Normal", Effect: "The ultimate wizard in terms of attack and defense."}, level: 7}, // unchanged
+		levelledTestCard{YGOCardREST: cModel.YGOCardREST{ID: "38033121", Color: "Effect", Effect: "Gains 500 ATK for every Dark Magician in the GY."}, level: 4},    // new
+	}
+	job, _ := ingester.Start(skc_testing.TestContext, cards, nil, nil)
+	waitForJob(t, ingester, job.ID)
+
+	search := func(minLevel int, maxLevel int) []string {
+		results, _ := dao.VectorSearchOnCardEmbedding(skc_testing.TestContext, cModel.YGOCardREST{ID: "00000000"}, []float32{1, 0, 0},
+			model.VectorSearchFilter{MinLevel: &minLevel, MaxLevel: &maxLevel})
+		ids := make([]string, len(results))
+		for ind, result := range results {
+			ids[ind] = result.ID
+		}
+		return ids
+	}
+	assert.Equal([]string{"38033121"}, search(1, 4), "Level of embedded cards should be saved")
+	assert.Equal([]string{"46986414"}, search(7, 7), "Level of existing documents should be backfilled")
+	assert.Empty(search(8, 12))
+}
@@ -24,7 +24,7 @@ func init() {
 
 func main() {
 	downstream.ConnectToYGOService()
-	db.EstablishSKCSuggestionEngineDBConn()
-	go api.RunHttpServer()
+	downstream.ConfigureEmbeddingProviders()
+	go api.RunHttpServer(db.EstablishSKCSuggestionEngineDAO())
 	select {}
 }
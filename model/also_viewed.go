@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+)
+
+// document stored in the alsoViewed collection - cards viewed in the same sessions as a card or product
+type AlsoViewedEntry struct {
+	ResourceName ResourceName   `bson:"resourceName" json:"resourceName"`
+	ResourceID   string         `bson:"resourceID" json:"resourceID"`
+	Cards        []CoViewedCard `bson:"cards" json:"cards"` // most sessions first
+	BuiltAt      time.Time      `bson:"builtAt" json:"builtAt"`
+}
+
+type CoViewedCard struct {
+	CardID   string `bson:"cardID" json:"cardID"`
+	Sessions int    `bson:"sessions" json:"sessions"` // sessions the card and the resource were both viewed in
+}
+
+// occurrences of each reference is the number of sessions the card was viewed in along with the subject
+type AlsoViewed struct {
+	Card       cModel.YGOCard  `json:"card"`
+	AlsoViewed []CardReference `json:"alsoViewed"`
+	BuiltAt    *time.Time      `json:"builtAt,omitempty"` // when co-occurrences were last counted, missing when the card was never viewed with another card
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+)
+
+type IngestionStatus string
+
+const (
+	IngestionRunning   IngestionStatus = "RUNNING"
+	IngestionCompleted IngestionStatus = "COMPLETED"
+)
+
+// cards to (re)embed - product IDs are expanded to every card in the product
+type CardEmbeddingIngestionRequest struct {
+	CardIDs    cModel.CardIDs    `json:"cardIDs" validate:"omitempty,ygocardids"`
+	ProductIDs cModel.ProductIDs `json:"productIDs" validate:"omitempty,ygoproductids"`
+}
+
+type IngestionSkip struct {
+	CardID string `json:"cardID"`
+	Reason string `json:"reason"`
+}
+
+type IngestionFailure struct {
+	CardID string `json:"cardID"`
+	Reason string `json:"reason"`
+}
+
+// progress and outcome of a job that embeds card text and upserts it into the cardEmbedding collection
+type CardEmbeddingIngestionJob struct {
+	ID          string             `json:"id"`
+	Status      IngestionStatus    `json:"status"`
+	Model       string             `json:"model"`
+	Requested   int                `json:"requested"`
+	Processed   int                `json:"processed"`
+	Embedded    int                `json:"embedded"`
+	Skipped     []IngestionSkip    `json:"skipped"`
+	Failures    []IngestionFailure `json:"failures"`
+	StartedAt   time.Time          `json:"startedAt"`
+	CompletedAt *time.Time         `json:"completedAt,omitempty"`
+}
@@ -0,0 +1,143 @@
+package model
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+)
+
+type GraphNodeType string
+
+const (
+	CardNode      GraphNodeType = "card"
+	ArchetypeNode GraphNodeType = "archetype"
+)
+
+type GraphEdgeType string
+
+const (
+	MaterialEdge  GraphEdgeType = "material"  // source names target in its material clause
+	ReferenceEdge GraphEdgeType = "reference" // source names target in its effect
+	ArchetypeEdge GraphEdgeType = "archetype" // source belongs to or references the target archetype
+)
+
+type GraphNode struct {
+	ID    string         `json:"id"` // card ID for cards, archetype nodes use archetype: followed by the archetype name
+	Type  GraphNodeType  `json:"type"`
+	Label string         `json:"label"`
+	Depth int            `json:"depth"` // hops from the root card
+	Card  cModel.YGOCard `json:"card,omitempty"`
+}
+
+// edges are directed - source is always the card whose text contains the reference
+type GraphEdge struct {
+	Source      string        `json:"source"`
+	Target      string        `json:"target"`
+	Type        GraphEdgeType `json:"type"`
+	Occurrences int           `json:"occurrences"`
+}
+
+type CardGraph struct {
+	Root      string      `json:"root"`
+	Depth     int         `json:"depth"`
+	Nodes     []GraphNode `json:"nodes"`
+	Edges     []GraphEdge `json:"edges"`
+	Truncated bool        `json:"truncated"` // true if the node limit was reached before every hop was walked
+}
+
+func ArchetypeNodeID(archetype string) string {
+	return "archetype:" + archetype
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+func (g CardGraph) GraphML() ([]byte, error) {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "label", For: "node", AttrName: "label", AttrType: "string"},
+			{ID: "nodeType", For: "node", AttrName: "type", AttrType: "string"},
+			{ID: "depth", For: "node", AttrName: "depth", AttrType: "int"},
+			{ID: "edgeType", For: "edge", AttrName: "type", AttrType: "string"},
+			{ID: "occurrences", For: "edge", AttrName: "occurrences", AttrType: "int"},
+		},
+	}
+	doc.Graph.ID, doc.Graph.EdgeDefault = g.Root, "directed"
+
+	for _, node := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: node.ID, Data: []graphMLData{
+			{Key: "label", Value: node.Label}, {Key: "nodeType", Value: string(node.Type)}, {Key: "depth", Value: fmt.Sprint(node.Depth)},
+		}})
+	}
+	for _, edge := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: edge.Source, Target: edge.Target, Data: []graphMLData{
+			{Key: "edgeType", Value: string(edge.Type)}, {Key: "occurrences", Value: fmt.Sprint(edge.Occurrences)},
+		}})
+	}
+
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// Graphviz DOT representation - archetypes are drawn as boxes
+func (g CardGraph) DOT() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(g.Root))
+	for _, node := range g.Nodes {
+		shape := "ellipse"
+		if node.Type == ArchetypeNode {
+			shape = "box"
+		}
+		fmt.Fprintf(&b, "  %s [label=%s, shape=%s, depth=%d];\n", dotQuote(node.ID), dotQuote(node.Label), shape, node.Depth)
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s, occurrences=%d];\n", dotQuote(edge.Source), dotQuote(edge.Target), dotQuote(string(edge.Type)), edge.Occurrences)
+	}
+	b.WriteString("}\n")
+	return b.Bytes()
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
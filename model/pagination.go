@@ -0,0 +1,66 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+)
+
+// Portion of a list response to return, parsed from the limit and cursor query params
+type Page struct {
+	Limit  int // 0 = no limit
+	Offset int
+}
+
+// Set when more results are available - clients pass NextCursor as the cursor query param to get the next page
+type Pagination struct {
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+func (p *Pagination) SetNextCursor(page Page, hasMore bool) {
+	if hasMore {
+		p.NextCursor = EncodeCursor(page.Offset + page.Limit)
+	}
+}
+
+// cursors are opaque to clients - they only work because every paginated list has a stable order
+func EncodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func DecodeCursor(cursor string) (int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+
+	offset, err := strconv.Atoi(string(decoded))
+	if err != nil {
+		return 0, err
+	} else if offset < 0 {
+		return 0, errors.New("negative offset")
+	}
+	return offset, nil
+}
+
+// Items of list within page. True is returned when there are items after the page.
+func Paginate[T any](list []T, page Page) ([]T, bool) {
+	start := min(page.Offset, len(list))
+	if page.Limit == 0 {
+		return list[start:], false
+	}
+
+	end := min(start+page.Limit, len(list))
+	return list[start:end], end < len(list)
+}
+
+// Pages every list using the same offset. True is returned when any of the lists has items after the page.
+func PaginateLists[T any](page Page, lists ...*[]T) bool {
+	hasMore := false
+	for _, list := range lists {
+		var listHasMore bool
+		*list, listHasMore = Paginate(*list, page)
+		hasMore = hasMore || listHasMore
+	}
+	return hasMore
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// document stored in the referenceIndex collection - one document per indexed card, references are card IDs
+type ReferenceIndexEntry struct {
+	CardID       string    `bson:"cardID" json:"cardID"`
+	Name         string    `bson:"name" json:"name"`
+	ReferencedBy []string  `bson:"referencedBy" json:"referencedBy"` // cards that reference the name in their effect
+	MaterialFor  []string  `bson:"materialFor" json:"materialFor"`   // cards that list the name as a material
+	AliasedBy    []string  `bson:"aliasedBy" json:"aliasedBy"`       // cards whose name becomes or is always treated as the name
+	BuiltAt      time.Time `bson:"builtAt" json:"builtAt"`
+	Searched     bool      `bson:"searched" json:"searched"` // references were found by searching every card in ygo-service, false for older snapshots
+}
+
+type ReferenceIndexStatus struct {
+	Ready           bool       `json:"ready"`
+	Refreshing      bool       `json:"refreshing"`
+	BuiltAt         *time.Time `json:"builtAt,omitempty"`
+	Cards           int        `json:"cards"`           // cards whose support is served from the index
+	ReferencedNames int        `json:"referencedNames"` // names referenced by at least one card
+	LastError       string     `json:"lastError,omitempty"`
+}
@@ -1,15 +1,104 @@
 package model
 
 import (
+	"cmp"
+	"slices"
+	"time"
+
 	cModel "github.com/ygo-skc/skc-go/common/v3/model"
 )
 
 type CardReference struct {
-	Occurrences int            `json:"occurrences"`
-	Card        cModel.YGOCard `json:"card"`
+	Occurrences int               `json:"occurrences"`
+	Card        cModel.YGOCard    `json:"card"`
+	Intents     []ReferenceIntent `json:"intents,omitempty"`
+	Popularity  *float64          `json:"popularity,omitempty"` // only included when requested
+}
+
+// What a card does with a card it names - determined using the clause the quoted name is found in
+type ReferenceIntent string
+
+const (
+	SearchIntent        ReferenceIntent = "search" // added from the Deck to the hand
+	SpecialSummonIntent ReferenceIntent = "specialSummon"
+	SendToGYIntent      ReferenceIntent = "sendToGY"
+	NegateIntent        ReferenceIntent = "negate"
+	BanishIntent        ReferenceIntent = "banish"
+	IfYouControlIntent  ReferenceIntent = "ifYouControl" // controlling the card is a condition, eg: If you control "Umi"
+)
+
+var ReferenceIntents = []ReferenceIntent{SearchIntent, SpecialSummonIntent, SendToGYIntent, NegateIntent, BanishIntent, IfYouControlIntent}
+
+// adds intents that aren't already part of the reference - intents are kept in the order of ReferenceIntents
+func (r *CardReference) AddIntents(intents []ReferenceIntent) {
+	for _, intent := range intents {
+		if !slices.Contains(r.Intents, intent) {
+			r.Intents = append(r.Intents, intent)
+		}
+	}
+	slices.SortFunc(r.Intents, func(a, b ReferenceIntent) int {
+		return cmp.Compare(slices.Index(ReferenceIntents, a), slices.Index(ReferenceIntents, b))
+	})
+}
+
+// references with at least one of the intents - references are returned as is when no intent is given
+func FilterByIntent(references []CardReference, intents []ReferenceIntent) []CardReference {
+	if len(intents) == 0 {
+		return references
+	}
+
+	filtered := make([]CardReference, 0, len(references))
+	for _, reference := range references {
+		if slices.ContainsFunc(reference.Intents, func(intent ReferenceIntent) bool { return slices.Contains(intents, intent) }) {
+			filtered = append(filtered, reference)
+		}
+	}
+	return filtered
+}
+
+type SuggestionStage string
+
+const (
+	CardNameResolutionStage SuggestionStage = "cardNameResolution" // quoted names could not be resolved to cards - named materials/references are missing
+	SupportLookupStage      SuggestionStage = "supportLookup"      // cards referencing the subject could not be retrieved - support is missing
+	CardColorsStage         SuggestionStage = "cardColors"         // card colors could not be retrieved - references are not ordered by color
+	RankingSignalsStage     SuggestionStage = "rankingSignals"     // views or release dates used by the requested sort could not be retrieved - references use the default order
+)
+
+// sub-operation that failed while generating a response
+type StageError struct {
+	Stage      SuggestionStage `json:"stage"`
+	Message    string          `json:"message"`
+	StatusCode int             `json:"statusCode"`
+}
+
+// Set when a downstream call failed and part of the response is missing - everything that was resolved is still returned.
+// Lets clients tell "no references" apart from "lookup failed".
+type Degradation struct {
+	Degraded bool         `json:"degraded"`
+	Errors   []StageError `json:"errors,omitempty"`
+}
+
+func (d *Degradation) Fail(stage SuggestionStage, err *cModel.APIError) {
+	d.Degraded = true
+	if !d.Failed(stage) {
+		d.Errors = append(d.Errors, StageError{Stage: stage, Message: err.Message, StatusCode: err.StatusCode})
+	}
+}
+
+func (d Degradation) Failed(stage SuggestionStage) bool {
+	return slices.ContainsFunc(d.Errors, func(e StageError) bool { return e.Stage == stage })
+}
+
+// combines failures of a nested response
+func (d *Degradation) Merge(other Degradation) {
+	for _, e := range other.Errors {
+		d.Fail(e.Stage, &cModel.APIError{Message: e.Message, StatusCode: e.StatusCode})
+	}
 }
 
 type CardSuggestions struct {
+	Degradation
 	Card                 cModel.YGOCard  `json:"card"`
 	HasSelfReference     bool            `json:"hasSelfReference"`
 	NamedMaterials       []CardReference `json:"namedMaterials"`
@@ -17,9 +106,21 @@ type CardSuggestions struct {
 	RelevantArchetypes   []string        `json:"relevantArchetypes"`
 	MaterialArchetypes   []string        `json:"materialArchetypes"`
 	ReferencedArchetypes []string        `json:"referencedArchetypes"`
+	TreatedAs            []string        `json:"treatedAs"`   // archetypes the card is always treated as part of - included in RelevantArchetypes
+	NameAliases          []string        `json:"nameAliases"` // other names of the card, eg: This card's name becomes "Y"
+}
+
+// draft/custom card whose text should be analyzed - materials are only needed when they aren't part of the effect
+type CardAnalysisRequest struct {
+	Name        string `json:"cardName" validate:"required,max=150"`
+	Effect      string `json:"cardEffect" validate:"required,max=2500"`
+	MonsterType string `json:"monsterType" validate:"omitempty,max=40"`
+	Materials   string `json:"materials" validate:"omitempty,max=500"`
 }
 
 type BatchCardSuggestions[RK cModel.YGOResourceKey] struct {
+	Degradation
+	Pagination
 	NamedMaterials        []CardReference `json:"namedMaterials"`
 	NamedReferences       []CardReference `json:"namedReferences"`
 	RelevantArchetypes    []string        `json:"relevantArchetypes"`
@@ -33,32 +134,59 @@ type CardSupport struct {
 	Card         cModel.YGOCard  `json:"card"`
 	ReferencedBy []CardReference `json:"referencedBy"`
 	MaterialFor  []CardReference `json:"materialFor"`
+	AliasedBy    []CardReference `json:"aliasedBy"` // cards whose name becomes or is always treated as the name of Card
 }
 
 type BatchCardSupport[RK cModel.YGOResourceKey] struct {
+	Degradation
+	Pagination
 	ReferencedBy          []CardReference `json:"referencedBy"`
 	MaterialFor           []CardReference `json:"materialFor"`
+	AliasedBy             []CardReference `json:"aliasedBy"`
 	UnknownResources      RK              `json:"unknownResources"`
 	IntersectingResources RK              `json:"falsePositives"`
 }
 
+// named materials and named references are paginated
+func (s *BatchCardSuggestions[RK]) Paginate(page Page) {
+	s.SetNextCursor(page, PaginateLists(page, &s.NamedMaterials, &s.NamedReferences))
+}
+
+// every list of references is paginated
+func (s *BatchCardSupport[RK]) Paginate(page Page) {
+	s.SetNextCursor(page, PaginateLists(page, &s.ReferencedBy, &s.MaterialFor, &s.AliasedBy))
+}
+
 type ProductSuggestions[RK cModel.YGOResourceKey] struct {
+	Degradation
+	Pagination
 	Suggestions BatchCardSuggestions[RK] `json:"suggestions"`
 	Support     BatchCardSupport[RK]     `json:"support"`
 }
 
+// suggestions and support are paginated using the same cursor
+func (s *ProductSuggestions[RK]) Paginate(page Page) {
+	s.Suggestions.Paginate(page)
+	s.Support.Paginate(page)
+	s.SetNextCursor(page, s.Suggestions.NextCursor != "" || s.Support.NextCursor != "")
+}
+
 type ArchetypalSuggestions struct {
-	Total      int              `json:"total"`
-	UsingName  []cModel.YGOCard `json:"usingName"`
-	UsingText  []cModel.YGOCard `json:"usingText"`
-	Exclusions []cModel.YGOCard `json:"exclusions"`
+	Pagination
+	Total      int                `json:"total"` // cards in the archetype, not just the current page
+	UsingName  []cModel.YGOCard   `json:"usingName"`
+	UsingText  []cModel.YGOCard   `json:"usingText"`
+	Exclusions []cModel.YGOCard   `json:"exclusions"`
+	Popularity map[string]float64 `json:"popularity,omitempty"` // card ID -> popularity score, only included when requested
 }
 
 type ArchetypeMembers struct {
-	Archetype        string           `bson:"archetype" json:"archetype"`
-	InheritMembers   []cModel.YGOCard `bson:"inheritMembers" json:"inheritMembers"`
-	QualifiedMembers []cModel.YGOCard `bson:"qualifiedMembers" json:"qualifiedMembers"`
-	ExcludedMembers  []cModel.YGOCard `bson:"excludedMembers" json:"excludedMembers"`
+	Pagination       `bson:"-"`
+	Archetype        string             `bson:"archetype" json:"archetype"`
+	InheritMembers   []cModel.YGOCard   `bson:"inheritMembers" json:"inheritMembers"`
+	QualifiedMembers []cModel.YGOCard   `bson:"qualifiedMembers" json:"qualifiedMembers"`
+	ExcludedMembers  []cModel.YGOCard   `bson:"excludedMembers" json:"excludedMembers"`
+	Popularity       map[string]float64 `bson:"-" json:"popularity,omitempty"` // card ID -> popularity score, only included when requested
 }
 
 // looks for a self reference, if a self reference is found it is removed from original slice
@@ -85,11 +213,112 @@ func RemoveSelfReference(self string, cr *[]CardReference) bool {
 }
 
 type VectorSearchResult struct {
-	ID   string `bson:"id"`
-	Text string `bson:"text"`
+	ID                string  `bson:"id"`
+	Text              string  `bson:"text"`
+	CosineSimilarity  float64 `bson:"cosineSimilarity"`
+	SharedType        int     `bson:"sharedType"`
+	SharedAttribute   int     `bson:"sharedAttribute"`
+	SharedMonsterType int     `bson:"sharedMonsterType"`
+	FinalScore        float64 `bson:"finalScore"` // cosine similarity + boosts
+	RerankScore       float64 `bson:"-"`
+}
+
+// document stored in the cardEmbedding collection - textEmbedding is the vector used by $vectorSearch
+type CardEmbedding struct {
+	ID            string    `bson:"id" json:"id"`
+	Text          string    `bson:"text" json:"text"`
+	Type          string    `bson:"type" json:"type"`
+	Attribute     string    `bson:"attribute" json:"attribute"`
+	MonsterType   string    `bson:"monsterType" json:"monsterType"`
+	Level         *int      `bson:"level,omitempty" json:"level,omitempty"`             // level, rank or link rating - not every document has one
+	ReleaseDate   string    `bson:"releaseDate,omitempty" json:"releaseDate,omitempty"` // YYYY-MM-DD of the earliest product the card was ingested from
+	TextEmbedding []float32 `bson:"textEmbedding" json:"textEmbedding"`
 }
 
+// Cards with more than one cardEmbedding document - Removed stays 0 until the removal is confirmed
+type CardEmbeddingDedupe struct {
+	CardIDs    []string `json:"cardIDs"`
+	Duplicates int      `json:"duplicates"` // documents that are removed once confirmed, one document of each card is kept
+	Removed    int64    `json:"removed"`
+	Confirmed  bool     `json:"confirmed"`
+}
+
+// document stored in the embeddingCache collection - key is the card ID combined with a hash of the text, model and input type
+type CachedEmbedding struct {
+	Key       string    `bson:"key"`
+	CardID    string    `bson:"cardID"`
+	Model     string    `bson:"model"`
+	TextHash  string    `bson:"textHash"`
+	Embedding []float32 `bson:"embedding"`
+	CreatedAt time.Time `bson:"createdAt"`
+}
+
+type RankingStage string
+
+const (
+	RerankStage RankingStage = "rerank" // results ordered by the reranker
+	VectorStage RankingStage = "vector" // reranker was unavailable, results are ordered by vector search score
+)
+
 type SimilarCards struct {
-	Card    cModel.YGOCard   `json:"card"`
-	Matches []cModel.YGOCard `json:"matches"`
+	Card         cModel.YGOCard     `json:"card"`
+	Matches      []cModel.YGOCard   `json:"matches"`
+	Scores       []SimilarityScore  `json:"scores,omitempty"`     // same order as Matches, only included when requested
+	Popularity   map[string]float64 `json:"popularity,omitempty"` // card ID -> popularity score of each match, only included when requested
+	RankingStage RankingStage       `json:"rankingStage"`
+}
+
+type BatchSimilarCards[RK cModel.YGOResourceKey] struct {
+	Subjects              []SimilarCards  `json:"subjects"`
+	Aggregate             []CardReference `json:"aggregate"` // occurrences is the number of subjects a card is similar to
+	UnknownResources      RK              `json:"unknownResources"`
+	IntersectingResources RK              `json:"falsePositives"`
+}
+
+// breakdown of how a similar card was scored - boosts are the values added to the vector score
+type SimilarityScore struct {
+	CardID            string  `json:"cardID"`
+	VectorScore       float64 `json:"vectorScore"`
+	SharedType        float64 `json:"sharedTypeBoost"`
+	SharedAttribute   float64 `json:"sharedAttributeBoost"`
+	SharedMonsterType float64 `json:"sharedMonsterTypeBoost"`
+	FinalScore        float64 `json:"finalScore"`
+	RerankScore       float64 `json:"rerankScore"`
+}
+
+// restricts which cards are considered by vector search - empty values are ignored
+type VectorSearchFilter struct {
+	Color       string
+	Attribute   string
+	MonsterType string
+	MinLevel    *int
+	MaxLevel    *int
+	ExcludedIDs []string
+	Limit       int // number of results, 0 uses default
+}
+
+// controls for similar card requests, parsed from query params
+type SimilarCardsFilter struct {
+	Color            string `validate:"max=30"`
+	Attribute        string `validate:"omitempty,cardattribute"`
+	MonsterType      string `validate:"max=40"`
+	ExcludeArchetype bool
+	Limit            int `validate:"min=1,max=50,ltefield=TopK"` // number of matches returned
+	TopK             int `validate:"min=1,max=100"`              // number of vector search candidates that are re-ranked
+}
+
+type CardSearchQuery struct {
+	Query string `validate:"required,searchquery"`
+}
+
+type ScoredCard struct {
+	Card        cModel.YGOCard `json:"card"`
+	VectorScore float64        `json:"vectorScore"`
+	RerankScore float64        `json:"rerankScore"`
+}
+
+type CardSearchResults struct {
+	Query        string       `json:"query"`
+	Results      []ScoredCard `json:"results"`
+	RankingStage RankingStage `json:"rankingStage"`
 }
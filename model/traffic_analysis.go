@@ -55,8 +55,35 @@ type TrafficResourceUtilizationMetric struct {
 }
 
 type Trending struct {
-	ResourceName ResourceName     `json:"resourceName"`
-	Metrics      []TrendingMetric `json:"metrics"`
+	ResourceName ResourceName       `json:"resourceName"`
+	Window       string             `json:"window"`
+	Compare      TrendingComparison `json:"compare"`
+	Metrics      []TrendingMetric   `json:"metrics"`
+}
+
+// Which period the current window is compared against to determine the change in position of each resource
+type TrendingComparison string
+
+const (
+	PreviousWindowComparison TrendingComparison = "previous" // window right before the current one
+	LastWeekComparison       TrendingComparison = "lastWeek" // current window shifted back 7 days - only for windows of 7 days or less so the two periods don't overlap
+)
+
+// longest window that can be compared with the same window last week
+const MaxLastWeekComparisonWindow = 7 * 24 * time.Hour
+
+// windows clients can choose from
+var TrendingWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"10d": 10 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+type TrendingQuery struct {
+	Window  string             `validate:"trendingwindow"`
+	Limit   int                `validate:"min=1,max=50"` // number of resources returned
+	Compare TrendingComparison `validate:"trendingcomparison"`
 }
 
 type TrendingMetric struct {
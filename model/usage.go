@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+type UsageKind string
+
+const (
+	EmbeddingUsage UsageKind = "embedding"
+	RerankUsage    UsageKind = "rerank"
+)
+
+// tokens used by a single operation (eg: Similar Cards) during the day
+type OperationTokenUsage struct {
+	Calls           int `bson:"calls" json:"calls"`
+	EmbeddingTokens int `bson:"embeddingTokens" json:"embeddingTokens"`
+	RerankTokens    int `bson:"rerankTokens" json:"rerankTokens"`
+}
+
+// document stored in the tokenUsage collection - one document per day (UTC)
+type DailyTokenUsage struct {
+	Date             string                         `bson:"date" json:"date"`
+	Operations       map[string]OperationTokenUsage `bson:"operations" json:"operations"`
+	TotalTokens      int                            `bson:"totalTokens" json:"totalTokens"`
+	EstimatedCostUSD float64                        `bson:"estimatedCostUSD" json:"estimatedCostUSD"`
+	UpdatedAt        time.Time                      `bson:"updatedAt" json:"updatedAt"`
+}
+
+type TokenUsageReport struct {
+	DailyTokenUsage
+	Budget         int  `json:"budget"` // 0 when no budget is configured
+	BudgetExceeded bool `json:"budgetExceeded"`
+}
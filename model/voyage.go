@@ -18,6 +18,12 @@ type EmbeddingResponse struct {
 	Object string `json:"object"`
 	Data   []Data `json:"data"`
 	Model  string `json:"model"`
+	Usage  Usage  `json:"usage"`
+}
+
+// tokens billed for a single provider call
+type Usage struct {
+	TotalTokens int `json:"total_tokens"`
 }
 
 type Data struct {
@@ -33,10 +39,32 @@ type RerankRequest struct {
 }
 
 type RerankResponse struct {
-	Data []RerankResults `json:"data"`
+	Data  []RerankResults `json:"data"`
+	Usage Usage           `json:"usage"`
 }
 
 type RerankResults struct {
 	Index int     `json:"index"`
 	Score float64 `json:"relevance_score"`
 }
+
+// OpenAI compatible embeddings request - the response uses the same shape as EmbeddingResponse
+type OpenAIEmbeddingRequest struct {
+	Input          []string `json:"input"`
+	Model          string   `json:"model"`
+	Dimensions     int      `json:"dimensions,omitempty"`
+	EncodingFormat string   `json:"encoding_format,omitempty"`
+}
+
+// rerank request/response shared by most non Voyage providers
+type CompatibleRerankRequest struct {
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	Model     string   `json:"model,omitempty"`
+	TopN      uint8    `json:"top_n"`
+}
+
+type CompatibleRerankResponse struct {
+	Results []RerankResults `json:"results"`
+	Usage   Usage           `json:"usage"`
+}
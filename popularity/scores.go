@@ -0,0 +1,131 @@
+package popularity
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
+	"github.com/ygo-skc/skc-suggestion-engine/db"
+)
+
+const (
+	defaultWindow          = 30 * 24 * time.Hour
+	defaultRefreshInterval = time.Hour
+)
+
+// Popularity of every recently viewed card, precomputed from trafficAnalysis on a schedule so requests never aggregate traffic data.
+// Scores are between 0 and 1 - the most viewed card has a score of 1 and cards without views in the window have a score of 0.
+type Scores struct {
+	mu     sync.RWMutex
+	scores map[string]float64 // card ID -> score, only cards with views
+
+	dao             db.SKCSuggestionEngineDAO // nil = scores are never refreshed
+	window          time.Duration             // views older than this are ignored
+	refreshInterval time.Duration
+}
+
+// Scores used by suggestion, support, archetype and similar card endpoints
+var CardScores = NewScores(nil, defaultWindow, defaultRefreshInterval)
+
+func NewScores(dao db.SKCSuggestionEngineDAO, window time.Duration, refreshInterval time.Duration) *Scores {
+	return &Scores{scores: make(map[string]float64), dao: dao, window: window, refreshInterval: refreshInterval}
+}
+
+// Uses POPULARITY_WINDOW (Go duration, defaults to 720h) and POPULARITY_REFRESH_INTERVAL (Go duration, defaults to 1h) env variables to configure the scores.
+func NewScoresFromEnv(dao db.SKCSuggestionEngineDAO) *Scores {
+	window, refreshInterval := defaultWindow, defaultRefreshInterval
+	if value := cUtil.EnvMap["POPULARITY_WINDOW"]; value != "" {
+		var err error
+		if window, err = time.ParseDuration(value); err != nil || window <= 0 {
+			log.Fatalf("POPULARITY_WINDOW is not a positive duration: %s", value)
+		}
+	}
+	if value := cUtil.EnvMap["POPULARITY_REFRESH_INTERVAL"]; value != "" {
+		var err error
+		if refreshInterval, err = time.ParseDuration(value); err != nil || refreshInterval <= 0 {
+			log.Fatalf("POPULARITY_REFRESH_INTERVAL is not a positive duration: %s", value)
+		}
+	}
+
+	slog.Info("Configured card popularity", slog.Duration("window", window), slog.Duration("refresh_interval", refreshInterval))
+	return NewScores(dao, window, refreshInterval)
+}
+
+// Computes scores then refreshes them periodically until ctx is cancelled
+func (s *Scores) Run(ctx context.Context) {
+	s.refreshAndLog(ctx)
+
+	ticker := time.NewTicker(s.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.refreshAndLog(ctx)
+		}
+	}
+}
+
+func (s *Scores) refreshAndLog(ctx context.Context) {
+	if err := s.Refresh(ctx); err != nil {
+		cUtil.RetrieveLogger(ctx).Error("Could not refresh card popularity - previous scores are kept", slog.Any("err", err))
+	}
+}
+
+// Recomputes scores using views within the window. Scores are unchanged when views can't be retrieved.
+func (s *Scores) Refresh(ctx context.Context) *cModel.APIError {
+	if s.dao == nil {
+		return nil
+	}
+
+	views, err := s.dao.GetCardViews(ctx, time.Now().Add(-s.window))
+	if err != nil {
+		return err
+	}
+	s.compute(views)
+	cUtil.RetrieveLogger(ctx).Info("Refreshed card popularity", slog.Int("cards", len(views)))
+	return nil
+}
+
+// views are log scaled so a handful of very popular cards don't push every other card's score to ~0
+func (s *Scores) compute(views map[string]int) {
+	maxViews := 0
+	for _, v := range views {
+		maxViews = max(maxViews, v)
+	}
+
+	scores := make(map[string]float64, len(views))
+	for cardID, v := range views {
+		if v > 0 {
+			scores[cardID] = math.Round(math.Log1p(float64(v))/math.Log1p(float64(maxViews))*1000) / 1000
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scores = scores
+}
+
+func (s *Scores) Score(cardID string) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.scores[cardID]
+}
+
+// Score of each card, keyed by card ID - every requested card is included
+func (s *Scores) Of(cardIDs []string) map[string]float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	scores := make(map[string]float64, len(cardIDs))
+	for _, cardID := range cardIDs {
+		scores[cardID] = s.scores[cardID]
+	}
+	return scores
+}
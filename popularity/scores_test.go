@@ -0,0 +1,38 @@
+package popularity
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	skc_testing "github.com/ygo-skc/skc-suggestion-engine/testing"
+)
+
+type cardViewsDAO struct {
+	skc_testing.SKCSuggestionEngineDAOImplementation
+	views map[string]int
+	err   *cModel.APIError
+}
+
+func (impl *cardViewsDAO) GetCardViews(ctx context.Context, from time.Time) (map[string]int, *cModel.APIError) {
+	return impl.views, impl.err
+}
+
+func TestScores(t *testing.T) {
+	assert := assert.New(t)
+	dao := &cardViewsDAO{views: map[string]int{"46986414": 99, "38033121": 9, "50237654": 0}}
+	scores := NewScores(dao, time.Hour, time.Hour)
+
+	assert.Equal(map[string]float64{"46986414": 0}, scores.Of([]string{"46986414"}), "Scores should be 0 until the first refresh")
+
+	assert.Nil(scores.Refresh(skc_testing.TestContext))
+	assert.Equal(map[string]float64{"46986414": 1, "38033121": 0.5, "50237654": 0, "89631139": 0},
+		scores.Of([]string{"46986414", "38033121", "50237654", "89631139"}), "Views should be log scaled relative to the most viewed card")
+
+	dao.err = &cModel.APIError{Message: "Could not get traffic data.", StatusCode: http.StatusInternalServerError}
+	assert.NotNil(scores.Refresh(skc_testing.TestContext))
+	assert.Equal(0.5, scores.Score("38033121"), "Previous scores should be kept when views can't be retrieved")
+}
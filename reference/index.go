@@ -0,0 +1,419 @@
+package reference
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
+	"github.com/ygo-skc/skc-suggestion-engine/db"
+	"github.com/ygo-skc/skc-suggestion-engine/downstream"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+	"github.com/ygo-skc/skc-suggestion-engine/suggest"
+)
+
+const (
+	cardFetchBatchSize       = 500
+	referenceSearchBatchSize = 50 // names searched in a single ygo-service call
+	defaultRefreshInterval   = 24 * time.Hour
+)
+
+// Splits references into cards that reference subject in their effect and cards that use subject as a material
+type SupportClassifier func(subject cModel.YGOCard, references []cModel.YGOCard) ([]model.CardReference, []model.CardReference)
+
+// immutable once built - a refresh swaps the whole snapshot
+type snapshot struct {
+	cardsByID    cModel.CardDataMap // subjects and the cards referencing them
+	cardsByName  cModel.CardDataMap
+	subjects     map[string]struct{} // names of the cards whose references were searched
+	referencedBy map[string][]string // card name -> IDs of cards referencing the name in their effect
+	materialFor  map[string][]string // card name -> IDs of cards using the name as a material
+	aliasedBy    map[string][]string // card name -> IDs of cards whose name becomes or is always treated as the name
+	builtAt      time.Time
+}
+
+// Inverted index of card name -> cards referencing that name. Subjects are cards with a cardEmbedding document -
+// the cards referencing them are found in the background by asking ygo-service to search the text of every card, a batch of names at a time.
+// Lets support and suggestions be served w/o asking ygo-service to scan the text of every card on each request.
+type Index struct {
+	mu         sync.RWMutex
+	current    *snapshot // nil until the first build/load completes
+	refreshing bool
+	lastErr    string
+
+	classify        SupportClassifier
+	dao             db.SKCSuggestionEngineDAO // nil = index is not persisted
+	refreshInterval time.Duration
+}
+
+func NewIndex(dao db.SKCSuggestionEngineDAO, classify SupportClassifier) *Index {
+	return &Index{classify: classify, dao: dao, refreshInterval: defaultRefreshInterval}
+}
+
+// Uses REFERENCE_INDEX_REFRESH_INTERVAL env variable (Go duration, eg: 12h - defaults to 24h, 0 disables periodic refreshes) to configure the index.
+func NewIndexFromEnv(dao db.SKCSuggestionEngineDAO, classify SupportClassifier) *Index {
+	i := NewIndex(dao, classify)
+	if value := cUtil.EnvMap["REFERENCE_INDEX_REFRESH_INTERVAL"]; value != "" {
+		var err error
+		if i.refreshInterval, err = time.ParseDuration(value); err != nil {
+			log.Fatalf("REFERENCE_INDEX_REFRESH_INTERVAL is not a duration: %v", err)
+		}
+	}
+
+	slog.Info("Configured reference index", slog.Duration("refresh_interval", i.refreshInterval))
+	return i
+}
+
+// Loads the last snapshot, rebuilding it when missing or stale, then rebuilds periodically until ctx is cancelled
+func (i *Index) Run(ctx context.Context) {
+	if err := i.Load(ctx); err != nil {
+		cUtil.RetrieveLogger(ctx).Warn("Could not load reference index snapshot", slog.Any("err", err))
+	}
+
+	if status := i.Status(); !status.Ready || (i.refreshInterval > 0 && time.Since(*status.BuiltAt) >= i.refreshInterval) {
+		i.Refresh(ctx)
+	}
+	if i.refreshInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(i.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			i.Refresh(ctx)
+		}
+	}
+}
+
+// Starts a refresh in the background. False is returned if a refresh is already running.
+func (i *Index) Start(ctx context.Context) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.refreshing {
+		return false
+	}
+	i.refreshing = true
+
+	// refresh outlives the request that started it
+	go i.refresh(context.WithoutCancel(ctx))
+	return true
+}
+
+// Rebuilds the index using the current text of every card, waiting for the rebuild to finish. No-op if a refresh is already running.
+func (i *Index) Refresh(ctx context.Context) {
+	i.mu.Lock()
+	if i.refreshing {
+		i.mu.Unlock()
+		return
+	}
+	i.refreshing = true
+	i.mu.Unlock()
+
+	i.refresh(ctx)
+}
+
+// caller must set i.refreshing
+func (i *Index) refresh(ctx context.Context) {
+	logger := cUtil.RetrieveLogger(ctx)
+	logger.Info("Refreshing reference index")
+
+	err := i.rebuild(ctx)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.refreshing = false
+	if err != nil {
+		i.lastErr = err.Message
+		logger.Error("Could not refresh reference index", slog.Any("err", err))
+	} else {
+		i.lastErr = ""
+	}
+}
+
+func (i *Index) rebuild(ctx context.Context) *cModel.APIError {
+	if i.dao == nil {
+		return nil
+	}
+
+	cardIDs, err := i.dao.GetCardEmbeddingIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	subjects, err := fetchCards(ctx, cardIDs)
+	if err != nil {
+		return err
+	}
+	return i.Build(ctx, subjects)
+}
+
+// Replaces the index with one covering subjects - the result is persisted so it can be loaded after a restart
+func (i *Index) Build(ctx context.Context, subjects []cModel.YGOCard) *cModel.APIError {
+	s := newSnapshot(subjects, time.Now().UTC())
+	names := slices.Sorted(maps.Keys(s.cardsByName)) // subjects sharing a name are searched once
+	for name := range s.cardsByName {
+		s.subjects[name] = struct{}{}
+	}
+
+	for batch := range slices.Chunk(names, referenceSearchBatchSize) {
+		referencesProto, err := downstream.YGO.CardService.GetCardsReferencingNameInEffectProto(ctx, batch)
+		if err != nil {
+			return err
+		}
+		references := cModel.YGOCardListRESTFromProto(referencesProto)
+		s.add(references)
+
+		for _, name := range batch {
+			referencedBy, materialFor := i.classify(s.cardsByName[name], references)
+			if len(referencedBy) > 0 {
+				s.referencedBy[name] = referenceIDs(referencedBy)
+			}
+			if len(materialFor) > 0 {
+				s.materialFor[name] = referenceIDs(materialFor)
+			}
+		}
+
+		for _, reference := range references {
+			for _, alias := range suggest.FindAliases(reference.GetEffect()).NameAliases {
+				if alias != reference.GetName() && slices.Contains(batch, alias) {
+					s.aliasedBy[alias] = append(s.aliasedBy[alias], reference.GetID())
+				}
+			}
+		}
+	}
+
+	i.swap(s)
+	cUtil.RetrieveLogger(ctx).Info("Built reference index", slog.Int("subjects", len(s.subjects)), slog.Int("cards", len(s.cardsByID)),
+		slog.Int("referenced_names", s.referencedNames()))
+
+	if i.dao != nil {
+		return i.dao.ReplaceReferenceIndex(ctx, s.entries())
+	}
+	return nil
+}
+
+// Restores the last persisted snapshot. Card data is fetched again as only IDs are persisted.
+func (i *Index) Load(ctx context.Context) *cModel.APIError {
+	if i.dao == nil {
+		return nil
+	}
+
+	entries, err := i.dao.GetReferenceIndex(ctx)
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+	if !entries[0].Searched {
+		cUtil.RetrieveLogger(ctx).Warn("Reference index snapshot was built from embedded cards only - it will be rebuilt")
+		return nil
+	}
+
+	cardIDs := make(cModel.CardIDs, 0, len(entries))
+	for _, entry := range entries {
+		for _, cardID := range slices.Concat([]string{entry.CardID}, entry.ReferencedBy, entry.MaterialFor, entry.AliasedBy) {
+			if !slices.Contains(cardIDs, cardID) {
+				cardIDs = append(cardIDs, cardID)
+			}
+		}
+	}
+	cards, err := fetchCards(ctx, cardIDs)
+	if err != nil {
+		return err
+	}
+
+	s := newSnapshot(cards, entries[0].BuiltAt)
+	for _, entry := range entries {
+		s.subjects[entry.Name] = struct{}{}
+		if len(entry.ReferencedBy) > 0 {
+			s.referencedBy[entry.Name] = entry.ReferencedBy
+		}
+		if len(entry.MaterialFor) > 0 {
+			s.materialFor[entry.Name] = entry.MaterialFor
+		}
+		if len(entry.AliasedBy) > 0 {
+			s.aliasedBy[entry.Name] = entry.AliasedBy
+		}
+	}
+
+	i.swap(s)
+	cUtil.RetrieveLogger(ctx).Info("Loaded reference index snapshot", slog.Int("subjects", len(s.subjects)), slog.Time("built_at", s.builtAt))
+	return nil
+}
+
+func (i *Index) swap(s *snapshot) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.current = s
+}
+
+// nil when the index isn't ready - safe to call on a nil Index
+func (i *Index) snapshot() *snapshot {
+	if i == nil {
+		return nil
+	}
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.current
+}
+
+// Cards referencing subject in their effect, cards using subject as a material and cards aliasing subject.
+// False is returned if the index isn't ready or subject isn't one of its subjects - ygo-service should be used instead.
+func (i *Index) Support(subject cModel.YGOCard) (model.CardSupport, bool) {
+	s := i.snapshot()
+	if s == nil {
+		return model.CardSupport{}, false
+	}
+	if _, isSubject := s.subjects[subject.GetName()]; !isSubject {
+		return model.CardSupport{}, false
+	}
+
+	// only a few cards reference subject - classifying them again is cheap and gives the intent of each reference
+	name := subject.GetName()
+	referencedBy, materialFor := i.classify(subject, s.cards(slices.Concat(s.referencedBy[name], s.materialFor[name])))
+	return model.CardSupport{Card: subject, ReferencedBy: referencedBy, MaterialFor: materialFor, AliasedBy: s.references(s.aliasedBy[name])}, true
+}
+
+// Resolves names to cards. The index doesn't know every card so false is returned if it isn't ready or a name is missing from it.
+func (i *Index) CardsByName(names []string) (cModel.CardDataMap, bool) {
+	s := i.snapshot()
+	if s == nil {
+		return nil, false
+	}
+
+	cards := make(cModel.CardDataMap, len(names))
+	for _, name := range names {
+		card, isPresent := s.cardsByName[name]
+		if !isPresent {
+			return nil, false // name might belong to a card that isn't part of the index
+		}
+		cards[name] = card
+	}
+	return cards, true
+}
+
+func (i *Index) Status() model.ReferenceIndexStatus {
+	s := i.snapshot()
+
+	i.mu.RLock()
+	status := model.ReferenceIndexStatus{Refreshing: i.refreshing, LastError: i.lastErr}
+	i.mu.RUnlock()
+
+	if s != nil {
+		builtAt := s.builtAt
+		status.Ready, status.BuiltAt, status.Cards, status.ReferencedNames = true, &builtAt, len(s.subjects), s.referencedNames()
+	}
+	return status
+}
+
+func newSnapshot(cards []cModel.YGOCard, builtAt time.Time) *snapshot {
+	s := snapshot{
+		cardsByID:    make(cModel.CardDataMap, len(cards)),
+		cardsByName:  make(cModel.CardDataMap, len(cards)),
+		subjects:     make(map[string]struct{}),
+		referencedBy: make(map[string][]string),
+		materialFor:  make(map[string][]string),
+		aliasedBy:    make(map[string][]string),
+		builtAt:      builtAt,
+	}
+	s.add(cards)
+	return &s
+}
+
+// only used while building - cards already in the snapshot are kept
+func (s *snapshot) add(cards []cModel.YGOCard) {
+	for _, card := range cards {
+		if _, isPresent := s.cardsByID[card.GetID()]; !isPresent {
+			s.cardsByID[card.GetID()] = card
+		}
+		if _, isPresent := s.cardsByName[card.GetName()]; !isPresent {
+			s.cardsByName[card.GetName()] = card
+		}
+	}
+}
+
+func referenceIDs(references []model.CardReference) []string {
+	cardIDs := make([]string, len(references))
+	for ind, reference := range references {
+		cardIDs[ind] = reference.Card.GetID()
+	}
+	return cardIDs
+}
+
+// unique cards with the given IDs
+func (s *snapshot) cards(cardIDs []string) []cModel.YGOCard {
+	cards := make([]cModel.YGOCard, 0, len(cardIDs))
+	seen := make(map[string]struct{}, len(cardIDs))
+	for _, cardID := range cardIDs {
+		if _, isSeen := seen[cardID]; isSeen {
+			continue
+		}
+		seen[cardID] = struct{}{}
+		if card, isPresent := s.cardsByID[cardID]; isPresent {
+			cards = append(cards, card)
+		}
+	}
+	return cards
+}
+
+func (s *snapshot) references(cardIDs []string) []model.CardReference {
+	references := make([]model.CardReference, 0, len(cardIDs))
+	for _, cardID := range cardIDs {
+		if card, isPresent := s.cardsByID[cardID]; isPresent {
+			references = append(references, model.CardReference{Occurrences: 1, Card: card})
+		}
+	}
+	return references
+}
+
+func (s *snapshot) referencedNames() int {
+	names := make(map[string]struct{}, len(s.referencedBy)+len(s.aliasedBy))
+	for name := range s.referencedBy {
+		names[name] = struct{}{}
+	}
+	for name := range s.materialFor {
+		names[name] = struct{}{}
+	}
+	for name := range s.aliasedBy {
+		names[name] = struct{}{}
+	}
+	return len(names)
+}
+
+func (s *snapshot) entries() []model.ReferenceIndexEntry {
+	entries := make([]model.ReferenceIndexEntry, 0, len(s.subjects))
+	for name := range s.subjects {
+		entries = append(entries, model.ReferenceIndexEntry{
+			CardID:       s.cardsByName[name].GetID(),
+			Name:         name,
+			ReferencedBy: s.referencedBy[name],
+			MaterialFor:  s.materialFor[name],
+			AliasedBy:    s.aliasedBy[name],
+			BuiltAt:      s.builtAt,
+			Searched:     true,
+		})
+	}
+	return entries
+}
+
+func fetchCards(ctx context.Context, cardIDs cModel.CardIDs) ([]cModel.YGOCard, *cModel.APIError) {
+	cards := make([]cModel.YGOCard, 0, len(cardIDs))
+	for batch := range slices.Chunk(cardIDs, cardFetchBatchSize) {
+		cardsProto, err := downstream.YGO.CardService.GetCardsByIDProto(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		for _, card := range cModel.BatchCardDataFromProto[cModel.CardIDs](cardsProto, cModel.CardIDAsKey).CardInfo {
+			cards = append(cards, card)
+		}
+	}
+	return cards, nil
+}
@@ -0,0 +1,89 @@
+package suggest
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+)
+
+const sequentialActionSeparator = ", then"
+
+type intentMatcher struct {
+	intent model.ReferenceIntent
+	regex  *regexp.Regexp
+}
+
+var (
+	// order determines the order intents are returned in
+	intentMatchers = []intentMatcher{
+		{intent: model.SearchIntent, regex: regexp.MustCompile(`(?i)\badd\b.*\bfrom (?:your|their) Deck\b|\bfrom (?:your|their) Deck to (?:your|their) hand\b`)},
+		{intent: model.SpecialSummonIntent, regex: regexp.MustCompile(`(?i)\bSpecial Summon`)},
+		{intent: model.SendToGYIntent, regex: regexp.MustCompile(`(?i)\b(?:send|sends|sending|sent)\b.*\bto the (?:GY|Graveyard)\b`)},
+		{intent: model.NegateIntent, regex: regexp.MustCompile(`(?i)\bnegate`)},
+		{intent: model.BanishIntent, regex: regexp.MustCompile(`(?i)\bbanish`)},
+		{intent: model.IfYouControlIntent, regex: regexp.MustCompile(`(?i)\b(?:if|while) you control\b`)},
+	}
+)
+
+// Intents of every quoted occurrence of name in text. Each occurrence is classified using the clause it is found in,
+// eg: in `discard 1 card; add 1 "Polymerization" from your Deck to your hand` only the clause after the semicolon is used.
+// Aliases (see FindAliases) are not references so they have no intent. Nil is returned when no intent is found.
+func ReferenceIntents(text string, name string) []model.ReferenceIntent {
+	text = quoteReplacer.Replace(text)
+	tokens := Tokenize(text)
+
+	found := make(map[model.ReferenceIntent]struct{})
+	for _, token := range tokens {
+		if token.Text != name || token.Context == TreatedAsContext || token.Context == NameAliasContext {
+			continue
+		}
+
+		clause := surroundingClause(text, tokens, token)
+		for _, matcher := range intentMatchers {
+			if matcher.regex.MatchString(clause) {
+				found[matcher.intent] = struct{}{}
+			}
+		}
+	}
+
+	var intents []model.ReferenceIntent
+	for _, matcher := range intentMatchers {
+		if _, isPresent := found[matcher.intent]; isPresent {
+			intents = append(intents, matcher.intent)
+		}
+	}
+	return intents
+}
+
+// clauses end at a period, semicolon, colon or new line that isn't part of a quoted name (eg: "Lord of D.").
+// Sequential actions are also split, eg: Send this card to the GY, then, you can Special Summon 1 "Dark Magician"
+func surroundingClause(text string, tokens []Token, token Token) string {
+	start, end := token.Start, token.End
+	for start > 0 && !isClauseBoundary(text, tokens, start-1) {
+		start--
+	}
+	for end < len(text) && !isClauseBoundary(text, tokens, end) {
+		end++
+	}
+
+	if i := strings.LastIndex(text[start:token.Start], sequentialActionSeparator); i != -1 {
+		start += i + len(sequentialActionSeparator)
+	}
+	if i := strings.Index(text[token.End:end], sequentialActionSeparator); i != -1 {
+		end = token.End + i
+	}
+	return text[start:end]
+}
+
+func isClauseBoundary(text string, tokens []Token, i int) bool {
+	if !strings.ContainsRune(".;:\n", rune(text[i])) {
+		return false
+	}
+	for _, token := range tokens {
+		if i >= token.Start && i < token.End {
+			return false
+		}
+	}
+	return true
+}
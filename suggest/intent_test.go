@@ -0,0 +1,34 @@
+package suggest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+)
+
+func TestReferenceIntents(t *testing.T) {
+	assert := assert.New(t)
+
+	testCases := []struct {
+		text     string
+		name     string
+		expected []model.ReferenceIntent
+	}{
+		{`You can discard this card; add 1 "Polymerization" from your Deck to your hand.`, "Polymerization", []model.ReferenceIntent{model.SearchIntent}},
+		{`Special Summon 1 "Dark Magician" from your hand or GY.`, "Dark Magician", []model.ReferenceIntent{model.SpecialSummonIntent}},
+		{`Send this card to the GY, then, you can Special Summon 1 "Dark Magician" from your GY.`, "Dark Magician", []model.ReferenceIntent{model.SpecialSummonIntent}},
+		{`You can send 1 "Fusion Parasite" from your Deck to the GY.`, "Fusion Parasite", []model.ReferenceIntent{model.SendToGYIntent}},
+		{`When your opponent activates "Raigeki": You can negate the activation.`, "Raigeki", nil},
+		{`Negate the effects of "Raigeki" and banish it.`, "Raigeki", []model.ReferenceIntent{model.NegateIntent, model.BanishIntent}},
+		{`If you control "Umi": Draw 1 card.`, "Umi", []model.ReferenceIntent{model.IfYouControlIntent}},
+		{`If you control "Lord of D.", banish 1 card.`, "Lord of D.", []model.ReferenceIntent{model.BanishIntent, model.IfYouControlIntent}},
+		{`If "Umi" is on the field, add 1 "Umi" from your Deck to your hand.`, "Umi", []model.ReferenceIntent{model.SearchIntent}},
+		{`This card's name becomes "The Legendary Fisherman" while on the field. Banish this card.`, "The Legendary Fisherman", nil},
+		{`"Dark Magician" + "Buster Blader"`, "Dark Magician", nil},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(testCase.expected, ReferenceIntents(testCase.text, testCase.name), testCase.text)
+	}
+}
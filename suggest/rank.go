@@ -0,0 +1,127 @@
+package suggest
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+)
+
+type RankingStrategy string
+
+const (
+	DefaultRanking     RankingStrategy = "default"     // most occurrences first, then card color (when known), popularity, name and ID
+	PopularityRanking  RankingStrategy = "popularity"  // most popular cards first
+	ReleaseDateRanking RankingStrategy = "releaseDate" // newest cards first - cards without a known release date are last
+	ColorRanking       RankingStrategy = "color"       // card color then name, occurrences are ignored
+	BlendRanking       RankingStrategy = "blend"       // weighted score of occurrences, popularity and release date
+)
+
+// every strategy clients can choose from
+var RankingStrategies = []RankingStrategy{DefaultRanking, PopularityRanking, ReleaseDateRanking, ColorRanking, BlendRanking}
+
+// weights used by BlendRanking - each signal is scaled to [0, 1] relative to the references being ranked
+const (
+	blendOccurrencesWeight = 0.5
+	blendPopularityWeight  = 0.3 // popularity is scaled again so the most popular reference gets the full weight
+	blendRecencyWeight     = 0.2
+
+	releaseDateFormat = "2006-01-02"
+)
+
+// Data strategies use to rank references, keyed by card ID (colors are keyed by card color). Missing data ranks a card last.
+// Popularity breaks ties for every strategy.
+type RankingSignals struct {
+	CardColorIDs map[string]uint32
+	Popularity   map[string]float64 // 0 - 1, see popularity.Scores
+	ReleaseDates map[string]string  // YYYY-MM-DD
+}
+
+// DefaultRanking only uses colors the caller already has - references sorted w/o colors (eg: support) keep that order so popularity refreshes can't change it
+func (s RankingStrategy) UsesCardColors() bool {
+	return s == ColorRanking
+}
+
+func (s RankingStrategy) UsesReleaseDates() bool {
+	return s == ReleaseDateRanking || s == BlendRanking
+}
+
+// Sorts refs in place using the strategy. Ties are broken using the default order so references are always returned in the same order.
+func RankCardReferences(refs []model.CardReference, strategy RankingStrategy, signals RankingSignals) {
+	var compare func(a, b model.CardReference) int
+	switch strategy {
+	case PopularityRanking:
+		compare = func(a, b model.CardReference) int {
+			return cmp.Compare(signals.Popularity[b.Card.GetID()], signals.Popularity[a.Card.GetID()])
+		}
+	case ReleaseDateRanking:
+		// dates sort chronologically as text and a missing date ("") sorts before every date - newest first puts them last
+		compare = func(a, b model.CardReference) int {
+			return cmp.Compare(signals.ReleaseDates[b.Card.GetID()], signals.ReleaseDates[a.Card.GetID()])
+		}
+	case ColorRanking:
+		compare = func(a, b model.CardReference) int {
+			return cmp.Or(
+				cmp.Compare(signals.CardColorIDs[a.Card.GetColor()], signals.CardColorIDs[b.Card.GetColor()]),
+				cmp.Compare(a.Card.GetName(), b.Card.GetName()),
+			)
+		}
+	case BlendRanking:
+		scores := blendScores(refs, signals)
+		compare = func(a, b model.CardReference) int {
+			return cmp.Compare(scores[b.Card.GetID()], scores[a.Card.GetID()])
+		}
+	default:
+		compare = func(a, b model.CardReference) int { return 0 }
+	}
+
+	byDefault := sortCardReferences(signals.CardColorIDs, signals.Popularity)
+	slices.SortStableFunc(refs, func(a, b model.CardReference) int {
+		return cmp.Or(compare(a, b), byDefault(a, b))
+	})
+}
+
+// score of each reference, keyed by card ID - occurrences and popularity are scaled using the largest value, release dates using the oldest and newest date
+func blendScores(refs []model.CardReference, signals RankingSignals) map[string]float64 {
+	maxOccurrences, maxPopularity := 0, 0.0
+	var oldest, newest time.Time
+	releaseDates := make(map[string]time.Time, len(refs))
+	for _, ref := range refs {
+		maxOccurrences = max(maxOccurrences, ref.Occurrences)
+		maxPopularity = max(maxPopularity, signals.Popularity[ref.Card.GetID()])
+
+		if releaseDate, err := time.Parse(releaseDateFormat, signals.ReleaseDates[ref.Card.GetID()]); err == nil {
+			releaseDates[ref.Card.GetID()] = releaseDate
+			if oldest.IsZero() || releaseDate.Before(oldest) {
+				oldest = releaseDate
+			}
+			if releaseDate.After(newest) {
+				newest = releaseDate
+			}
+		}
+	}
+
+	scores := make(map[string]float64, len(refs))
+	for _, ref := range refs {
+		cardID := ref.Card.GetID()
+		score := blendOccurrencesWeight*scale(float64(ref.Occurrences), float64(maxOccurrences)) +
+			blendPopularityWeight*scale(signals.Popularity[cardID], maxPopularity)
+		if releaseDate, isPresent := releaseDates[cardID]; isPresent {
+			if span := newest.Sub(oldest); span > 0 {
+				score += blendRecencyWeight * releaseDate.Sub(oldest).Hours() / span.Hours()
+			} else {
+				score += blendRecencyWeight // every dated card was released the same day
+			}
+		}
+		scores[cardID] = score
+	}
+	return scores
+}
+
+func scale(value, maxValue float64) float64 {
+	if maxValue == 0 {
+		return 0
+	}
+	return value / maxValue
+}
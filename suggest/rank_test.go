@@ -0,0 +1,80 @@
+package suggest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+)
+
+var (
+	rankingColorIDs = map[string]uint32{"Normal": 1, "Effect": 2, "Fusion": 3}
+
+	darkMagician     = model.CardReference{Card: cModel.YGOCardREST{ID: "46986414", Name: "Dark Magician", Color: "Normal"}, Occurrences: 1}
+	darkMagicianGirl = model.CardReference{Card: cModel.YGOCardREST{ID: "38033121", Name: "Dark Magician Girl", Color: "Effect"}, Occurrences: 3}
+	theDarkMagicians = model.CardReference{Card: cModel.YGOCardREST{ID: "50237654", Name: "The Dark Magicians", Color: "Fusion"}, Occurrences: 1}
+	apprentice       = model.CardReference{Card: cModel.YGOCardREST{ID: "09156135", Name: "Apprentice Illusion Magician", Color: "Effect"}, Occurrences: 1}
+)
+
+// card ID of each reference once ranked
+func rank(strategy RankingStrategy, signals RankingSignals) []string {
+	refs := []model.CardReference{darkMagician, darkMagicianGirl, theDarkMagicians, apprentice}
+	RankCardReferences(refs, strategy, signals)
+
+	ids := make([]string, len(refs))
+	for i, ref := range refs {
+		ids[i] = ref.Card.GetID()
+	}
+	return ids
+}
+
+func TestDefaultRanking(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{"38033121", "46986414", "09156135", "50237654"}, rank(DefaultRanking, RankingSignals{CardColorIDs: rankingColorIDs}),
+		"Most occurrences first, then card color and name")
+	assert.Equal([]string{"38033121", "09156135", "46986414", "50237654"}, rank(DefaultRanking, RankingSignals{}), "Colors are ignored when missing")
+	assert.Equal(rank(DefaultRanking, RankingSignals{}), rank("", RankingSignals{}), "Unknown strategies use the default order")
+	assert.Equal([]string{"38033121", "50237654", "09156135", "46986414"}, rank(DefaultRanking, RankingSignals{Popularity: map[string]float64{"50237654": 0.2}}),
+		"Popularity breaks ties before name")
+}
+
+func TestPopularityRanking(t *testing.T) {
+	assert := assert.New(t)
+
+	popularity := map[string]float64{"50237654": 1, "46986414": 0.4, "09156135": 0.4}
+	assert.Equal([]string{"50237654", "46986414", "09156135", "38033121"}, rank(PopularityRanking, RankingSignals{CardColorIDs: rankingColorIDs, Popularity: popularity}),
+		"Most popular first - ties and cards without views use the default order")
+	assert.Equal(rank(DefaultRanking, RankingSignals{}), rank(PopularityRanking, RankingSignals{}), "Nothing was viewed")
+}
+
+func TestReleaseDateRanking(t *testing.T) {
+	assert := assert.New(t)
+
+	releaseDates := map[string]string{"46986414": "2002-03-08", "50237654": "2016-10-01", "09156135": "2016-10-01"}
+	assert.Equal([]string{"09156135", "50237654", "46986414", "38033121"}, rank(ReleaseDateRanking, RankingSignals{ReleaseDates: releaseDates}),
+		"Newest first - cards without a release date are last")
+}
+
+func TestColorRanking(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{"46986414", "09156135", "38033121", "50237654"}, rank(ColorRanking, RankingSignals{CardColorIDs: rankingColorIDs}),
+		"Card color then name - occurrences are ignored")
+}
+
+func TestBlendRanking(t *testing.T) {
+	assert := assert.New(t)
+
+	// Dark Magician Girl: 0.5 (occurrences) | The Dark Magicians: 0.5/3 + 0.3 + 0.2 | Dark Magician: 0.5/3 + 0.15 | Apprentice: 0.5/3 + 0.2
+	signals := RankingSignals{
+		CardColorIDs: rankingColorIDs,
+		Popularity:   map[string]float64{"50237654": 0.8, "46986414": 0.4},
+		ReleaseDates: map[string]string{"46986414": "2002-03-08", "50237654": "2016-10-01", "09156135": "2016-10-01"},
+	}
+	assert.Equal([]string{"50237654", "38033121", "09156135", "46986414"}, rank(BlendRanking, signals))
+
+	assert.Equal(rank(DefaultRanking, signals), rank(BlendRanking, RankingSignals{CardColorIDs: rankingColorIDs}),
+		"Only occurrences are used when there are no views or release dates")
+}
@@ -6,15 +6,21 @@ import (
 	"github.com/ygo-skc/skc-suggestion-engine/model"
 )
 
+// Most occurrences first, then by card color, name and ID - references are always returned in the same order.
+// Colors are ignored when ccIDs is nil.
 func SortCardReferences(ccIDs map[string]uint32) func(a, b model.CardReference) int {
+	return sortCardReferences(ccIDs, nil)
+}
+
+// same as SortCardReferences with popularity (keyed by card ID) breaking ties between references with the same occurrences and color
+func sortCardReferences(ccIDs map[string]uint32, popularity map[string]float64) func(a, b model.CardReference) int {
 	return func(a, b model.CardReference) int {
-		switch {
-		case a.Occurrences != b.Occurrences:
-			return cmp.Compare(b.Occurrences, a.Occurrences)
-		case a.Card.GetColor() != b.Card.GetColor():
-			return cmp.Compare(ccIDs[a.Card.GetColor()], ccIDs[b.Card.GetColor()])
-		default:
-			return cmp.Compare(a.Card.GetName(), b.Card.GetName())
-		}
+		return cmp.Or(
+			cmp.Compare(b.Occurrences, a.Occurrences),
+			cmp.Compare(ccIDs[a.Card.GetColor()], ccIDs[b.Card.GetColor()]),
+			cmp.Compare(popularity[b.Card.GetID()], popularity[a.Card.GetID()]),
+			cmp.Compare(a.Card.GetName(), b.Card.GetName()),
+			cmp.Compare(a.Card.GetID(), b.Card.GetID()),
+		)
 	}
 }
@@ -2,11 +2,9 @@ package suggest
 
 import (
 	"context"
-	"regexp"
 	"sync"
 
 	cModel "github.com/ygo-skc/skc-go/common/v3/model"
-	"github.com/ygo-skc/skc-go/common/v3/parser"
 	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
 	"github.com/ygo-skc/skc-go/common/v3/ygo"
 	"github.com/ygo-skc/skc-suggestion-engine/db"
@@ -14,9 +12,10 @@ import (
 	"github.com/ygo-skc/skc-suggestion-engine/model"
 )
 
-var (
-	QuotedStringRegex = regexp.MustCompile("^(\"[ \\w\\d-:@,'.]{3,}?\"|'[ \\w\\d-:@,'.]{3,}?')|[\\W](\"[ \\w\\d-:@,'.]{3,}?\"|'[ \\w\\d-:@,'.]{3,}?')")
-)
+// Resolves card names w/o calling ygo-service. False is returned when names can't be resolved this way.
+type CardNameResolver interface {
+	CardsByName([]string) (cModel.CardDataMap, bool)
+}
 
 type UnparsedSuggestionData struct {
 	namedReferencesByToken cModel.CardDataMap
@@ -81,8 +80,11 @@ func ParseSuggestionData(cardName string, materialText string, effectText string
 	suggestions.MaterialArchetypes, nonArchetypeMaterialTokens = partitionTokensByCardText(materialText, usd)
 	suggestions.ReferencedArchetypes, nonArchetypeReferenceTokens = partitionTokensByCardText(effectText, usd)
 
-	parseTokenAsCard(nonArchetypeMaterialTokens, usd.namedReferencesByToken, &suggestions.NamedMaterials)
-	parseTokenAsCard(nonArchetypeReferenceTokens, usd.namedReferencesByToken, &suggestions.NamedReferences)
+	parseTokenAsCard(materialText, nonArchetypeMaterialTokens, usd.namedReferencesByToken, &suggestions.NamedMaterials)
+	parseTokenAsCard(effectText, nonArchetypeReferenceTokens, usd.namedReferencesByToken, &suggestions.NamedReferences)
+
+	aliases := FindAliases(effectText)
+	suggestions.TreatedAs, suggestions.NameAliases = aliases.TreatedAs, aliases.NameAliases
 
 	return suggestions
 }
@@ -93,49 +95,58 @@ func partitionTokensByCardText(cardText string, usd UnparsedSuggestionData) ([]s
 	seenArchetypeTokens := make(map[string]struct{}, len(usd.archetypeSet))
 	nonArchetypeTokens := make(map[string]int, len(usd.namedReferencesByToken))
 
-	for _, token := range QuotedStringRegex.FindAllString(cardText, -1) {
-		parser.CleanupToken(&token)
-		if _, exists := usd.archetypeSet[token]; exists {
-			if _, seen := seenArchetypeTokens[token]; !seen {
-				seenArchetypeTokens[token] = struct{}{}
-				archetypeTokens = append(archetypeTokens, token)
+	for _, token := range Tokenize(cardText) {
+		// aliases describe the card itself - see FindAliases
+		if token.Context == TreatedAsContext || token.Context == NameAliasContext {
+			continue
+		}
+
+		if _, exists := usd.archetypeSet[token.Text]; exists {
+			if _, seen := seenArchetypeTokens[token.Text]; !seen {
+				seenArchetypeTokens[token.Text] = struct{}{}
+				archetypeTokens = append(archetypeTokens, token.Text)
 			}
 		}
 
-		if _, exists := usd.namedReferencesByToken[token]; exists {
-			nonArchetypeTokens[token]++
+		// eg: a "Dark Magician" monster refers to the series, not the card
+		if _, exists := usd.namedReferencesByToken[token.Text]; exists && !token.ArchetypeUsage {
+			nonArchetypeTokens[token.Text]++
 		}
 	}
 	return archetypeTokens, nonArchetypeTokens
 }
 
-// creates the suggestion references and their occurrence
-func parseTokenAsCard(tokenOccurrences map[string]int, namedReferencesByToken cModel.CardDataMap, references *[]model.CardReference) {
+// creates the suggestion references, their occurrence and their intent in cardText
+func parseTokenAsCard(cardText string, tokenOccurrences map[string]int, namedReferencesByToken cModel.CardDataMap, references *[]model.CardReference) {
 	for token, occurrence := range tokenOccurrences {
-		*references = append(*references, model.CardReference{Occurrences: occurrence, Card: namedReferencesByToken[token]})
+		*references = append(*references, model.CardReference{Occurrences: occurrence, Card: namedReferencesByToken[token], Intents: ReferenceIntents(cardText, token)})
 	}
 }
 
-// cycles through tokens - makes DB calls where necessary and attempts to build objects containing direct references (and their occurrences), archetype references
-func GenerateUnparsedSuggestionData(ctx context.Context, tokens []string, relevantArchetypes []string) UnparsedSuggestionData {
+// cycles through tokens (see TokenTexts) - makes DB calls where necessary and attempts to build objects containing direct references (and their occurrences), archetype references
+// Tokens are resolved to cards using resolver when possible, otherwise ygo-service is used.
+// If ygo-service fails, the error is returned along with data that has no named references - archetypes can still be parsed using it.
+func GenerateUnparsedSuggestionData(ctx context.Context, tokens []string, relevantArchetypes []string,
+	resolver CardNameResolver) (UnparsedSuggestionData, *cModel.APIError) {
 	usd := UnparsedSuggestionData{namedReferencesByToken: cModel.CardDataMap{}, archetypeSet: make(map[string]struct{})}
 
 	for _, archetype := range relevantArchetypes {
 		usd.archetypeSet[archetype] = struct{}{}
 	}
 
-	totalTokens := len(tokens)
-
-	if totalTokens != 0 {
-		for i := range totalTokens {
-			parser.CleanupToken(&tokens[i])
+	if len(tokens) != 0 {
+		if resolver != nil {
+			if cards, isResolved := resolver.CardsByName(tokens); isResolved {
+				usd.namedReferencesByToken = cards
+				return usd, nil
+			}
 		}
 
-		cardsProto, _ := downstream.YGO.CardService.GetCardsByNameProto(ctx, tokens) // TODO: handle error
-		var batchCardData *cModel.BatchCardData[cModel.CardNames]
-		if cardsProto != nil {
-			batchCardData = cModel.BatchCardDataFromProto[cModel.CardNames](cardsProto, cModel.CardNameAsKey)
+		cardsProto, err := downstream.YGO.CardService.GetCardsByNameProto(ctx, tokens)
+		if err != nil {
+			return usd, err
 		}
+		batchCardData := cModel.BatchCardDataFromProto[cModel.CardNames](cardsProto, cModel.CardNameAsKey)
 
 		for _, token := range tokens {
 			if card, isPresent := batchCardData.CardInfo[token]; isPresent {
@@ -144,5 +155,5 @@ func GenerateUnparsedSuggestionData(ctx context.Context, tokens []string, releva
 		}
 	}
 
-	return usd
+	return usd, nil
 }
@@ -0,0 +1,166 @@
+package suggest
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	minTokenLength = 3
+	maxTokenLength = 100 // longest card names are ~70 characters, anything longer is most likely an unbalanced quote
+)
+
+type TokenContext string
+
+const (
+	PlainContext     TokenContext = "plain"
+	TreatedAsContext TokenContext = "treatedAs" // part of a "(This card is always treated as ...)" clause - the card itself, not a reference to another card
+	NameAliasContext TokenContext = "nameAlias" // follows "This card's name becomes" or "This card's name is always treated as" - another name for the card
+	MentionsContext  TokenContext = "mentions"  // part of a "... that mentions ..." list - references cards whose text contains the name
+)
+
+// Quoted name found in card text
+type Token struct {
+	Text           string
+	Start, End     int // byte offsets of Text within the normalized card text, quotes excluded
+	Context        TokenContext
+	ArchetypeUsage bool // token is followed by a card kind (eg: a "Blue-Eyes" monster) so it's an archetype/series, not a card name
+}
+
+var (
+	quoteReplacer = strings.NewReplacer("“", `"`, "”", `"`, "‘", "'", "’", "'") // curly quotes are used in some card text
+
+	treatedAsClauseRegex = regexp.MustCompile(`\(This card is (?:also )?always treated as [^)]*\)`)
+	nameAliasRegex       = regexp.MustCompile(`[Tt]his card's name (?:becomes|is (?:also )?always treated as) ["']`) // only the token right after the phrase is an alias
+	mentionsClauseRegex  = regexp.MustCompile(`\bmentions? [^.;:]*`)
+	cardKindRegex        = regexp.MustCompile(`^ (?:(?:Normal|Effect|Fusion|Ritual|Synchro|Xyz|Pendulum|Link|Tuner|Union|Gemini|Spirit|Flip|Toon|Continuous|Quick-Play|Field|Equip|Counter) )*(?:[Mm]onsters?|[Cc]ards?|Spells?|Traps?|Spell/Traps?|Spell Cards?|Trap Cards?)\b`)
+)
+
+// Finds quoted names in card text following Konami conventions:
+//   - names can be wrapped in double or single quotes (older text) - curly quotes are treated as straight quotes
+//   - names can contain apostrophes (eg: 'Iron Core of Koa'ki Meiru') and punctuation (eg: "D/D/D", "Danger!? Tsuchinoko?", "Evil★Twin Ki-sikil")
+//   - names can contain quoted text, eg: "The "Hero" Card" - the outer name is returned
+//
+// Tokens are returned in the order they appear.
+func Tokenize(text string) []Token {
+	text = quoteReplacer.Replace(text)
+	tokens := make([]Token, 0)
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if (r == '"' || r == '\'') && opensQuote(text, i) {
+			if end := closingQuote(text, i+size, byte(r)); end != -1 {
+				if name := strings.TrimSpace(text[i+size : end]); utf8.RuneCountInString(name) >= minTokenLength && len(name) <= maxTokenLength {
+					start := i + size + strings.Index(text[i+size:end], name)
+					tokens = append(tokens, Token{Text: name, Start: start, End: start + len(name), Context: PlainContext,
+						ArchetypeUsage: cardKindRegex.MatchString(text[end+1:])})
+				}
+				i = end + 1
+				continue
+			}
+		}
+		i += size
+	}
+
+	markNameAliases(text, tokens)
+	markClause(text, tokens, treatedAsClauseRegex, TreatedAsContext)
+	markClause(text, tokens, mentionsClauseRegex, MentionsContext)
+	return tokens
+}
+
+// Names a card is also known as according to its own text
+type Aliases struct {
+	TreatedAs   []string // archetypes the card is always treated as part of, eg: (This card is always treated as a "Magician" card.)
+	NameAliases []string // names the card's name becomes or is always treated as, eg: This card's name becomes "The Legendary Fisherman" while on the field
+}
+
+// Older text uses "(This card is always treated as "X".)" for names - names in a treated as clause that aren't followed by a card kind are considered name aliases.
+func FindAliases(text string) Aliases {
+	aliases := Aliases{TreatedAs: make([]string, 0), NameAliases: make([]string, 0)}
+	for _, token := range Tokenize(text) {
+		switch {
+		case token.Context == TreatedAsContext && token.ArchetypeUsage:
+			if !slices.Contains(aliases.TreatedAs, token.Text) {
+				aliases.TreatedAs = append(aliases.TreatedAs, token.Text)
+			}
+		case token.Context == TreatedAsContext || token.Context == NameAliasContext:
+			if !slices.Contains(aliases.NameAliases, token.Text) {
+				aliases.NameAliases = append(aliases.NameAliases, token.Text)
+			}
+		}
+	}
+	return aliases
+}
+
+// unique token text in the order they are first found
+func TokenTexts(text string) []string {
+	seen := make(map[string]struct{})
+	texts := make([]string, 0)
+	for _, token := range Tokenize(text) {
+		if _, isPresent := seen[token.Text]; !isPresent {
+			seen[token.Text] = struct{}{}
+			texts = append(texts, token.Text)
+		}
+	}
+	return texts
+}
+
+// a quote opens a name when it isn't part of a word - eg: the apostrophe in Koa'ki doesn't
+func opensQuote(text string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, _ := utf8.DecodeLastRuneInString(text[:i])
+	return !unicode.IsLetter(prev) && !unicode.IsDigit(prev)
+}
+
+// a quote closes a name when it isn't followed by a letter or digit - names containing quoted text open a nested level that needs to be closed first.
+// -1 is returned if the name is never closed on the same line
+func closingQuote(text string, from int, quote byte) int {
+	depth := 0
+	for i := from; i < len(text); i++ {
+		switch {
+		case text[i] == '\n':
+			return -1
+		case text[i] != quote:
+			continue
+		case i+1 < len(text) && isWordStart(text[i+1:]) && opensQuote(text, i):
+			depth++ // nested quote, eg: the first quote around Hero in "The "Hero" Card"
+		case i+1 < len(text) && isWordStart(text[i+1:]):
+			continue // apostrophe within a word
+		case depth > 0:
+			depth--
+		default:
+			return i
+		}
+	}
+	return -1
+}
+
+func isWordStart(text string) bool {
+	r, _ := utf8.DecodeRuneInString(text)
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func markNameAliases(text string, tokens []Token) {
+	for _, phrase := range nameAliasRegex.FindAllStringIndex(text, -1) {
+		for i := range tokens {
+			if tokens[i].Start == phrase[1] {
+				tokens[i].Context = NameAliasContext
+			}
+		}
+	}
+}
+
+func markClause(text string, tokens []Token, clauseRegex *regexp.Regexp, context TokenContext) {
+	for _, clause := range clauseRegex.FindAllStringIndex(text, -1) {
+		for i := range tokens {
+			if tokens[i].Context == PlainContext && tokens[i].Start >= clause[0] && tokens[i].End <= clause[1] {
+				tokens[i].Context = context
+			}
+		}
+	}
+}
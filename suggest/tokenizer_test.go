@@ -0,0 +1,63 @@
+package suggest
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// expected tokens of a card in testdata/tokenizer_corpus.json - context defaults to plain
+type corpusToken struct {
+	Text           string       `json:"text"`
+	Context        TokenContext `json:"context"`
+	ArchetypeUsage bool         `json:"archetypeUsage"`
+}
+
+type corpusEntry struct {
+	Name   string        `json:"name"`
+	Text   string        `json:"text"`
+	Tokens []corpusToken `json:"tokens"`
+}
+
+func TestTokenizeCorpus(t *testing.T) {
+	contents, err := os.ReadFile("testdata/tokenizer_corpus.json")
+	if err != nil {
+		t.Fatalf("Could not read corpus: %v", err)
+	}
+
+	var corpus []corpusEntry
+	if err := json.Unmarshal(contents, &corpus); err != nil {
+		t.Fatalf("Could not parse corpus: %v", err)
+	}
+
+	for _, entry := range corpus {
+		t.Run(entry.Name, func(t *testing.T) {
+			actual := make([]corpusToken, 0)
+			for _, token := range Tokenize(entry.Text) {
+				actual = append(actual, corpusToken{Text: token.Text, Context: token.Context, ArchetypeUsage: token.ArchetypeUsage})
+			}
+
+			expected := make([]corpusToken, len(entry.Tokens))
+			for i, token := range entry.Tokens {
+				if token.Context == "" {
+					token.Context = PlainContext
+				}
+				expected[i] = token
+			}
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestTokenOffsets(t *testing.T) {
+	assert := assert.New(t)
+
+	text := "Add 1 “Polymerization” from your Deck."
+	tokens := Tokenize(text)
+	assert.Len(tokens, 1)
+	assert.Equal("Polymerization", quoteReplacer.Replace(text)[tokens[0].Start:tokens[0].End], "Offsets should point to the normalized text")
+
+	assert.Equal([]string{"Dark Magician", "Buster Blader"}, TokenTexts(`"Dark Magician" + "Buster Blader", "Dark Magician" must be on the field.`))
+}
@@ -21,11 +21,21 @@ func (impl SKCSuggestionEngineDAOImplementation) InsertTrafficData(ctx context.C
 }
 
 func (impl SKCSuggestionEngineDAOImplementation) GetTrafficData(
-	ctx context.Context, resourceName model.ResourceName, from time.Time, to time.Time) ([]model.TrafficResourceUtilizationMetric, *cModel.APIError) {
+	ctx context.Context, resourceName model.ResourceName, from time.Time, to time.Time, limit int) ([]model.TrafficResourceUtilizationMetric, *cModel.APIError) {
 	log.Fatalln("GetTrafficData() not mocked")
 	return nil, nil
 }
 
+func (impl SKCSuggestionEngineDAOImplementation) GetCardViews(ctx context.Context, from time.Time) (map[string]int, *cModel.APIError) {
+	log.Fatalln("GetCardViews() not mocked")
+	return nil, nil
+}
+
+func (impl SKCSuggestionEngineDAOImplementation) StreamTrafficAnalysis(ctx context.Context, from time.Time, fn func(model.TrafficAnalysis)) *cModel.APIError {
+	log.Fatalln("StreamTrafficAnalysis() not mocked")
+	return nil
+}
+
 func (impl SKCSuggestionEngineDAOImplementation) IsBlackListed(ctx context.Context, blackListType string, blackListPhrase string) (bool, *cModel.APIError) {
 	log.Fatalln("IsBlackListed() not mocked")
 	return false, nil
@@ -56,7 +66,97 @@ func (impl SKCSuggestionEngineDAOImplementation) GetRelevantArchetypes(ctx conte
 	return nil, nil
 }
 
-func (impl SKCSuggestionEngineDAOImplementation) VectorSearchOnCardEmbedding(ctx context.Context, subject cModel.YGOCard, queryVector []float32) ([]model.VectorSearchResult, *cModel.APIError) {
+func (impl SKCSuggestionEngineDAOImplementation) GetArchetypesByName(ctx context.Context, names []string) ([]string, *cModel.APIError) {
+	log.Fatalln("GetArchetypesByName() not mocked")
+	return nil, nil
+}
+
+func (impl SKCSuggestionEngineDAOImplementation) VectorSearchOnCardEmbedding(ctx context.Context, subject cModel.YGOCard, queryVector []float32, filter model.VectorSearchFilter) ([]model.VectorSearchResult, *cModel.APIError) {
 	log.Fatalln("VectorSearchOnCardEmbedding() not mocked")
 	return nil, nil
 }
+
+func (impl SKCSuggestionEngineDAOImplementation) VectorSearchUsingQuery(ctx context.Context, queryVector []float32) ([]model.VectorSearchResult, *cModel.APIError) {
+	log.Fatalln("VectorSearchUsingQuery() not mocked")
+	return nil, nil
+}
+
+func (impl SKCSuggestionEngineDAOImplementation) GetCardEmbedding(ctx context.Context, cardID string) (*model.CardEmbedding, *cModel.APIError) {
+	log.Fatalln("GetCardEmbedding() not mocked")
+	return nil, nil
+}
+
+func (impl SKCSuggestionEngineDAOImplementation) GetCardEmbeddingTexts(ctx context.Context, cardIDs cModel.CardIDs) (map[string]string, *cModel.APIError) {
+	log.Fatalln("GetCardEmbeddingTexts() not mocked")
+	return nil, nil
+}
+
+func (impl SKCSuggestionEngineDAOImplementation) UpsertCardEmbeddings(ctx context.Context, cardEmbeddings []model.CardEmbedding) *cModel.APIError {
+	log.Fatalln("UpsertCardEmbeddings() not mocked")
+	return nil
+}
+
+func (impl SKCSuggestionEngineDAOImplementation) GetCachedEmbedding(ctx context.Context, key string) ([]float32, *cModel.APIError) {
+	log.Fatalln("GetCachedEmbedding() not mocked")
+	return nil, nil
+}
+
+func (impl SKCSuggestionEngineDAOImplementation) InsertCachedEmbedding(ctx context.Context, cachedEmbedding model.CachedEmbedding) *cModel.APIError {
+	log.Fatalln("InsertCachedEmbedding() not mocked")
+	return nil
+}
+
+func (impl SKCSuggestionEngineDAOImplementation) GetTokenUsage(ctx context.Context, date string) (*model.DailyTokenUsage, *cModel.APIError) {
+	log.Fatalln("GetTokenUsage() not mocked")
+	return nil, nil
+}
+
+func (impl SKCSuggestionEngineDAOImplementation) UpsertTokenUsage(ctx context.Context, usage model.DailyTokenUsage) *cModel.APIError {
+	log.Fatalln("UpsertTokenUsage() not mocked")
+	return nil
+}
+
+func (impl SKCSuggestionEngineDAOImplementation) GetCardEmbeddingIDs(ctx context.Context) (cModel.CardIDs, *cModel.APIError) {
+	log.Fatalln("GetCardEmbeddingIDs() not mocked")
+	return nil, nil
+}
+
+func (impl SKCSuggestionEngineDAOImplementation) GetCardReleaseDates(ctx context.Context, cardIDs cModel.CardIDs) (map[string]string, *cModel.APIError) {
+	log.Fatalln("GetCardReleaseDates() not mocked")
+	return nil, nil
+}
+
+func (impl SKCSuggestionEngineDAOImplementation) UpdateCardReleaseDates(ctx context.Context, releaseDateByID map[string]string) *cModel.APIError {
+	log.Fatalln("UpdateCardReleaseDates() not mocked")
+	return nil
+}
+
+func (impl SKCSuggestionEngineDAOImplementation) UpdateCardLevels(ctx context.Context, levelByID map[string]int) *cModel.APIError {
+	log.Fatalln("UpdateCardLevels() not mocked")
+	return nil
+}
+
+func (impl SKCSuggestionEngineDAOImplementation) DedupeCardEmbeddings(ctx context.Context, confirm bool) (*model.CardEmbeddingDedupe, *cModel.APIError) {
+	log.Fatalln("DedupeCardEmbeddings() not mocked")
+	return nil, nil
+}
+
+func (impl SKCSuggestionEngineDAOImplementation) GetReferenceIndex(ctx context.Context) ([]model.ReferenceIndexEntry, *cModel.APIError) {
+	log.Fatalln("GetReferenceIndex() not mocked")
+	return nil, nil
+}
+
+func (impl SKCSuggestionEngineDAOImplementation) ReplaceReferenceIndex(ctx context.Context, entries []model.ReferenceIndexEntry) *cModel.APIError {
+	log.Fatalln("ReplaceReferenceIndex() not mocked")
+	return nil
+}
+
+func (impl SKCSuggestionEngineDAOImplementation) GetAlsoViewed(ctx context.Context, resourceName model.ResourceName, resourceID string) (*model.AlsoViewedEntry, *cModel.APIError) {
+	log.Fatalln("GetAlsoViewed() not mocked")
+	return nil, nil
+}
+
+func (impl SKCSuggestionEngineDAOImplementation) ReplaceAlsoViewed(ctx context.Context, entries []model.AlsoViewedEntry) *cModel.APIError {
+	log.Fatalln("ReplaceAlsoViewed() not mocked")
+	return nil
+}
@@ -2,6 +2,8 @@ package testing
 
 import (
 	"context"
+	"slices"
+	"strings"
 
 	"github.com/ygo-skc/skc-go/common/v3/model"
 	"github.com/ygo-skc/skc-go/common/v3/ygo"
@@ -79,7 +81,14 @@ func (svc YGOCardClientMock) GetCardsByName(ctx context.Context, cardNames model
 }
 
 func (svc YGOCardClientMock) GetCardsReferencingNameInEffectProto(ctx context.Context, cards []string) (*ygo.CardList, *model.APIError) {
-	panic(ni)
+	references := make([]*ygo.Card, 0)
+	for _, card := range CardMocks {
+		if slices.ContainsFunc(cards, func(cardName string) bool { return card.Name != cardName && strings.Contains(card.Effect, cardName) }) {
+			references = append(references, card.ToProto())
+		}
+	}
+
+	return &ygo.CardList{Cards: references}, nil
 }
 
 func (svc YGOCardClientMock) GetCardsReferencingNameInEffect(ctx context.Context, cards []string) ([]model.YGOCard, *model.APIError) {
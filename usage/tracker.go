@@ -0,0 +1,268 @@
+package usage
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"maps"
+	"strconv"
+	"sync"
+	"time"
+
+	cModel "github.com/ygo-skc/skc-go/common/v3/model"
+	cUtil "github.com/ygo-skc/skc-go/common/v3/util"
+	"github.com/ygo-skc/skc-suggestion-engine/db"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+)
+
+const (
+	unknownOperation = "Unknown"
+	flushInterval    = 5 * time.Minute
+)
+
+type BudgetMode string
+
+const (
+	DegradedMode   BudgetMode = "degraded" // re-ranking is skipped once the budget is exceeded
+	CachedOnlyMode BudgetMode = "cached"   // re-ranking is skipped and only cached embeddings can be used once the budget is exceeded
+)
+
+type operationKey struct{}
+
+// Usage recorded using the returned context is attributed to op - should be the same operation name used with cUtil.InitRequest
+func WithOperation(ctx context.Context, op string) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+func operation(ctx context.Context) string {
+	if op, ok := ctx.Value(operationKey{}).(string); ok {
+		return op
+	}
+	return unknownOperation
+}
+
+// Aggregates tokens used by embedding/rerank providers for the current day (UTC). Totals are periodically persisted so they survive restarts.
+type Tracker struct {
+	mu    sync.Mutex
+	today model.DailyTokenUsage
+	dirty bool // today has changes that weren't persisted
+
+	budget              int // max tokens per day, 0 = no budget
+	mode                BudgetMode
+	embeddingCostPerMil float64 // USD per million tokens
+	rerankCostPerMil    float64
+
+	dao db.SKCSuggestionEngineDAO // nil = usage is not persisted
+	now func() time.Time
+}
+
+// Tracker used by embedding and rerank clients
+var DailyTracker = NewTracker(nil, 0, DegradedMode, 0, 0)
+
+func NewTracker(dao db.SKCSuggestionEngineDAO, budget int, mode BudgetMode, embeddingCostPerMil float64, rerankCostPerMil float64) *Tracker {
+	t := &Tracker{budget: budget, mode: mode, embeddingCostPerMil: embeddingCostPerMil, rerankCostPerMil: rerankCostPerMil, dao: dao, now: time.Now}
+	t.today = newDailyTokenUsage(t.date())
+	return t
+}
+
+// Uses DAILY_TOKEN_BUDGET (0 or empty = no budget), TOKEN_BUDGET_MODE (degraded or cached), EMBEDDING_COST_PER_MILLION_TOKENS and RERANK_COST_PER_MILLION_TOKENS env variables to configure the tracker.
+func NewTrackerFromEnv(dao db.SKCSuggestionEngineDAO) *Tracker {
+	budget := 0
+	if value := cUtil.EnvMap["DAILY_TOKEN_BUDGET"]; value != "" {
+		var err error
+		if budget, err = strconv.Atoi(value); err != nil {
+			log.Fatalf("DAILY_TOKEN_BUDGET is not a number: %v", err)
+		}
+	}
+
+	mode := BudgetMode(cUtil.EnvMap["TOKEN_BUDGET_MODE"])
+	switch mode {
+	case "":
+		mode = DegradedMode
+	case DegradedMode, CachedOnlyMode:
+	default:
+		log.Fatalf("Unknown TOKEN_BUDGET_MODE: %s", mode)
+	}
+
+	costs := make([]float64, 2)
+	for i, key := range []string{"EMBEDDING_COST_PER_MILLION_TOKENS", "RERANK_COST_PER_MILLION_TOKENS"} {
+		if value := cUtil.EnvMap[key]; value != "" {
+			var err error
+			if costs[i], err = strconv.ParseFloat(value, 64); err != nil {
+				log.Fatalf("%s is not a number: %v", key, err)
+			}
+		}
+	}
+
+	slog.Info("Configured token usage tracker", slog.Int("daily_budget", budget), slog.String("budget_mode", string(mode)))
+	return NewTracker(dao, budget, mode, costs[0], costs[1])
+}
+
+func newDailyTokenUsage(date string) model.DailyTokenUsage {
+	return model.DailyTokenUsage{Date: date, Operations: make(map[string]model.OperationTokenUsage)}
+}
+
+func (t *Tracker) date() string {
+	return t.now().UTC().Format(time.DateOnly)
+}
+
+// Loads usage persisted earlier in the day - otherwise a restart would reset the budget
+func (t *Tracker) Load(ctx context.Context) {
+	if t.dao == nil {
+		return
+	}
+
+	date := t.date()
+	persisted, err := t.dao.GetTokenUsage(ctx, date)
+	if err != nil {
+		cUtil.RetrieveLogger(ctx).Warn("Could not load persisted token usage", slog.Any("err", err))
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if persisted != nil && t.today.Date == date {
+		if persisted.Operations == nil {
+			persisted.Operations = make(map[string]model.OperationTokenUsage)
+		}
+		t.today = *persisted
+	}
+}
+
+// Persists usage every few minutes until ctx is cancelled
+func (t *Tracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			t.Flush(context.WithoutCancel(ctx))
+			return
+		case <-ticker.C:
+			t.Flush(ctx)
+		}
+	}
+}
+
+// Records tokens used by a provider call against the operation found in ctx
+func (t *Tracker) Record(ctx context.Context, kind model.UsageKind, tokens int) {
+	op := operation(ctx)
+
+	t.mu.Lock()
+	previous := t.rollover()
+
+	opUsage := t.today.Operations[op]
+	opUsage.Calls++
+	cost := t.embeddingCostPerMil
+	switch kind {
+	case model.EmbeddingUsage:
+		opUsage.EmbeddingTokens += tokens
+	case model.RerankUsage:
+		opUsage.RerankTokens += tokens
+		cost = t.rerankCostPerMil
+	}
+	t.today.Operations[op] = opUsage
+	t.today.TotalTokens += tokens
+	t.today.EstimatedCostUSD += float64(tokens) * cost / 1_000_000
+	t.today.UpdatedAt = t.now()
+	t.dirty = true
+	t.mu.Unlock()
+
+	if previous != nil {
+		t.persist(ctx, *previous)
+	}
+}
+
+// starts a new day if the date changed - the previous day is returned if it still needs to be persisted. Caller must hold t.mu
+func (t *Tracker) rollover() *model.DailyTokenUsage {
+	date := t.date()
+	if t.today.Date == date {
+		return nil
+	}
+
+	previous, wasDirty := t.today, t.dirty
+	t.today, t.dirty = newDailyTokenUsage(date), false
+	if wasDirty {
+		return &previous
+	}
+	return nil
+}
+
+// Persists today's usage if it changed since the last flush
+func (t *Tracker) Flush(ctx context.Context) {
+	t.mu.Lock()
+	previous := t.rollover()
+	var today *model.DailyTokenUsage
+	if t.dirty {
+		snapshot := t.snapshot()
+		today, t.dirty = &snapshot, false
+	}
+	t.mu.Unlock()
+
+	for _, usage := range []*model.DailyTokenUsage{previous, today} {
+		if usage != nil {
+			t.persist(ctx, *usage)
+		}
+	}
+}
+
+func (t *Tracker) persist(ctx context.Context, usage model.DailyTokenUsage) {
+	if t.dao == nil {
+		return
+	}
+	if err := t.dao.UpsertTokenUsage(ctx, usage); err != nil {
+		cUtil.RetrieveLogger(ctx).Warn("Could not persist token usage", slog.String("date", usage.Date), slog.Any("err", err))
+	}
+}
+
+// copy of today's usage that is safe to use after unlocking. Caller must hold t.mu
+func (t *Tracker) snapshot() model.DailyTokenUsage {
+	s := t.today
+	s.Operations = maps.Clone(t.today.Operations)
+	return s
+}
+
+func (t *Tracker) Today() model.TokenUsageReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	today := t.snapshot()
+	if date := t.date(); today.Date != date {
+		today = newDailyTokenUsage(date) // nothing recorded yet today, previous day is persisted by the next Record or Flush call
+	}
+	return model.TokenUsageReport{DailyTokenUsage: today, Budget: t.budget, BudgetExceeded: t.budgetExceeded()}
+}
+
+// Usage for any day (YYYY-MM-DD), nil if nothing was recorded. Budget is compared against the current budget.
+func (t *Tracker) Day(ctx context.Context, date string) (*model.TokenUsageReport, *cModel.APIError) {
+	if date == t.date() {
+		report := t.Today()
+		return &report, nil
+	}
+	if t.dao == nil {
+		return nil, nil
+	}
+
+	usage, err := t.dao.GetTokenUsage(ctx, date)
+	if err != nil || usage == nil {
+		return nil, err
+	}
+	return &model.TokenUsageReport{DailyTokenUsage: *usage, Budget: t.budget, BudgetExceeded: t.budget > 0 && usage.TotalTokens >= t.budget}, nil
+}
+
+func (t *Tracker) budgetExceeded() bool {
+	return t.budget > 0 && t.today.Date == t.date() && t.today.TotalTokens >= t.budget
+}
+
+// True once the tokens used today reach the daily budget - re-ranking should be skipped
+func (t *Tracker) BudgetExceeded() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.budgetExceeded()
+}
+
+// True when the budget is exceeded and only cached embeddings should be used
+func (t *Tracker) CachedOnly() bool {
+	return t.mode == CachedOnlyMode && t.BudgetExceeded()
+}
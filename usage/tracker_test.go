@@ -0,0 +1,86 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ygo-skc/skc-suggestion-engine/db"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
+	skc_testing "github.com/ygo-skc/skc-suggestion-engine/testing"
+)
+
+func TestRecordAttributesUsageToOperation(t *testing.T) {
+	assert := assert.New(t)
+	tracker := NewTracker(nil, 0, DegradedMode, 0.02, 0.05)
+
+	ctx := WithOperation(skc_testing.TestContext, "Similar Cards")
+	tracker.Record(ctx, model.EmbeddingUsage, 300)
+	tracker.Record(ctx, model.RerankUsage, 700)
+	tracker.Record(skc_testing.TestContext, model.EmbeddingUsage, 1_000_000)
+
+	today := tracker.Today()
+	assert.Equal(model.OperationTokenUsage{Calls: 2, EmbeddingTokens: 300, RerankTokens: 700}, today.Operations["Similar Cards"])
+	assert.Equal(model.OperationTokenUsage{Calls: 1, EmbeddingTokens: 1_000_000}, today.Operations[unknownOperation], "Usage without an operation should still be tracked")
+	assert.Equal(1_001_000, today.TotalTokens)
+	assert.InDelta(0.02+(300*0.02+700*0.05)/1_000_000, today.EstimatedCostUSD, 1e-9)
+}
+
+func TestBudget(t *testing.T) {
+	assert := assert.New(t)
+
+	tracker := NewTracker(nil, 1000, CachedOnlyMode, 0, 0)
+	tracker.Record(skc_testing.TestContext, model.EmbeddingUsage, 999)
+	assert.False(tracker.BudgetExceeded())
+	assert.False(tracker.CachedOnly())
+
+	tracker.Record(skc_testing.TestContext, model.RerankUsage, 1)
+	assert.True(tracker.BudgetExceeded())
+	assert.True(tracker.CachedOnly())
+	assert.True(tracker.Today().BudgetExceeded)
+
+	tracker = NewTracker(nil, 1000, DegradedMode, 0, 0)
+	tracker.Record(skc_testing.TestContext, model.EmbeddingUsage, 5000)
+	assert.True(tracker.BudgetExceeded())
+	assert.False(tracker.CachedOnly(), "Degraded mode should still allow embedding")
+
+	tracker = NewTracker(nil, 0, CachedOnlyMode, 0, 0)
+	tracker.Record(skc_testing.TestContext, model.EmbeddingUsage, 5000)
+	assert.False(tracker.BudgetExceeded(), "No budget should never be exceeded")
+}
+
+func TestUsageIsPersistedDaily(t *testing.T) {
+	assert := assert.New(t)
+	dao, _ := db.NewSKCSuggestionEngineDAOInMemory("")
+
+	now := time.Date(2026, 10, 17, 23, 59, 0, 0, time.UTC)
+	tracker := NewTracker(dao, 1000, DegradedMode, 0, 0)
+	tracker.now = func() time.Time { return now }
+	tracker.today = newDailyTokenUsage(tracker.date())
+
+	tracker.Record(skc_testing.TestContext, model.EmbeddingUsage, 1200)
+	assert.True(tracker.BudgetExceeded())
+
+	// next day - previous day is persisted and the budget resets
+	now = now.Add(2 * time.Minute)
+	tracker.Record(skc_testing.TestContext, model.EmbeddingUsage, 10)
+	assert.False(tracker.BudgetExceeded())
+
+	previousDay, _ := dao.GetTokenUsage(skc_testing.TestContext, "2026-10-17")
+	assert.Equal(1200, previousDay.TotalTokens)
+
+	report, _ := tracker.Day(skc_testing.TestContext, "2026-10-17")
+	assert.True(report.BudgetExceeded)
+
+	// restart - today's usage is loaded after being flushed
+	tracker.Flush(skc_testing.TestContext)
+	restarted := NewTracker(dao, 1000, DegradedMode, 0, 0)
+	restarted.now = tracker.now
+	restarted.today = newDailyTokenUsage(restarted.date())
+	restarted.Load(skc_testing.TestContext)
+	assert.Equal(10, restarted.Today().TotalTokens)
+
+	missing, err := tracker.Day(skc_testing.TestContext, "2026-01-01")
+	assert.Nil(err)
+	assert.Nil(missing)
+}
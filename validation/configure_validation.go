@@ -17,9 +17,13 @@ var (
 	Translator ut.Translator
 
 	cardIDRegex        = regexp.MustCompile(`^[0-9]{8}$`)
+	productIDRegex     = regexp.MustCompile(`^[0-9A-Z]{3,4}$`)
 	systemNameRegex    = regexp.MustCompile(`^[a-zA-Z0-9 \-]{3,}$`)
 	systemVersionRegex = regexp.MustCompile(`^([1-9]\d*|0)(\.(([1-9]\d*)|0)){2,3}$`)
 	archetypeRegex     = regexp.MustCompile(`^.{3,}$`)
+	searchQueryRegex   = regexp.MustCompile(`^.{3,200}$`)
+
+	cardAttributes = []string{"DARK", "LIGHT", "EARTH", "WATER", "FIRE", "WIND", "DIVINE", "SPELL", "TRAP"}
 )
 
 const (
@@ -29,7 +33,16 @@ const (
 	ipv4Validator             = "ipv4"
 	ArchetypeValidator        = "archetype"
 	ygoCardIDsValidator       = "ygocardids"
+	ygoProductIDsValidator    = "ygoproductids"
 	trendingResourceValidator = "trendingresource"
+	searchQueryValidator      = "searchquery"
+	cardAttributeValidator    = "cardattribute"
+	minValidator              = "min"
+	maxValidator              = "max"
+	lteFieldValidator         = "ltefield"
+	trendingWindowValidator   = "trendingwindow"
+	trendingCompareValidator  = "trendingcomparison"
+	lastWeekWindowValidator   = "lastweekwindow"
 )
 
 func init() {
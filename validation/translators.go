@@ -1,8 +1,14 @@
 package validation
 
 import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
+	"github.com/ygo-skc/skc-suggestion-engine/model"
 )
 
 // overrides a validators default message and sets it's error message
@@ -33,5 +39,21 @@ func configureTranslations() {
 	registerTranslation(ipv4Validator, "{0} should use ipv4 format.")
 	registerTranslation(ArchetypeValidator, "{0} should be valid archetype.")
 	registerTranslation(ygoCardIDsValidator, "One or more Card IDs are not in correct format. IDs are given to cards by Konami and are numeric with 8 digits.")
+	registerTranslation(ygoProductIDsValidator, "One or more Product IDs are not in correct format. IDs are given to products by Konami and are 3 or 4 uppercase letters/numbers.")
 	registerTranslation(trendingResourceValidator, "Trending resource can be one of two types: CARD, PRODUCT.")
+	registerTranslation(searchQueryValidator, "{0} should be between 3 and 200 characters.")
+	registerTranslation(cardAttributeValidator, "{0} should be one of: DARK, LIGHT, EARTH, WATER, FIRE, WIND, DIVINE, SPELL, TRAP.")
+	registerTranslation(minValidator, "{0} is below the minimum allowed value.")
+	registerTranslation(maxValidator, "{0} is above the maximum allowed value.")
+	registerTranslation(lteFieldValidator, "{0} is larger than the field it is limited by.")
+	registerTranslation(trendingWindowValidator, "{0} should be one of: "+strings.Join(trendingWindowNames(), ", ")+".")
+	registerTranslation(trendingCompareValidator, "{0} should be one of: previous, lastWeek.")
+	registerTranslation(lastWeekWindowValidator, "{0} lastWeek can only be used with windows of 7d or less.")
+}
+
+// shortest window first
+func trendingWindowNames() []string {
+	return slices.SortedFunc(maps.Keys(model.TrendingWindows), func(a, b string) int {
+		return cmp.Compare(model.TrendingWindows[a], model.TrendingWindows[b])
+	})
 }
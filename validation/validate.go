@@ -29,3 +29,58 @@ func ValidateBatchCardIDs(bci cModel.BatchCardIDs) *ValidationErrors {
 	}
 	return nil
 }
+
+func ValidateCardEmbeddingIngestionRequest(r model.CardEmbeddingIngestionRequest) *ValidationErrors {
+	if err := V.Struct(r); err != nil {
+		if ve, ok := err.(validator.ValidationErrors); ok {
+			return HandleValidationErrors(ve)
+		}
+		slog.Error("Unexpected error while validating input", slog.Any("err", err))
+		return nil
+	}
+	return nil
+}
+
+func ValidateCardSearchQuery(q model.CardSearchQuery) *ValidationErrors {
+	if err := V.Struct(q); err != nil {
+		if ve, ok := err.(validator.ValidationErrors); ok {
+			return HandleValidationErrors(ve)
+		}
+		slog.Error("Unexpected error while validating input", slog.Any("err", err))
+		return nil
+	}
+	return nil
+}
+
+func ValidateSimilarCardsFilter(f model.SimilarCardsFilter) *ValidationErrors {
+	if err := V.Struct(f); err != nil {
+		if ve, ok := err.(validator.ValidationErrors); ok {
+			return HandleValidationErrors(ve)
+		}
+		slog.Error("Unexpected error while validating input", slog.Any("err", err))
+		return nil
+	}
+	return nil
+}
+
+func ValidateCardAnalysisRequest(r model.CardAnalysisRequest) *ValidationErrors {
+	if err := V.Struct(r); err != nil {
+		if ve, ok := err.(validator.ValidationErrors); ok {
+			return HandleValidationErrors(ve)
+		}
+		slog.Error("Unexpected error while validating input", slog.Any("err", err))
+		return nil
+	}
+	return nil
+}
+
+func ValidateTrendingQuery(q model.TrendingQuery) *ValidationErrors {
+	if err := V.Struct(q); err != nil {
+		if ve, ok := err.(validator.ValidationErrors); ok {
+			return HandleValidationErrors(ve)
+		}
+		slog.Error("Unexpected error while validating input", slog.Any("err", err))
+		return nil
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package validation
 
 import (
 	"log/slog"
+	"slices"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 	cModel "github.com/ygo-skc/skc-go/common/v3/model"
@@ -35,7 +37,46 @@ func configureCustomValidators() {
 		return true
 	})
 
+	V.RegisterValidation(ygoProductIDsValidator, func(fl validator.FieldLevel) bool {
+		productIDs := fl.Field().Interface().(cModel.ProductIDs)
+
+		for _, productID := range productIDs {
+			if !productIDRegex.MatchString(productID) {
+				slog.Info("Product ID not in proper format")
+				return false
+			}
+		}
+
+		return true
+	})
+
 	V.RegisterValidation(trendingResourceValidator, func(fl validator.FieldLevel) bool {
 		return fl.Field().String() == string(model.CardResource) || fl.Field().String() == string(model.ProductResource)
 	})
+
+	V.RegisterValidation(cardAttributeValidator, func(fl validator.FieldLevel) bool {
+		return slices.Contains(cardAttributes, strings.ToUpper(fl.Field().String()))
+	})
+
+	V.RegisterValidation(trendingWindowValidator, func(fl validator.FieldLevel) bool {
+		_, isPresent := model.TrendingWindows[fl.Field().String()]
+		return isPresent
+	})
+
+	V.RegisterValidation(trendingCompareValidator, func(fl validator.FieldLevel) bool {
+		compare := model.TrendingComparison(fl.Field().String())
+		return compare == model.PreviousWindowComparison || compare == model.LastWeekComparison
+	})
+
+	// comparing to last week only makes sense when the two periods don't overlap
+	V.RegisterStructValidation(func(sl validator.StructLevel) {
+		tq := sl.Current().Interface().(model.TrendingQuery)
+		if window, isPresent := model.TrendingWindows[tq.Window]; isPresent && tq.Compare == model.LastWeekComparison && window > model.MaxLastWeekComparisonWindow {
+			sl.ReportError(tq.Compare, "Compare", "Compare", lastWeekWindowValidator, "")
+		}
+	}, model.TrendingQuery{})
+
+	V.RegisterValidation(searchQueryValidator, func(fl validator.FieldLevel) bool {
+		return searchQueryRegex.MatchString(fl.Field().String())
+	})
 }